@@ -34,6 +34,7 @@ func init() {
 				RawID:     true,
 			},
 		},
+		NoExport: true,
 		Fields: []resource.FieldDef{
 			{StructField: "Name", Flag: "name", Short: "n", Type: resource.String, Required: true, Description: "Address name"},
 			{StructField: "Type", Flag: "type", Type: resource.String, Required: true, Description: "Address type"},
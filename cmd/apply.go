@@ -0,0 +1,137 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"terrakube/internal/apierror"
+	"terrakube/internal/resource"
+)
+
+const applyLong = `
+Create, update or delete resources from YAML or JSON manifests.
+
+Each manifest names the kind of resource (any resource command, such as
+organization, workspace or variable), the parents it lives under, by name or
+ID, and a spec using the flag names of the create command:
+
+  kind: workspace
+  parents:
+    organization: acme
+  spec:
+    name: production-vpc
+    source: https://github.com/acme/infra.git
+    branch: main
+
+Existing resources are found by name (or key, path or version for the kinds
+identified by those fields) and only updated when the spec differs. Kinds
+without such a field, such as schedules, match a resource with the same spec.
+Set id to target a resource by ID. Manifests are applied parents first, so a
+file can create an organization and the workspaces in it. YAML files may hold
+several documents separated by ---.
+
+Fields that hold the ID of another resource, such as the template-id of a
+schedule, also accept its name. Sensitive fields set to <secret>, as written
+by export, are left unchanged; replace the placeholder to create the resource.
+
+Set delete: true in a manifest to delete its resource instead. Deletions are
+applied last, children first.
+
+Manifests are applied one at a time, stopping at the first failure. With
+--atomic they are sent as one JSON:API atomic operations request instead, so
+that either every change lands or none does. Resources created in the request
+are referred to by local ID from the manifests under them, but fields such as
+the template-id of a schedule can only name resources that already exist.
+`
+
+var applyExamples = `
+Apply every manifest in a directory
+  %[1]v apply -f manifests/
+
+Apply manifests from stdin
+  cat workspace.yaml | %[1]v apply -f -
+
+Apply a workspace and its variables together or not at all
+  %[1]v apply -f workspace/ --atomic
+`
+
+var applyCmd = &cobra.Command{
+	Use:          "apply -f PATH",
+	Short:        "create, update or delete resources from manifests",
+	Long:         applyLong,
+	Example:      fmt.Sprintf(applyExamples, rootCmd.Use),
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		paths, _ := cmd.Flags().GetStringArray("filename")
+		manifests, err := resource.ReadManifests(paths)
+		if err != nil {
+			return err
+		}
+		if len(manifests) == 0 {
+			return fmt.Errorf("no manifests found")
+		}
+
+		client, err := newClient()
+		if err != nil {
+			return err
+		}
+		if atomic, _ := cmd.Flags().GetBool("atomic"); !atomic {
+			return resource.Apply(getContext(), client, manifests, os.Stdout)
+		}
+
+		batch, err := resource.CompileAtomic(getContext(), client, manifests)
+		if err != nil {
+			return err
+		}
+		if err := resource.SubmitAtomic(getContext(), client, batch, os.Stdout); err != nil {
+			return atomicError(batch, err)
+		}
+		return nil
+	},
+}
+
+// atomicOperationsPointer prefixes the source pointers of the errors about
+// one operation of an atomic request.
+const atomicOperationsPointer = "/atomic:operations/"
+
+// atomicError explains a failed atomic request by the manifests of the
+// operations its errors point at.
+func atomicError(batch *resource.AtomicBatch, err error) error {
+	err = apiError(err)
+	var apiErr *apierror.Error
+	if !errors.As(err, &apiErr) {
+		return err
+	}
+	var lines []string
+	for _, d := range apiErr.Errors {
+		if d.Source == nil {
+			continue
+		}
+		rest, ok := strings.CutPrefix(d.Source.Pointer, atomicOperationsPointer)
+		index, _, _ := strings.Cut(rest, "/")
+		i, convErr := strconv.Atoi(index)
+		ch, found := batch.Operation(i)
+		if !ok || convErr != nil || !found {
+			continue
+		}
+		lines = append(lines, fmt.Sprintf("  %s (%s): %s", ch.Name, ch.Manifest.Source, d.String()))
+	}
+	if len(lines) == 0 {
+		return fmt.Errorf("nothing was applied: %w", err)
+	}
+	return fmt.Errorf("nothing was applied:\n%s\n%w", strings.Join(lines, "\n"), err)
+}
+
+func init() {
+	applyCmd.Flags().StringArrayP("filename", "f", nil, "Manifest file or directory, - for stdin (repeatable)")
+	_ = applyCmd.MarkFlagRequired("filename")
+	applyCmd.Flags().Bool("atomic", false, "Submit every change in one atomic request, so that all or none are applied")
+
+	rootCmd.AddCommand(applyCmd)
+}
@@ -0,0 +1,163 @@
+package cmd
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/jsonapi"
+	terrakube "github.com/terrakube-io/terrakube-go"
+
+	"terrakube/testutil"
+)
+
+func TestCmdApplyE2E(t *testing.T) {
+	resetGlobalFlags()
+	org := testutil.FixtureOrganization()
+
+	var created terrakube.Workspace
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/vnd.api+json")
+		switch {
+		case r.Method == http.MethodGet && strings.HasSuffix(r.URL.Path, "/organization"):
+			_ = jsonapi.MarshalPayload(w, []*terrakube.Organization{org})
+		case r.Method == http.MethodGet && strings.HasSuffix(r.URL.Path, "/workspace"):
+			_ = jsonapi.MarshalPayload(w, []*terrakube.Workspace{})
+		case r.Method == http.MethodPost && strings.HasSuffix(r.URL.Path, "/organization/"+org.ID+"/workspace"):
+			body, _ := io.ReadAll(r.Body)
+			if err := jsonapi.UnmarshalPayload(strings.NewReader(string(body)), &created); err != nil {
+				t.Errorf("decoding workspace: %v", err)
+			}
+			created.ID = "d4e5f6a7-b8c9-0123-defa-234567890123"
+			w.WriteHeader(http.StatusCreated)
+			_ = jsonapi.MarshalPayload(w, &created)
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL)
+		}
+	})
+	ts := setupTestServer(handler)
+	defer ts.Close()
+
+	dir := t.TempDir()
+	manifest := `kind: organization
+spec:
+  name: ` + org.Name + `
+---
+kind: workspace
+parents:
+  organization: ` + org.Name + `
+spec:
+  name: production-vpc
+  source: https://github.com/acme-corp/infra.git
+  branch: main
+`
+	if err := os.WriteFile(filepath.Join(dir, "infra.yaml"), []byte(manifest), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := executeCommand("apply", "-f", dir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := "organization/" + org.Name + " unchanged\nworkspace/production-vpc created\n"
+	if !strings.Contains(out, want) {
+		t.Errorf("expected output %q, got: %s", want, out)
+	}
+	if created.Name != "production-vpc" || created.Branch != "main" {
+		t.Errorf("unexpected workspace created: %+v", created)
+	}
+}
+
+const atomicManifests = `kind: workspace
+parents:
+  organization: acme-corp
+spec:
+  name: staging-vpc
+---
+kind: variable
+parents:
+  organization: acme-corp
+  workspace: staging-vpc
+spec:
+  key: AWS_REGION
+  value: eu-west-1
+  category: ENV
+`
+
+// atomicServer serves the fixture organization, no workspaces, and answers
+// atomic requests with respond.
+func atomicServer(t *testing.T, respond func(w http.ResponseWriter, req terrakube.AtomicRequest)) {
+	t.Helper()
+	org := testutil.FixtureOrganization()
+	ts := setupTestServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/vnd.api+json")
+		switch {
+		case r.Method == http.MethodGet && strings.HasSuffix(r.URL.Path, "/organization"):
+			_ = jsonapi.MarshalPayload(w, []*terrakube.Organization{org})
+		case r.Method == http.MethodGet && strings.HasSuffix(r.URL.Path, "/workspace"):
+			_ = jsonapi.MarshalPayload(w, []*terrakube.Workspace{})
+		case r.Method == http.MethodPost && strings.HasSuffix(r.URL.Path, "/operations"):
+			var req terrakube.AtomicRequest
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				t.Errorf("decoding atomic request: %v", err)
+			}
+			respond(w, req)
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL)
+		}
+	}))
+	t.Cleanup(ts.Close)
+}
+
+func TestCmdApplyAtomicE2E(t *testing.T) {
+	resetGlobalFlags()
+	orgID := testutil.FixtureOrganization().ID
+	var got terrakube.AtomicRequest
+	atomicServer(t, func(w http.ResponseWriter, req terrakube.AtomicRequest) {
+		got = req
+		_, _ = io.WriteString(w, `{"atomic:results":[{"data":{"type":"workspace","id":"w-1"}},{"data":{"type":"variable","id":"v-1"}}]}`)
+	})
+
+	out, err := executeCommand("apply", "-f", writeManifest(t, atomicManifests), "--atomic")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := "workspace/staging-vpc created\nvariable/AWS_REGION created\n"; !strings.Contains(out, want) {
+		t.Errorf("expected output %q, got: %s", want, out)
+	}
+
+	if len(got.Operations) != 2 {
+		t.Fatalf("expected 2 operations, got %+v", got.Operations)
+	}
+	ws, variable := got.Operations[0], got.Operations[1]
+	if ws.Op != "add" || ws.Href != "/organization/"+orgID+"/workspace" || !strings.Contains(string(ws.Data), `"lid":"lid-1"`) {
+		t.Errorf("unexpected workspace operation: %s %s %s", ws.Op, ws.Href, ws.Data)
+	}
+	if variable.Href != "/organization/"+orgID+"/workspace/lid-1/variable" || !strings.Contains(string(variable.Data), `"key":"AWS_REGION"`) {
+		t.Errorf("expected the variable to be added under the new workspace, got %s %s", variable.Href, variable.Data)
+	}
+}
+
+func TestCmdApplyAtomicFailure(t *testing.T) {
+	resetGlobalFlags()
+	atomicServer(t, func(w http.ResponseWriter, _ terrakube.AtomicRequest) {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		_, _ = io.WriteString(w, `{"errors":[{"detail":"Invalid category","source":{"pointer":"/atomic:operations/1/data/attributes/category"}}]}`)
+	})
+	path := writeManifest(t, atomicManifests)
+
+	out, err := executeCommand("apply", "-f", path, "--atomic")
+	if err == nil {
+		t.Fatalf("expected an error, got output: %s", out)
+	}
+	if want := "variable/AWS_REGION (" + path + " (document 2)): Invalid category"; !strings.Contains(err.Error(), want) {
+		t.Errorf("expected the error to point at the manifest with %q, got: %v", want, err)
+	}
+	if strings.Contains(out, "created") {
+		t.Errorf("expected nothing to be reported as created, got: %s", out)
+	}
+}
@@ -0,0 +1,132 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+const authStatusLong = `
+Show the Terrakube server the CLI talks to and where each connection setting
+comes from: a flag, an environment variable or the config file. The token is
+checked against the server unless nothing is configured.
+
+The command exits with status 3 when no API URL or token is configured.
+`
+
+var authStatusExamples = `
+Show the connection settings of the current profile
+  %[1]v auth status
+
+Check the credentials of another context
+  %[1]v auth status --context prod --output yaml
+`
+
+// authStatus is the output of "auth status". Tokens are never shown.
+type authStatus struct {
+	Context        string `json:"context,omitempty" yaml:"context,omitempty"`
+	ContextSource  string `json:"context_source,omitempty" yaml:"context_source,omitempty"`
+	Endpoint       string `json:"endpoint" yaml:"endpoint"`
+	EndpointSource string `json:"endpoint_source" yaml:"endpoint_source"`
+	TokenSource    string `json:"token_source" yaml:"token_source"`
+	TokenValid     bool   `json:"token_valid" yaml:"token_valid"`
+	Error          string `json:"error,omitempty" yaml:"error,omitempty"`
+}
+
+var authCmd = &cobra.Command{
+	Use:   "auth status",
+	Short: "inspect authentication",
+}
+
+var authStatusCmd = &cobra.Command{
+	Use:          "status",
+	Short:        "show the server in use and whether the token is accepted",
+	Long:         authStatusLong,
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		status := authStatus{
+			Endpoint:       profileValue("api_url"),
+			EndpointSource: settingSource("api_url"),
+			TokenSource:    tokenSource(),
+		}
+		if name := activeContext(); name != "" {
+			status.Context = name
+			status.ContextSource = contextSource()
+		}
+
+		err := checkToken()
+		if err != nil {
+			status.Error = err.Error()
+		} else {
+			status.TokenValid = true
+		}
+
+		renderOutput(status, output)
+		return err
+	},
+	Example: fmt.Sprintf(authStatusExamples, rootCmd.Use),
+}
+
+func init() {
+	authCmd.AddCommand(authStatusCmd)
+	rootCmd.AddCommand(authCmd)
+}
+
+// checkToken verifies that the server accepts the configured token by
+// listing organizations, as login does.
+func checkToken() error {
+	client, err := newClient()
+	if err != nil {
+		return err
+	}
+	if _, err := client.Organizations.List(getContext(), nil); err != nil {
+		return fmt.Errorf("token check failed: %w", err)
+	}
+	return nil
+}
+
+// contextSource reports how the active context was selected.
+func contextSource() string {
+	switch {
+	case contextName != "":
+		return "flag"
+	case os.Getenv(envPrefix+"_CONTEXT") != "":
+		return "env"
+	default:
+		return "config file"
+	}
+}
+
+// settingSource reports whether a connection setting read by profileValue
+// comes from the environment or the config file.
+func settingSource(key string) string {
+	if os.Getenv(envPrefix+"_"+strings.ToUpper(key)) != "" {
+		return "env"
+	}
+	if profileKeySet(key) {
+		return "config file"
+	}
+	return "none"
+}
+
+// tokenSource is settingSource for the token, naming the credential store
+// when the config file only holds a reference.
+func tokenSource() string {
+	if src := settingSource("token"); src != "none" {
+		return src
+	}
+	if profileKeySet("token_ref") {
+		return "credential store (" + profileValue("token_ref") + ")"
+	}
+	return "none"
+}
+
+func profileKeySet(key string) bool {
+	if name := activeContext(); name != "" {
+		key = "contexts." + name + "." + key
+	}
+	return viper.GetString(key) != ""
+}
@@ -0,0 +1,122 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/google/jsonapi"
+	"github.com/spf13/viper"
+	terrakube "github.com/terrakube-io/terrakube-go"
+)
+
+func TestCmdNotLoggedIn(t *testing.T) {
+	resetGlobalFlags()
+
+	_, err := executeCommand("organization", "list")
+	if err == nil {
+		t.Fatal("expected error without configured credentials, got nil")
+	}
+	if !errors.Is(err, errNotLoggedIn) {
+		t.Fatalf("expected errNotLoggedIn, got: %v", err)
+	}
+	if !strings.Contains(err.Error(), "API URL") {
+		t.Errorf("expected error to name the missing API URL, got: %v", err)
+	}
+	if code := exitCode(err); code != exitCodeNotLoggedIn {
+		t.Errorf("expected exit code %d, got %d", exitCodeNotLoggedIn, code)
+	}
+}
+
+func TestCmdNotLoggedInMissingToken(t *testing.T) {
+	resetGlobalFlags()
+	viper.Set("api_url", "http://terrakube.example.com")
+
+	_, err := executeCommand("organization", "list")
+	if !errors.Is(err, errNotLoggedIn) {
+		t.Fatalf("expected errNotLoggedIn, got: %v", err)
+	}
+	if !strings.Contains(err.Error(), "token") {
+		t.Errorf("expected error to name the missing token, got: %v", err)
+	}
+}
+
+func TestCmdAuthStatus(t *testing.T) {
+	resetGlobalFlags()
+
+	var authHeader string
+	ts := setupTestServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authHeader = r.Header.Get("Authorization")
+		w.Header().Set("Content-Type", "application/vnd.api+json")
+		_ = jsonapi.MarshalPayload(w, []*terrakube.Organization{})
+	}))
+	defer ts.Close()
+
+	cfgFile = writeContextsConfig(t, map[string]string{"prod": ts.URL}, "")
+	t.Setenv("TERRAKUBE_TOKEN", "env-token")
+
+	out, err := executeCommand("auth", "status", "--context", "prod")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var status authStatus
+	if err := json.Unmarshal([]byte(out), &status); err != nil {
+		t.Fatalf("invalid JSON output: %v\n%s", err, out)
+	}
+	want := authStatus{
+		Context:        "prod",
+		ContextSource:  "flag",
+		Endpoint:       ts.URL,
+		EndpointSource: "config file",
+		TokenSource:    "env",
+		TokenValid:     true,
+	}
+	if status != want {
+		t.Errorf("expected %+v, got %+v", want, status)
+	}
+	if authHeader != "Bearer env-token" {
+		t.Errorf("expected token from environment, got %q", authHeader)
+	}
+	if strings.Contains(out, "env-token") {
+		t.Errorf("expected token to be hidden, got: %s", out)
+	}
+}
+
+func TestCmdAuthStatusTokenRejected(t *testing.T) {
+	resetGlobalFlags()
+	ts := setupTestServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer ts.Close()
+
+	out, err := executeCommand("auth", "status")
+	if err == nil {
+		t.Fatal("expected error for rejected token, got nil")
+	}
+
+	var status authStatus
+	if err := json.Unmarshal([]byte(out), &status); err != nil {
+		t.Fatalf("invalid JSON output: %v\n%s", err, out)
+	}
+	if status.TokenValid {
+		t.Error("expected token_valid false")
+	}
+	if status.Error == "" {
+		t.Error("expected error to be reported")
+	}
+}
+
+func TestCmdAuthStatusNotLoggedIn(t *testing.T) {
+	resetGlobalFlags()
+
+	out, err := executeCommand("auth", "status")
+	if !errors.Is(err, errNotLoggedIn) {
+		t.Fatalf("expected errNotLoggedIn, got: %v", err)
+	}
+	if !strings.Contains(out, `"endpoint_source": "none"`) {
+		t.Errorf("expected missing endpoint to be reported, got: %s", out)
+	}
+}
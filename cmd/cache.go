@@ -0,0 +1,174 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"terrakube/internal/cache"
+	"terrakube/internal/resource"
+)
+
+const cacheLong = `
+Manage the resolution cache, which remembers the IDs that names given to flags
+such as --organization and --workspace resolve to.
+
+The cache is off by default. Set cache_ttl in the config file, or the
+TERRAKUBE_CACHE_TTL environment variable, to a duration such as 10m to enable
+it. Entries are kept per server and context under $XDG_CACHE_HOME/terrakube,
+expire after cache_ttl, and are dropped when a create, update or delete
+command changes a resource of the same type.
+`
+
+var cacheExamples = `
+Cache name lookups for ten minutes
+  TERRAKUBE_CACHE_TTL=10m %[1]v workspace list -o myorg
+
+Show the cached names
+  %[1]v cache show --output table
+
+Forget all cached names
+  %[1]v cache clear
+`
+
+// cacheEntry is the show view of a cached resolution.
+type cacheEntry struct {
+	ID      string `json:"id" yaml:"id"`
+	Type    string `json:"type" yaml:"type"`
+	Name    string `json:"name" yaml:"name"`
+	Scope   string `json:"scope,omitempty" yaml:"scope,omitempty"`
+	Server  string `json:"server" yaml:"server"`
+	Context string `json:"context,omitempty" yaml:"context,omitempty"`
+	Expires string `json:"expires" yaml:"expires"`
+}
+
+var cacheCmd = &cobra.Command{
+	Use:     "cache clear|show",
+	Short:   "manage the name resolution cache",
+	Long:    cacheLong,
+	Example: fmt.Sprintf(cacheExamples, rootCmd.Use),
+}
+
+var showCacheCmd = &cobra.Command{
+	Use:          "show",
+	Short:        "show cached name resolutions",
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		f, err := cacheFile(0)
+		if err != nil {
+			return err
+		}
+		cached, err := f.Entries()
+		if err != nil {
+			return err
+		}
+
+		entries := make([]cacheEntry, 0, len(cached))
+		for _, e := range cached {
+			entries = append(entries, cacheEntry{
+				ID:      e.ID,
+				Type:    e.Type,
+				Name:    e.Name,
+				Scope:   e.Scope,
+				Server:  e.Server,
+				Context: e.Profile,
+				Expires: e.Expires.Format(time.RFC3339),
+			})
+		}
+
+		renderOutput(entries, output)
+		return nil
+	},
+}
+
+var clearCacheCmd = &cobra.Command{
+	Use:          "clear",
+	Short:        "remove all cached name resolutions",
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		f, err := cacheFile(0)
+		if err != nil {
+			return err
+		}
+		if err := f.Clear(); err != nil {
+			return err
+		}
+
+		fmt.Println("Resolution cache cleared")
+		return nil
+	},
+}
+
+func init() {
+	cacheCmd.AddCommand(showCacheCmd)
+	cacheCmd.AddCommand(clearCacheCmd)
+
+	rootCmd.AddCommand(cacheCmd)
+}
+
+// cacheFile returns the resolution cache file with the given TTL.
+func cacheFile(ttl time.Duration) (*cache.File, error) {
+	path, err := cache.DefaultPath()
+	if err != nil {
+		return nil, fmt.Errorf("locating cache directory: %w", err)
+	}
+	return cache.New(path, ttl), nil
+}
+
+// setupResolutionCache enables the resolution cache when cache_ttl is set.
+func setupResolutionCache() error {
+	resource.Cache = nil
+	if viper.GetString("cache_ttl") == "" {
+		return nil
+	}
+	ttl, err := durationSetting("cache_ttl", 0)
+	if err != nil || ttl <= 0 {
+		return err
+	}
+	f, err := cacheFile(ttl)
+	if err != nil {
+		return err
+	}
+	resource.Cache = resolutionCache{file: f}
+	return nil
+}
+
+// resolutionCache keys the entries of a cache file by the server and context
+// in use.
+type resolutionCache struct {
+	file *cache.File
+}
+
+func (c resolutionCache) key(typ, scope, name string) cache.Key {
+	return cache.Key{
+		Server:  normalizeAPIURL(profileValue("api_url")),
+		Profile: activeContext(),
+		Type:    typ,
+		Scope:   scope,
+		Name:    name,
+	}
+}
+
+func (c resolutionCache) Get(typ, scope, name string) (string, bool) {
+	return c.file.Get(c.key(typ, scope, name))
+}
+
+func (c resolutionCache) Put(typ, scope, name, id string) {
+	c.warn(c.file.Put(c.key(typ, scope, name), id))
+}
+
+func (c resolutionCache) Invalidate(typ string) {
+	k := c.key(typ, "", "")
+	c.warn(c.file.Invalidate(k.Server, k.Profile, typ))
+}
+
+// warn reports cache write failures under --verbose. They only cost a lookup
+// on the next run.
+func (c resolutionCache) warn(err error) {
+	if err != nil && verbose {
+		fmt.Fprintf(os.Stderr, "Resolution cache: %v\n", err)
+	}
+}
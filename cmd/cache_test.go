@@ -0,0 +1,73 @@
+package cmd
+
+import (
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/google/jsonapi"
+	"github.com/spf13/viper"
+	terrakube "github.com/terrakube-io/terrakube-go"
+
+	"terrakube/testutil"
+)
+
+func TestCmdResolutionCache(t *testing.T) {
+	resetGlobalFlags()
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	viper.Set("cache_ttl", "1m")
+	t.Cleanup(func() { viper.Set("cache_ttl", "") })
+
+	var orgLookups atomic.Int32
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/vnd.api+json")
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/organization") && r.Method == http.MethodGet:
+			orgLookups.Add(1)
+			_ = jsonapi.MarshalPayload(w, []*terrakube.Organization{testutil.FixtureOrganization()})
+		case strings.HasSuffix(r.URL.Path, "/organization"):
+			w.WriteHeader(http.StatusCreated)
+			_ = jsonapi.MarshalPayload(w, testutil.FixtureOrganization())
+		default:
+			_ = jsonapi.MarshalPayload(w, testutil.FixtureWorkspaceList())
+		}
+	})
+	ts := setupTestServer(handler)
+	defer ts.Close()
+
+	name := testutil.FixtureOrganization().Name
+	for range 2 {
+		if _, err := executeCommand("workspace", "list", "--organization", name); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if orgLookups.Load() != 1 {
+		t.Errorf("expected the organization to be looked up once, got %d", orgLookups.Load())
+	}
+
+	out, err := executeCommand("cache", "show")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(out, `"type": "organization"`) || !strings.Contains(out, testutil.FixtureOrganization().ID) {
+		t.Errorf("expected the organization in the cache, got: %s", out)
+	}
+
+	if _, err := executeCommand("organization", "create", "--name", "other"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := executeCommand("workspace", "list", "--organization", name); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if orgLookups.Load() != 2 {
+		t.Errorf("expected creating an organization to invalidate the cache, got %d lookups", orgLookups.Load())
+	}
+
+	if _, err := executeCommand("cache", "clear"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if out, _ := executeCommand("cache", "show"); strings.Contains(out, "organization") {
+		t.Errorf("expected an empty cache after clear, got: %s", out)
+	}
+}
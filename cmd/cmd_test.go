@@ -6,6 +6,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
@@ -14,6 +15,8 @@ import (
 	"github.com/spf13/pflag"
 	"github.com/spf13/viper"
 	terrakube "github.com/terrakube-io/terrakube-go"
+
+	"terrakube/internal/config"
 )
 
 // resetGlobalFlags resets Cobra flag states and Viper keys between tests.
@@ -35,7 +38,7 @@ func resetGlobalFlags() {
 		"executionMode", "icon", "provider", "tag-prefix", "tag-id",
 		"manage-provider", "manage-module", "manage-workspace",
 		"manage-state", "manage-collection", "manage-vcs", "manage-template",
-		"cli",
+		"cli", "current-context", "token_expiry",
 	}
 	for _, key := range viperKeysToReset {
 		viper.Set(key, "")
@@ -50,19 +53,23 @@ func resetGlobalFlags() {
 
 // resetCobraFlags recursively resets all flags on a command and its subcommands.
 func resetCobraFlags(cmd *cobra.Command) {
-	cmd.Flags().VisitAll(func(f *pflag.Flag) {
-		_ = f.Value.Set(f.DefValue)
-		f.Changed = false
-	})
-	cmd.PersistentFlags().VisitAll(func(f *pflag.Flag) {
-		_ = f.Value.Set(f.DefValue)
-		f.Changed = false
-	})
+	cmd.Flags().VisitAll(resetFlag)
+	cmd.PersistentFlags().VisitAll(resetFlag)
 	for _, sub := range cmd.Commands() {
 		resetCobraFlags(sub)
 	}
 }
 
+func resetFlag(f *pflag.Flag) {
+	// Setting "[]" on a slice flag would append it as a value.
+	if sv, ok := f.Value.(pflag.SliceValue); ok {
+		_ = sv.Replace(nil)
+	} else {
+		_ = f.Value.Set(f.DefValue)
+	}
+	f.Changed = false
+}
+
 // executeCommand runs the root cobra command with the given args and captures stdout.
 func executeCommand(args ...string) (string, error) {
 	old := os.Stdout
@@ -570,12 +577,12 @@ func TestCmdRootTopLevelCommands(t *testing.T) {
 func TestCmdLogoutE2E(t *testing.T) {
 	resetGlobalFlags()
 
-	out, err := executeCommand("logout")
-	if err != nil {
-		t.Fatalf("unexpected error: %v", err)
+	_, err := executeCommand("logout")
+	if err == nil {
+		t.Fatal("expected error when logging out without stored credentials, got nil")
 	}
-	if !strings.Contains(out, "logout ok") {
-		t.Errorf("expected 'logout ok' in output, got: %s", out)
+	if !strings.Contains(err.Error(), "not logged in") {
+		t.Errorf("expected 'not logged in' error, got: %v", err)
 	}
 }
 
@@ -691,28 +698,55 @@ func TestCmdJobAlias(t *testing.T) {
 	}
 }
 
-// ----- Logout Stub Test -----
+// ----- Logout -----
 
-func TestCmdLogoutIsStub(t *testing.T) {
+func TestCmdLogoutRemovesCredentials(t *testing.T) {
 	resetGlobalFlags()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	cfgFile = path
 
-	viper.Set("api_url", "http://localhost:8080")
-	viper.Set("token", "some-secret-token")
+	cfg, _ := config.Load(path)
+	cfg.Set("api_url", "http://localhost:8080")
+	cfg.Set("token", "some-secret-token")
+	cfg.Set("output", "table")
+	if err := cfg.Save(); err != nil {
+		t.Fatalf("saving config: %v", err)
+	}
+	if err := os.Chmod(path, 0o644); err != nil {
+		t.Fatalf("chmod config: %v", err)
+	}
 
 	out, err := executeCommand("logout")
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
+	if !strings.Contains(out, "Successfully logged out") {
+		t.Errorf("expected logout message in output, got: %s", out)
+	}
 
-	if !strings.Contains(out, "logout ok") {
-		t.Errorf("expected 'logout ok' in output, got: %s", out)
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("reading config: %v", err)
+	}
+	if strings.Contains(string(b), "some-secret-token") || strings.Contains(string(b), "api_url") {
+		t.Errorf("expected credentials to be removed from config, got:\n%s", b)
+	}
+	if !strings.Contains(string(b), "output: table") {
+		t.Errorf("expected unrelated keys to be preserved, got:\n%s", b)
 	}
 
-	if viper.GetString("token") != "some-secret-token" {
-		t.Error("expected token to remain in viper after logout")
+	fi, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("stat config: %v", err)
+	}
+	if fi.Mode().Perm() != 0o600 {
+		t.Errorf("expected config mode 0600, got %v", fi.Mode().Perm())
 	}
-	if viper.GetString("api_url") != "http://localhost:8080" {
-		t.Error("expected api_url to remain in viper after logout")
+
+	resetGlobalFlags()
+	cfgFile = path
+	if _, err := executeCommand("logout"); err == nil || !strings.Contains(err.Error(), "not logged in") {
+		t.Errorf("expected 'not logged in' error on second logout, got: %v", err)
 	}
 }
 
@@ -720,6 +754,7 @@ func TestCmdLogoutIsStub(t *testing.T) {
 
 func TestCmdLoginWritesConfig(t *testing.T) {
 	resetGlobalFlags()
+	cfgFile = filepath.Join(t.TempDir(), "config.yaml")
 
 	desc := "test org"
 	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -752,6 +787,64 @@ func TestCmdLoginWritesConfig(t *testing.T) {
 	}
 }
 
+func TestCmdLoginFailureExitCodes(t *testing.T) {
+	resetGlobalFlags()
+	cfgFile = filepath.Join(t.TempDir(), "config.yaml")
+
+	ts := setupTestServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/vnd.api+json")
+		w.WriteHeader(http.StatusUnauthorized)
+		_, _ = w.Write([]byte(`{"errors":[{"detail":"invalid token"}]}`))
+	}))
+	defer ts.Close()
+
+	out, err := executeCommand("login", "--api-url", ts.URL, "--pat", "bad-pat", "--max-retries", "0")
+	if err == nil {
+		t.Fatalf("expected login with a rejected token to fail, got: %s", out)
+	}
+	var stdout, stderr strings.Builder
+	if code := reportError(&stdout, &stderr, err, false); code != exitCodeAuth {
+		t.Errorf("expected exit code %d, got %d: %s", exitCodeAuth, code, stderr.String())
+	}
+	if !strings.Contains(stderr.String(), "connecting to Terrakube server: 401 Unauthorized") || !strings.Contains(stderr.String(), "invalid token") {
+		t.Errorf("expected the connection error with its detail, got: %s", stderr.String())
+	}
+
+	ts.Close()
+	_, err = executeCommand("login", "--api-url", ts.URL, "--pat", "my-pat", "--max-retries", "0")
+	if err == nil {
+		t.Fatal("expected login to an unreachable server to fail, got nil")
+	}
+	stderr.Reset()
+	if code := reportError(&stdout, &stderr, err, false); code != exitCodeNetwork {
+		t.Errorf("expected exit code %d, got %d: %s", exitCodeNetwork, code, stderr.String())
+	}
+}
+
+func TestCmdLoginSaveFailure(t *testing.T) {
+	resetGlobalFlags()
+	notDir := filepath.Join(t.TempDir(), "file")
+	if err := os.WriteFile(notDir, nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	cfgFile = filepath.Join(notDir, "config.yaml")
+
+	ts := setupTestServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/vnd.api+json")
+		_ = jsonapi.MarshalPayload(w, []*terrakube.Organization{})
+	}))
+	defer ts.Close()
+
+	out, err := executeCommand("login", "--api-url", ts.URL, "--pat", "my-pat")
+	if err == nil || strings.Contains(out, "Successfully logged in") {
+		t.Fatalf("expected login to fail when the config cannot be saved, got: %s", out)
+	}
+	var stdout, stderr strings.Builder
+	if code := reportError(&stdout, &stderr, err, false); code != exitCodeError {
+		t.Errorf("expected exit code %d, got %d", exitCodeError, code)
+	}
+}
+
 // ----- newClient uses viper api_url -----
 
 func TestCmdNewClientUsesViperURL(t *testing.T) {
@@ -15,8 +15,9 @@ func init() {
 			GetContext: getContext,
 			GetOutput:  func() string { return output },
 		},
-		Name:    "collection-item",
-		Aliases: []string{"collection-items"},
+		Name:       "collection-item",
+		Aliases:    []string{"collection-items"},
+		Collection: "item",
 		Parents: []resource.ParentScope{
 			{
 				Name:      "organization",
@@ -33,9 +34,10 @@ func init() {
 				Resolver: collectionResolver,
 			},
 		},
+		Identity: "key",
 		Fields: []resource.FieldDef{
 			{StructField: "Key", Flag: "key", Short: "k", Type: resource.String, Required: true, Description: "Item key"},
-			{StructField: "Value", Flag: "value", Short: "v", Type: resource.String, Required: true, Description: "Item value"},
+			{StructField: "Value", Flag: "value", Short: "v", Type: resource.String, Required: true, Description: "Item value", SensitiveIf: "sensitive"},
 			{StructField: "Description", Flag: "description", Short: "d", Type: resource.String, Description: "Item description"},
 			{StructField: "Category", Flag: "category", Type: resource.String, Description: "Item category"},
 			{StructField: "Sensitive", Flag: "sensitive", Type: resource.Bool, Description: "Whether the item is sensitive"},
@@ -15,8 +15,9 @@ func init() {
 			GetContext: getContext,
 			GetOutput:  func() string { return output },
 		},
-		Name:    "collection-reference",
-		Aliases: []string{"collection-references", "collection-refs"},
+		Name:       "collection-reference",
+		Aliases:    []string{"collection-references", "collection-refs"},
+		Collection: "reference",
 		Parents: []resource.ParentScope{
 			{
 				Name:      "organization",
@@ -38,6 +39,7 @@ func init() {
 				ShortFlag: "w",
 				Aliases:   []string{"ws"},
 				IDFlag:    "workspace-id",
+				Relation:  "Workspace",
 				Resolver:  workspaceResolver,
 			},
 		},
@@ -0,0 +1,245 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"terrakube/internal/config"
+)
+
+const contextLong = `
+Manage named server profiles (contexts) stored in the CLI config file.
+
+Each context keeps its own API URL and token. The context used by a command is
+chosen by the --context flag, then the TERRAKUBE_CONTEXT environment variable,
+then the current context set with "context use". When no context is selected
+the top-level api_url and token written by older CLI versions are used.
+`
+
+var contextExamples = `
+Add a context for a staging server and switch to it
+  %[1]v context add staging -a https://terrakube-staging.example.com -t your-pat-token --use
+
+Run a single command against another context
+  %[1]v organization list --context prod
+
+List configured contexts
+  %[1]v context list --output table
+`
+
+var contextName string
+
+// contextEntry is the list/current view of a context. Tokens are never shown.
+type contextEntry struct {
+	Name    string `json:"name" yaml:"name"`
+	APIURL  string `json:"api_url" yaml:"api_url"`
+	Current bool   `json:"current" yaml:"current"`
+}
+
+var contextCmd = &cobra.Command{
+	Use:     "context add|use|list|delete|current",
+	Short:   "manage named server profiles",
+	Long:    contextLong,
+	Aliases: []string{"contexts", "ctx"},
+	Example: fmt.Sprintf(contextExamples, rootCmd.Use),
+}
+
+var addContextCmd = &cobra.Command{
+	Use:          "add NAME",
+	Short:        "add or update a context",
+	Args:         cobra.ExactArgs(1),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		name := args[0]
+		if err := config.ValidateContextName(name); err != nil {
+			return err
+		}
+
+		cfg, err := config.Load(configPath())
+		if err != nil {
+			return err
+		}
+
+		url, _ := cmd.Flags().GetString("api-url")
+		url = normalizeAPIURL(url)
+		cfg.SetContextValue(name, "api_url", url)
+		if token, _ := cmd.Flags().GetString("pat"); token != "" {
+			if err := saveSecret(cfg, credentialBackend(), name, "token", url, token); err != nil {
+				return err
+			}
+		}
+		use, _ := cmd.Flags().GetBool("use")
+		if use || cfg.CurrentContext() == "" {
+			cfg.SetCurrentContext(name)
+		}
+
+		if err := cfg.Save(); err != nil {
+			return err
+		}
+
+		fmt.Printf("Context %q saved\n", name)
+		return nil
+	},
+}
+
+var useContextCmd = &cobra.Command{
+	Use:          "use NAME",
+	Short:        "set the current context",
+	Args:         cobra.ExactArgs(1),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := config.Load(configPath())
+		if err != nil {
+			return err
+		}
+		if !cfg.HasContext(args[0]) {
+			return fmt.Errorf("context %q not found", args[0])
+		}
+
+		cfg.SetCurrentContext(args[0])
+		if err := cfg.Save(); err != nil {
+			return err
+		}
+
+		fmt.Printf("Switched to context %q\n", args[0])
+		return nil
+	},
+}
+
+var listContextsCmd = &cobra.Command{
+	Use:          "list",
+	Short:        "list contexts",
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		cfg, err := config.Load(configPath())
+		if err != nil {
+			return err
+		}
+
+		current := activeContext()
+		entries := make([]contextEntry, 0)
+		for _, name := range cfg.Contexts() {
+			entries = append(entries, contextEntry{
+				Name:    name,
+				APIURL:  cfg.ContextValue(name, "api_url"),
+				Current: name == current,
+			})
+		}
+
+		renderOutput(entries, output)
+		return nil
+	},
+}
+
+var deleteContextCmd = &cobra.Command{
+	Use:          "delete NAME",
+	Short:        "delete a context",
+	Args:         cobra.ExactArgs(1),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := config.Load(configPath())
+		if err != nil {
+			return err
+		}
+		if !cfg.DeleteContext(args[0]) {
+			return fmt.Errorf("context %q not found", args[0])
+		}
+		if err := cfg.Save(); err != nil {
+			return err
+		}
+
+		fmt.Printf("Context %q deleted\n", args[0])
+		return nil
+	},
+}
+
+var currentContextCmd = &cobra.Command{
+	Use:          "current",
+	Short:        "show the context in use",
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		name := activeContext()
+		if name == "" {
+			return fmt.Errorf("no context selected")
+		}
+
+		renderOutput(contextEntry{
+			Name:    name,
+			APIURL:  viper.GetString("contexts." + name + ".api_url"),
+			Current: true,
+		}, output)
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.PersistentFlags().StringVar(&contextName, "context", "", "Context (server profile) to use, overrides $TERRAKUBE_CONTEXT")
+
+	addContextCmd.Flags().StringP("api-url", "a", "", "API URL (required)")
+	_ = addContextCmd.MarkFlagRequired("api-url")
+	addContextCmd.Flags().StringP("pat", "t", "", "Personal Access Token")
+	addContextCmd.Flags().Bool("use", false, "Switch to the context after adding it")
+
+	contextCmd.AddCommand(addContextCmd)
+	contextCmd.AddCommand(useContextCmd)
+	contextCmd.AddCommand(listContextsCmd)
+	contextCmd.AddCommand(deleteContextCmd)
+	contextCmd.AddCommand(currentContextCmd)
+
+	rootCmd.AddCommand(contextCmd)
+}
+
+// activeContext returns the context selected by --context, TERRAKUBE_CONTEXT
+// or the current-context entry of the config file, in that order.
+func activeContext() string {
+	if contextName != "" {
+		return contextName
+	}
+	if env := os.Getenv(envPrefix + "_CONTEXT"); env != "" {
+		return env
+	}
+	return viper.GetString("current-context")
+}
+
+// profileValue reads a connection setting such as api_url or token.
+// Environment variables (TERRAKUBE_API_URL, TERRAKUBE_TOKEN, ...) take
+// precedence, then the active context or, when no context is active, the
+// legacy top-level keys. A context does not fall back to the top-level keys,
+// which may hold the token of another server.
+func profileValue(key string) string {
+	if env := os.Getenv(envPrefix + "_" + strings.ToUpper(key)); env != "" {
+		return env
+	}
+	return viper.GetString(profileKey(activeContext(), key))
+}
+
+// profileKey returns the viper key of a setting of the named context, or the
+// top-level key when name is empty.
+func profileKey(name, key string) string {
+	if name == "" {
+		return key
+	}
+	return "contexts." + name + "." + key
+}
+
+// configPath returns the config file in use: --config, or the default
+// ~/.terrakube-cli.yaml.
+func configPath() string {
+	if f := viper.ConfigFileUsed(); f != "" {
+		return f
+	}
+	home, err := os.UserHomeDir()
+	cobra.CheckErr(err)
+	return filepath.Join(home, ".terrakube-cli.yaml")
+}
+
+// normalizeAPIURL strips the /api/v1 suffix that the client adds itself.
+func normalizeAPIURL(url string) string {
+	url = strings.TrimSuffix(url, "/")
+	return strings.TrimSuffix(url, "/api/v1")
+}
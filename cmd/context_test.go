@@ -0,0 +1,261 @@
+package cmd
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/jsonapi"
+	"github.com/spf13/viper"
+	terrakube "github.com/terrakube-io/terrakube-go"
+
+	"terrakube/internal/config"
+)
+
+func writeContextsConfig(t *testing.T, contexts map[string]string, current string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	cfg, err := config.Load(path)
+	if err != nil {
+		t.Fatalf("loading config: %v", err)
+	}
+	for name, url := range contexts {
+		cfg.SetContextValue(name, "api_url", url)
+		cfg.SetContextValue(name, "token", name+"-token")
+	}
+	cfg.SetCurrentContext(current)
+	if err := cfg.Save(); err != nil {
+		t.Fatalf("saving config: %v", err)
+	}
+	return path
+}
+
+func TestCmdContextAddAndUse(t *testing.T) {
+	resetGlobalFlags()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	cfgFile = path
+
+	if _, err := executeCommand("context", "add", "dev", "--api-url", "https://dev.example.com/api/v1", "--pat", "dev-pat"); err != nil {
+		t.Fatalf("unexpected error adding dev: %v", err)
+	}
+	resetGlobalFlags()
+	cfgFile = path
+	if _, err := executeCommand("context", "add", "prod", "--api-url", "https://prod.example.com", "--pat", "prod-pat"); err != nil {
+		t.Fatalf("unexpected error adding prod: %v", err)
+	}
+
+	cfg, err := config.Load(path)
+	if err != nil {
+		t.Fatalf("loading config: %v", err)
+	}
+	if cfg.CurrentContext() != "dev" {
+		t.Errorf("expected first context to become current, got %q", cfg.CurrentContext())
+	}
+	if got := cfg.ContextValue("dev", "api_url"); got != "https://dev.example.com" {
+		t.Errorf("expected /api/v1 to be stripped, got %q", got)
+	}
+	if got := cfg.ContextValue("prod", "token"); got != "prod-pat" {
+		t.Errorf("expected prod token to be stored, got %q", got)
+	}
+
+	resetGlobalFlags()
+	cfgFile = path
+	if _, err := executeCommand("context", "use", "prod"); err != nil {
+		t.Fatalf("unexpected error switching context: %v", err)
+	}
+	cfg, _ = config.Load(path)
+	if cfg.CurrentContext() != "prod" {
+		t.Errorf("expected current context prod, got %q", cfg.CurrentContext())
+	}
+
+	fi, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("stat config: %v", err)
+	}
+	if fi.Mode().Perm() != 0o600 {
+		t.Errorf("expected config mode 0600, got %v", fi.Mode().Perm())
+	}
+}
+
+func TestCmdContextUseUnknown(t *testing.T) {
+	resetGlobalFlags()
+	cfgFile = writeContextsConfig(t, map[string]string{"dev": "https://dev.example.com"}, "dev")
+
+	_, err := executeCommand("context", "use", "staging")
+	if err == nil {
+		t.Fatal("expected error for unknown context, got nil")
+	}
+	if !strings.Contains(err.Error(), "staging") {
+		t.Errorf("expected error to mention context name, got: %v", err)
+	}
+}
+
+func TestCmdContextListAndDelete(t *testing.T) {
+	resetGlobalFlags()
+	cfgFile = writeContextsConfig(t, map[string]string{
+		"dev":  "https://dev.example.com",
+		"prod": "https://prod.example.com",
+	}, "prod")
+	path := cfgFile
+
+	out, err := executeCommand("context", "list", "--context", "dev", "--output", "tsv")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := "dev\thttps://dev.example.com\ttrue\nprod\thttps://prod.example.com\tfalse\n"
+	if out != want {
+		t.Errorf("expected list output %q, got %q", want, out)
+	}
+	if strings.Contains(out, "token") {
+		t.Errorf("expected tokens to be hidden, got: %s", out)
+	}
+
+	resetGlobalFlags()
+	cfgFile = path
+	if _, err := executeCommand("context", "delete", "prod"); err != nil {
+		t.Fatalf("unexpected error deleting context: %v", err)
+	}
+	cfg, _ := config.Load(path)
+	if cfg.HasContext("prod") {
+		t.Error("expected prod context to be deleted")
+	}
+	if cfg.CurrentContext() != "" {
+		t.Errorf("expected current context to be cleared, got %q", cfg.CurrentContext())
+	}
+}
+
+func TestCmdContextFlagSelectsServer(t *testing.T) {
+	resetGlobalFlags()
+
+	var devHits, prodHits int
+	var authHeader string
+	dev := setupTestServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		devHits++
+		w.Header().Set("Content-Type", "application/vnd.api+json")
+		_ = jsonapi.MarshalPayload(w, []*terrakube.Organization{})
+	}))
+	defer dev.Close()
+	prod := setupTestServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		prodHits++
+		authHeader = r.Header.Get("Authorization")
+		w.Header().Set("Content-Type", "application/vnd.api+json")
+		_ = jsonapi.MarshalPayload(w, []*terrakube.Organization{})
+	}))
+	defer prod.Close()
+
+	cfgFile = writeContextsConfig(t, map[string]string{"dev": dev.URL, "prod": prod.URL}, "dev")
+
+	if _, err := executeCommand("organization", "list", "--context", "prod"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if prodHits != 1 || devHits != 0 {
+		t.Errorf("expected request to reach prod only, got dev=%d prod=%d", devHits, prodHits)
+	}
+	if authHeader != "Bearer prod-token" {
+		t.Errorf("expected prod token, got %q", authHeader)
+	}
+}
+
+func TestCmdContextEnvSelectsServer(t *testing.T) {
+	resetGlobalFlags()
+
+	var hits int
+	staging := setupTestServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits++
+		w.Header().Set("Content-Type", "application/vnd.api+json")
+		_ = jsonapi.MarshalPayload(w, []*terrakube.Organization{})
+	}))
+	defer staging.Close()
+
+	cfgFile = writeContextsConfig(t, map[string]string{"dev": "http://127.0.0.1:1", "staging": staging.URL}, "dev")
+	t.Setenv("TERRAKUBE_CONTEXT", "staging")
+
+	if _, err := executeCommand("organization", "list"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if hits != 1 {
+		t.Errorf("expected request to reach staging, got %d hits", hits)
+	}
+}
+
+func TestProfileValue(t *testing.T) {
+	resetGlobalFlags()
+	viper.Set("api_url", "https://legacy.example.com")
+	viper.Set("contexts.profile-test.api_url", "https://prod.example.com")
+
+	tests := []struct {
+		context, env, want string
+	}{
+		{"", "", "https://legacy.example.com"},
+		{"profile-test", "", "https://prod.example.com"},
+		{"", "https://env.example.com", "https://env.example.com"},
+		{"profile-test", "https://env.example.com", "https://env.example.com"},
+		{"other", "", ""},
+	}
+	for _, tt := range tests {
+		contextName = tt.context
+		t.Setenv("TERRAKUBE_API_URL", tt.env)
+		if got := profileValue("api_url"); got != tt.want {
+			t.Errorf("context %q, env %q: expected %q, got %q", tt.context, tt.env, tt.want, got)
+		}
+	}
+	contextName = ""
+}
+
+func TestCmdLogoutContext(t *testing.T) {
+	resetGlobalFlags()
+	path := writeContextsConfig(t, map[string]string{
+		"dev":  "https://dev.example.com",
+		"prod": "https://prod.example.com",
+	}, "dev")
+	cfgFile = path
+
+	if _, err := executeCommand("logout", "--context", "prod"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	cfg, err := config.Load(path)
+	if err != nil {
+		t.Fatalf("loading config: %v", err)
+	}
+	if got := cfg.ContextValue("prod", "token"); got != "" {
+		t.Errorf("expected prod token to be removed, got %q", got)
+	}
+	if got := cfg.ContextValue("dev", "token"); got != "dev-token" {
+		t.Errorf("expected dev token to be kept, got %q", got)
+	}
+}
+
+func TestCmdLogoutAll(t *testing.T) {
+	resetGlobalFlags()
+	path := writeContextsConfig(t, map[string]string{
+		"dev":  "https://dev.example.com",
+		"prod": "https://prod.example.com",
+	}, "dev")
+	cfgFile = path
+
+	if _, err := executeCommand("logout", "--all"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	cfg, err := config.Load(path)
+	if err != nil {
+		t.Fatalf("loading config: %v", err)
+	}
+	for _, name := range []string{"dev", "prod"} {
+		if got := cfg.ContextValue(name, "token"); got != "" {
+			t.Errorf("expected %s token to be removed, got %q", name, got)
+		}
+		if got := cfg.ContextValue(name, "api_url"); got != "" {
+			t.Errorf("expected %s api_url to be removed, got %q", name, got)
+		}
+	}
+
+	resetGlobalFlags()
+	cfgFile = path
+	if _, err := executeCommand("logout", "--all"); err == nil {
+		t.Error("expected error when no credentials are left, got nil")
+	}
+}
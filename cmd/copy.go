@@ -0,0 +1,191 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	terrakube "github.com/terrakube-io/terrakube-go"
+
+	"terrakube/internal/resource"
+)
+
+const copyLong = `
+Recreate an organization or a workspace, with everything configured in it, on
+another context, such as promoting a staging server's configuration to
+production.
+
+The source is read as "export" would write it and applied to the target as
+"apply" would: objects that already exist on the target, by name, are updated
+to match, and missing ones are created. References such as the VCS connection
+and tags of a workspace and the templates of its schedules and webhooks are
+matched by name on the target; a workspace copy also copies the VCS
+connection, tags and templates it uses.
+
+Sensitive values, such as the values of sensitive variables and private keys,
+are not readable from the source. They are left unchanged on existing target
+objects, and objects that need one to be created are skipped unless it is
+given with --secret KIND/NAME.FIELD=VALUE.
+
+TERRAKUBE_API_URL and TERRAKUBE_TOKEN apply to every context, so copy refuses
+to run while they are set.
+
+A line is printed per object with its result: created, updated, unchanged,
+skipped or failed. The copy carries on past failures, skipping the objects
+under a failed one, and exits with an error if any failed.
+`
+
+var copyExamples = `
+Promote a workspace from staging to production
+  %[1]v copy workspace production-vpc --organization acme-corp --from-context staging --to-context prod
+
+Copy a workspace with the value of a sensitive variable
+  %[1]v copy workspace production-vpc -o acme-corp --to-context prod --secret variable/DB_PASSWORD.value="$DB_PASSWORD"
+
+Clone an organization under a new name on the same server
+  %[1]v copy organization acme-corp --to-context default --to-organization acme-sandbox
+`
+
+var copyCmd = &cobra.Command{
+	Use:     "copy workspace|organization NAME",
+	Short:   "copy an organization or workspace to another context",
+	Long:    copyLong,
+	Example: fmt.Sprintf(copyExamples, rootCmd.Use),
+}
+
+var copyWorkspaceCmd = &cobra.Command{
+	Use:          "workspace NAME",
+	Short:        "copy a workspace and its children to another context",
+	Args:         cobra.ExactArgs(1),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		org, _ := cmd.Flags().GetString("organization")
+		return runCopy(cmd, func(c *terrakube.Client) ([]resource.Manifest, error) {
+			return resource.ExportResource(getContext(), c, org, "workspace", args[0])
+		}, org)
+	},
+}
+
+var copyOrganizationCmd = &cobra.Command{
+	Use:          "organization NAME",
+	Short:        "copy an organization and everything in it to another context",
+	Args:         cobra.ExactArgs(1),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runCopy(cmd, func(c *terrakube.Client) ([]resource.Manifest, error) {
+			return resource.Export(getContext(), c, args[0])
+		}, args[0])
+	},
+}
+
+// runCopy reads manifests from the --from-context server with read and
+// applies them to the --to-context server.
+func runCopy(cmd *cobra.Command, read func(*terrakube.Client) ([]resource.Manifest, error), organization string) error {
+	from, _ := cmd.Flags().GetString("from-context")
+	to, _ := cmd.Flags().GetString("to-context")
+	toOrg, _ := cmd.Flags().GetString("to-organization")
+	secrets, _ := cmd.Flags().GetStringArray("secret")
+	// The environment overrides the server and token of every context, so
+	// both ends would silently be the same server.
+	for _, env := range []string{envPrefix + "_API_URL", envPrefix + "_TOKEN"} {
+		if os.Getenv(env) != "" {
+			return fmt.Errorf("%s overrides both --from-context and --to-context, unset it to copy", env)
+		}
+	}
+	if from == "" {
+		from = activeContext()
+	}
+	if from == to && (toOrg == "" || toOrg == organization) {
+		return fmt.Errorf("source and target are the same, set a different --to-context or --to-organization")
+	}
+
+	var manifests []resource.Manifest
+	err := withContext(from, func() error {
+		c, err := newClient()
+		if err != nil {
+			return err
+		}
+		manifests, err = read(c)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	if toOrg != "" {
+		renameOrganization(manifests, toOrg)
+	}
+	for _, s := range secrets {
+		if err := supplySecret(manifests, s); err != nil {
+			return err
+		}
+	}
+
+	return withContext(to, func() error {
+		c, err := newClient()
+		if err != nil {
+			return err
+		}
+		return resource.ApplyEach(getContext(), c, manifests, os.Stdout)
+	})
+}
+
+// withContext runs fn with name as the active context.
+func withContext(name string, fn func() error) error {
+	saved := contextName
+	contextName = name
+	defer func() { contextName = saved }()
+	return fn()
+}
+
+// renameOrganization points manifests at the organization with the given
+// name, renaming the organization manifest itself if there is one.
+func renameOrganization(manifests []resource.Manifest, name string) {
+	for i, m := range manifests {
+		if m.Kind == "organization" {
+			manifests[i].Spec["name"] = name
+		}
+		if _, ok := m.Parents["organization"]; ok {
+			m.Parents["organization"] = name
+		}
+	}
+}
+
+// supplySecret sets a field given as KIND/NAME.FIELD=VALUE in the manifests
+// it names.
+func supplySecret(manifests []resource.Manifest, secret string) error {
+	target, value, ok := strings.Cut(secret, "=")
+	dot := strings.LastIndex(target, ".")
+	if !ok || dot < 0 || !strings.Contains(target[:dot], "/") {
+		return fmt.Errorf("invalid --secret %q, expected KIND/NAME.FIELD=VALUE", secret)
+	}
+	name, field := target[:dot], target[dot+1:]
+
+	found := false
+	for _, m := range manifests {
+		if m.Name() == name {
+			m.Spec[field] = value
+			found = true
+		}
+	}
+	if !found {
+		return fmt.Errorf("invalid --secret %q: %s is not copied", secret, name)
+	}
+	return nil
+}
+
+func init() {
+	copyWorkspaceCmd.Flags().StringP("organization", "o", "", "Organization name or ID of the workspace")
+	_ = copyWorkspaceCmd.MarkFlagRequired("organization")
+
+	for _, cmd := range []*cobra.Command{copyWorkspaceCmd, copyOrganizationCmd} {
+		cmd.Flags().String("from-context", "", "Context to copy from (default the active context)")
+		cmd.Flags().String("to-context", "", "Context to copy to")
+		cmd.Flags().String("to-organization", "", "Organization name on the target (default the same name)")
+		cmd.Flags().StringArray("secret", nil, "Value of a sensitive field, as KIND/NAME.FIELD=VALUE (repeatable)")
+		_ = cmd.MarkFlagRequired("to-context")
+		copyCmd.AddCommand(cmd)
+	}
+
+	rootCmd.AddCommand(copyCmd)
+}
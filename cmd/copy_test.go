@@ -0,0 +1,214 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"strings"
+	"testing"
+
+	"github.com/google/jsonapi"
+	terrakube "github.com/terrakube-io/terrakube-go"
+
+	"terrakube/internal/resource"
+	"terrakube/testutil"
+)
+
+// fakeTarget is a server that keeps the resources posted to it, by
+// collection name, and lists them back honoring attr==value filters.
+type fakeTarget struct {
+	org   *terrakube.Organization
+	store map[string][]map[string]any
+}
+
+func (f *fakeTarget) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/vnd.api+json")
+	collection := path.Base(r.URL.Path)
+	switch r.Method {
+	case http.MethodGet:
+		if collection == "organization" {
+			_ = jsonapi.MarshalPayload(w, []*terrakube.Organization{f.org})
+			return
+		}
+		attr, value, _ := strings.Cut(r.URL.Query().Get("filter"), "==")
+		items := []map[string]any{}
+		for _, item := range f.store[collection] {
+			if attr == "" || fmt.Sprint(item["attributes"].(map[string]any)[attr]) == value {
+				items = append(items, item)
+			}
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"data": items})
+	case http.MethodPost:
+		var doc map[string]map[string]any
+		body, _ := io.ReadAll(r.Body)
+		_ = json.Unmarshal(body, &doc)
+		data := doc["data"]
+		data["id"] = fmt.Sprintf("00000000-0000-0000-0000-%012d", len(f.store[collection])+1)
+		f.store[collection] = append(f.store[collection], data)
+		w.WriteHeader(http.StatusCreated)
+		_ = json.NewEncoder(w).Encode(doc)
+	default:
+		http.Error(w, "unexpected "+r.Method, http.StatusMethodNotAllowed)
+	}
+}
+
+func TestCmdCopyWorkspaceE2E(t *testing.T) {
+	resetGlobalFlags()
+	org := testutil.FixtureOrganization()
+	schedule := testutil.FixtureWorkspaceSchedule()
+	schedule.TemplateID = testutil.FixtureTemplate().ID
+
+	staging := setupTestServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			t.Errorf("unexpected request to the source %s %s", r.Method, r.URL)
+		}
+		w.Header().Set("Content-Type", "application/vnd.api+json")
+		switch p := r.URL.Path; {
+		case strings.HasSuffix(p, "/organization"):
+			_ = jsonapi.MarshalPayload(w, []*terrakube.Organization{org})
+		case strings.HasSuffix(p, "/workspace"):
+			_ = jsonapi.MarshalPayload(w, []*terrakube.Workspace{testutil.FixtureWorkspace()})
+		case strings.HasSuffix(p, "/variable"):
+			_ = jsonapi.MarshalPayload(w, testutil.FixtureVariableList())
+		case strings.HasSuffix(p, "/template"):
+			_ = jsonapi.MarshalPayload(w, testutil.FixtureTemplateList())
+		case strings.HasSuffix(p, "/schedule"):
+			_ = jsonapi.MarshalPayload(w, []*terrakube.WorkspaceSchedule{schedule})
+		default:
+			_ = jsonapi.MarshalPayload(w, []*terrakube.Workspace{})
+		}
+	}))
+	defer staging.Close()
+	target := &fakeTarget{org: org, store: map[string][]map[string]any{}}
+	prod := setupTestServer(target)
+	defer prod.Close()
+	cfgFile = writeContextsConfig(t, map[string]string{"staging": staging.URL, "prod": prod.URL}, "staging")
+
+	out, err := executeCommand("copy", "workspace", "production-vpc", "--organization", org.Name,
+		"--from-context", "staging", "--to-context", "prod")
+	if err != nil {
+		t.Fatalf("unexpected error: %v\n%s", err, out)
+	}
+
+	want := `template/standard-plan created
+workspace/production-vpc created
+variable/AWS_REGION created
+variable/DB_PASSWORD skipped: spec.value is a secret placeholder, set the value to create the variable
+workspace-schedule created
+`
+	if !strings.Contains(out, want) {
+		t.Errorf("expected output:\n%s\ngot:\n%s", want, out)
+	}
+	if got := target.store["variable"][0]["attributes"].(map[string]any)["value"]; got != "us-east-1" {
+		t.Errorf("expected the value of a non-sensitive variable to be copied, got %v", got)
+	}
+	templateID := target.store["template"][0]["id"]
+	if got := target.store["schedule"][0]["attributes"].(map[string]any)["templateId"]; got != templateID {
+		t.Errorf("expected the schedule to use the target template %v, got %v", templateID, got)
+	}
+}
+
+func TestCmdCopyWorkspaceKeepsVCS(t *testing.T) {
+	resetGlobalFlags()
+	org := testutil.FixtureOrganization()
+	ws := testutil.FixtureWorkspace()
+	ws.Vcs = &terrakube.VCS{ID: testutil.FixtureVCS().ID}
+
+	staging := setupTestServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/vnd.api+json")
+		switch p := r.URL.Path; {
+		case strings.HasSuffix(p, "/organization"):
+			_ = jsonapi.MarshalPayload(w, []*terrakube.Organization{org})
+		case strings.HasSuffix(p, "/workspace"):
+			_ = jsonapi.MarshalPayload(w, []*terrakube.Workspace{ws})
+		case strings.HasSuffix(p, "/vcs"):
+			_ = jsonapi.MarshalPayload(w, testutil.FixtureVCSList())
+		default:
+			_ = jsonapi.MarshalPayload(w, []*terrakube.Workspace{})
+		}
+	}))
+	defer staging.Close()
+	// The connection exists on the target, under another ID.
+	vcs := resourceData(t, testutil.FixtureVCS())
+	vcs["id"] = "00000000-0000-0000-0000-0000000000aa"
+	target := &fakeTarget{org: org, store: map[string][]map[string]any{"vcs": {vcs}}}
+	prod := setupTestServer(target)
+	defer prod.Close()
+	cfgFile = writeContextsConfig(t, map[string]string{"staging": staging.URL, "prod": prod.URL}, "staging")
+
+	out, err := executeCommand("copy", "workspace", "production-vpc", "--organization", org.Name,
+		"--from-context", "staging", "--to-context", "prod")
+	if err != nil {
+		t.Fatalf("unexpected error: %v\n%s", err, out)
+	}
+
+	want := "vcs/github-main unchanged\nworkspace/production-vpc created\n"
+	if !strings.Contains(out, want) {
+		t.Errorf("expected output:\n%s\ngot:\n%s", want, out)
+	}
+	if len(target.store["workspace"]) != 1 {
+		t.Fatalf("expected the workspace to be created, got %v", target.store["workspace"])
+	}
+	rels, _ := target.store["workspace"][0]["relationships"].(map[string]any)
+	rel, _ := rels["vcs"].(map[string]any)
+	data, _ := rel["data"].(map[string]any)
+	if data["id"] != vcs["id"] {
+		t.Errorf("expected the workspace to use the target VCS %v, got relationships %v", vcs["id"], rels)
+	}
+}
+
+// resourceData returns the JSON:API resource object of v as decoded JSON.
+func resourceData(t *testing.T, v any) map[string]any {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := jsonapi.MarshalPayload(&buf, v); err != nil {
+		t.Fatalf("marshaling %T: %v", v, err)
+	}
+	var doc map[string]map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatalf("decoding %T: %v", v, err)
+	}
+	return doc["data"]
+}
+
+func TestCmdCopySameTarget(t *testing.T) {
+	resetGlobalFlags()
+	cfgFile = writeContextsConfig(t, map[string]string{"staging": "http://127.0.0.1:1"}, "staging")
+
+	_, err := executeCommand("copy", "organization", "acme-corp", "--from-context", "staging", "--to-context", "staging")
+	if err == nil || !strings.Contains(err.Error(), "source and target are the same") {
+		t.Errorf("expected a same target error, got %v", err)
+	}
+}
+
+func TestCmdCopyRejectsEnvOverrides(t *testing.T) {
+	for _, env := range []string{"TERRAKUBE_API_URL", "TERRAKUBE_TOKEN"} {
+		t.Run(env, func(t *testing.T) {
+			resetGlobalFlags()
+			cfgFile = writeContextsConfig(t, map[string]string{"staging": "http://127.0.0.1:1", "prod": "http://127.0.0.1:2"}, "staging")
+			t.Setenv(env, "override")
+
+			_, err := executeCommand("copy", "organization", "acme-corp", "--from-context", "staging", "--to-context", "prod")
+			if err == nil || !strings.Contains(err.Error(), env) {
+				t.Errorf("expected an error naming %s, got %v", env, err)
+			}
+		})
+	}
+}
+
+func TestSupplySecret(t *testing.T) {
+	manifests := []resource.Manifest{{Kind: "variable", Spec: map[string]any{"key": "DB_PASSWORD", "value": resource.SecretPlaceholder}}}
+
+	if err := supplySecret(manifests, "variable/DB_PASSWORD.value=hunter2"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := manifests[0].Spec["value"]; got != "hunter2" {
+		t.Errorf("expected the secret to be set, got %v", got)
+	}
+	if err := supplySecret(manifests, "variable/MISSING.value=x"); err == nil || !strings.Contains(err.Error(), "is not copied") {
+		t.Errorf("expected an error for a manifest that is not copied, got %v", err)
+	}
+}
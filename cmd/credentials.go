@@ -0,0 +1,145 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"golang.org/x/term"
+
+	"terrakube/internal/config"
+	"terrakube/internal/credentials"
+)
+
+// credentialBackend returns the credential store new tokens are saved to:
+// the credential_store config key, or plaintext.
+func credentialBackend() string {
+	if backend := viper.GetString("credential_store"); backend != "" {
+		return backend
+	}
+	return credentials.Plaintext
+}
+
+// credentialStore opens a credential store backend. cfg is only used by the
+// plaintext backend and server is passed to credential helpers.
+func credentialStore(backend string, cfg *config.File, server string) (credentials.Store, error) {
+	switch backend {
+	case credentials.Plaintext:
+		return credentials.NewPlaintext(cfg), nil
+	case credentials.File:
+		return credentials.NewFile(credentialFilePath(), readPassphrase), nil
+	case credentials.Helper:
+		return credentials.NewHelper(viper.GetString("credential_helper"), server)
+	default:
+		return nil, fmt.Errorf("unknown credential store %q, expected %s, %s or %s",
+			backend, credentials.Plaintext, credentials.File, credentials.Helper)
+	}
+}
+
+// credentialFilePath returns the encrypted credential file: the
+// credential_file config key, or ~/.terrakube-cli.credentials.
+func credentialFilePath() string {
+	if path := viper.GetString("credential_file"); path != "" {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	cobra.CheckErr(err)
+	return filepath.Join(home, ".terrakube-cli.credentials")
+}
+
+// readPassphrase returns the passphrase of the encrypted credential file from
+// TERRAKUBE_PASSPHRASE, or prompts for it when stdin is a terminal.
+func readPassphrase() (string, error) {
+	if p := os.Getenv(envPrefix + "_PASSPHRASE"); p != "" {
+		return p, nil
+	}
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		return "", fmt.Errorf("credential file is encrypted: set %s_PASSPHRASE", envPrefix)
+	}
+	fmt.Fprint(os.Stderr, "Credential file passphrase: ")
+	b, err := term.ReadPassword(fd)
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return "", fmt.Errorf("reading passphrase: %w", err)
+	}
+	return string(b), nil
+}
+
+// saveSecret stores a profile secret such as "token" or "refresh_token" in
+// backend for the named context ("" for the top-level settings). Backends
+// other than plaintext leave only a <field>_ref in the config file. cfg is
+// not saved.
+func saveSecret(cfg *config.File, backend, name, field, server, secret string) error {
+	store, err := credentialStore(backend, cfg, server)
+	if err != nil {
+		return err
+	}
+	key := credentials.Key(name, field)
+	if err := store.Set(key, secret); err != nil {
+		return err
+	}
+
+	if backend == credentials.Plaintext {
+		cfg.UnsetProfileValue(name, field+"_ref")
+		return nil
+	}
+	cfg.UnsetProfileValue(name, field)
+	cfg.SetProfileValue(name, field+"_ref", credentials.Ref(backend, key))
+	return nil
+}
+
+// deleteStoredSecret removes the secret a <field>_ref points to. A secret
+// that is already gone is not an error.
+func deleteStoredSecret(ref, server string) error {
+	backend, key, err := credentials.ParseRef(ref)
+	if err != nil {
+		return err
+	}
+	store, err := credentialStore(backend, nil, server)
+	if err != nil {
+		return err
+	}
+	if err := store.Delete(key); err != nil && !errors.Is(err, credentials.ErrNotFound) {
+		return err
+	}
+	return nil
+}
+
+// resolveToken returns the API token of the active profile. TERRAKUBE_TOKEN
+// wins, then the profile's token.
+func resolveToken() (string, error) {
+	if token := os.Getenv(envPrefix + "_TOKEN"); token != "" {
+		return token, nil
+	}
+	return profileSecret("token")
+}
+
+// profileSecret returns a secret of the active profile, stored in plain text
+// or behind a <field>_ref pointing into a credential store.
+func profileSecret(field string) (string, error) {
+	if secret := profileValue(field); secret != "" {
+		return secret, nil
+	}
+	ref := profileValue(field + "_ref")
+	if ref == "" {
+		return "", nil
+	}
+
+	backend, key, err := credentials.ParseRef(ref)
+	if err != nil {
+		return "", err
+	}
+	store, err := credentialStore(backend, nil, profileValue("api_url"))
+	if err != nil {
+		return "", err
+	}
+	secret, err := store.Get(key)
+	if errors.Is(err, credentials.ErrNotFound) {
+		return "", fmt.Errorf("no %s found in the %s credential store, run login again", field, backend)
+	}
+	return secret, err
+}
@@ -0,0 +1,94 @@
+package cmd
+
+import (
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/jsonapi"
+	"github.com/spf13/viper"
+	terrakube "github.com/terrakube-io/terrakube-go"
+
+	"terrakube/internal/config"
+	"terrakube/internal/credentials"
+)
+
+func TestCmdLoginEncryptedFileStore(t *testing.T) {
+	resetGlobalFlags()
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yaml")
+	credFile := filepath.Join(dir, "credentials")
+	cfgFile = path
+	viper.Set("credential_file", credFile)
+	t.Cleanup(func() { viper.Set("credential_file", "") })
+	t.Setenv("TERRAKUBE_PASSPHRASE", "s3cret passphrase")
+
+	var gotAuth string
+	ts := setupTestServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotAuth = r.Header.Get("Authorization")
+		w.Header().Set("Content-Type", "application/vnd.api+json")
+		_ = jsonapi.MarshalPayload(w, []*terrakube.Organization{})
+	}))
+	defer ts.Close()
+
+	out, err := executeCommand("login", "--api-url", ts.URL, "--pat", "my-secret-pat", "--credential-store", "file")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(out, "Successfully logged in") {
+		t.Fatalf("expected login to succeed, got: %s", out)
+	}
+
+	b, _ := os.ReadFile(path)
+	if strings.Contains(string(b), "my-secret-pat") {
+		t.Errorf("expected token to be kept out of the config file, got:\n%s", b)
+	}
+	cfg, _ := config.Load(path)
+	if got := cfg.Get("token_ref"); got != "file" {
+		t.Errorf("expected token_ref 'file', got %q", got)
+	}
+	b, _ = os.ReadFile(credFile)
+	if len(b) == 0 || strings.Contains(string(b), "my-secret-pat") {
+		t.Errorf("expected an encrypted credential file, got:\n%s", b)
+	}
+
+	resetGlobalFlags()
+	cfgFile = path
+	viper.Set("api_url", ts.URL)
+	if _, err := executeCommand("organization", "list"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if gotAuth != "Bearer my-secret-pat" {
+		t.Errorf("expected token from the credential file, got %q", gotAuth)
+	}
+
+	resetGlobalFlags()
+	cfgFile = path
+	if _, err := executeCommand("logout"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	cfg, _ = config.Load(path)
+	if cfg.Get("token_ref") != "" {
+		t.Error("expected token_ref to be removed on logout")
+	}
+	store, err := credentialStore(credentials.File, nil, "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := store.Get(""); !errors.Is(err, credentials.ErrNotFound) {
+		t.Errorf("expected token to be erased from the credential file, got %v", err)
+	}
+}
+
+func TestCmdResolveTokenUnknownRef(t *testing.T) {
+	resetGlobalFlags()
+	viper.Set("token_ref", "vault:prod")
+	t.Cleanup(func() { viper.Set("token_ref", "") })
+
+	if _, err := resolveToken(); err == nil || !strings.Contains(err.Error(), "invalid credential reference") {
+		t.Errorf("expected invalid reference error, got %v", err)
+	}
+}
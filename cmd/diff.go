@@ -0,0 +1,80 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	outputpkg "terrakube/internal/output"
+	"terrakube/internal/resource"
+)
+
+const diffLong = `
+Show what "apply" would change for the same manifests, without changing
+anything.
+
+Each resource that would be created or updated is printed as a unified diff of
+its fields, followed by a summary. Sensitive values, such as the values of
+sensitive variables, VCS client secrets and SSH private keys, are masked. With
+--output json or yaml the diff is printed as a document instead.
+
+The exit status is 0 when there are no changes and 2 when there are, so CI jobs
+can gate on it. Other failures exit with the usual codes.
+`
+
+var diffExamples = `
+Preview the changes of a directory of manifests
+  %[1]v diff -f manifests/
+
+Fail a CI job when production drifted from the manifests
+  %[1]v diff -f prod/ --output json > plan.json || exit $?
+`
+
+var diffCmd = &cobra.Command{
+	Use:          "diff -f PATH",
+	Short:        "preview the changes apply would make",
+	Long:         diffLong,
+	Example:      fmt.Sprintf(diffExamples, rootCmd.Use),
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		paths, _ := cmd.Flags().GetStringArray("filename")
+		manifests, err := resource.ReadManifests(paths)
+		if err != nil {
+			return err
+		}
+		if len(manifests) == 0 {
+			return fmt.Errorf("no manifests found")
+		}
+
+		client, err := newClient()
+		if err != nil {
+			return err
+		}
+		changes, err := resource.PlanAll(getContext(), client, manifests)
+		if err != nil {
+			return err
+		}
+
+		if rootCmd.PersistentFlags().Changed("output") {
+			err = outputpkg.Render(os.Stdout, resource.NewDiffReport(changes), output)
+		} else {
+			err = resource.WriteDiff(os.Stdout, changes)
+		}
+		if err != nil {
+			return err
+		}
+		if resource.HasChanges(changes) {
+			return errChanges
+		}
+		return nil
+	},
+}
+
+func init() {
+	diffCmd.Flags().StringArrayP("filename", "f", nil, "Manifest file or directory, - for stdin (repeatable)")
+	_ = diffCmd.MarkFlagRequired("filename")
+
+	rootCmd.AddCommand(diffCmd)
+}
@@ -0,0 +1,95 @@
+package cmd
+
+import (
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/jsonapi"
+	terrakube "github.com/terrakube-io/terrakube-go"
+
+	"terrakube/testutil"
+)
+
+const variableManifest = `kind: variable
+parents:
+  organization: a1b2c3d4-e5f6-7890-abcd-ef1234567890
+  workspace: d4e5f6a7-b8c9-0123-defa-234567890123
+spec:
+  key: AWS_REGION
+  value: eu-west-1
+  category: ENV
+`
+
+func variableServer(t *testing.T) {
+	t.Helper()
+	ts := setupTestServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			t.Errorf("expected diff to only read, got %s %s", r.Method, r.URL)
+		}
+		if got := r.URL.Query().Get("filter"); got != "key==AWS_REGION" {
+			t.Errorf("expected the variable to be looked up by key, got filter %q", got)
+		}
+		w.Header().Set("Content-Type", "application/vnd.api+json")
+		_ = jsonapi.MarshalPayload(w, []*terrakube.Variable{testutil.FixtureVariable()})
+	}))
+	t.Cleanup(ts.Close)
+}
+
+func writeManifest(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "variable.yaml")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestCmdDiffE2E(t *testing.T) {
+	resetGlobalFlags()
+	variableServer(t)
+
+	out, err := executeCommand("diff", "-f", writeManifest(t, variableManifest))
+	if !errors.Is(err, errChanges) || exitCode(err) != exitCodeChanges {
+		t.Fatalf("expected the changes exit status, got %v", err)
+	}
+	if !strings.Contains(out, "-value: us-east-1\n+value: eu-west-1\n") {
+		t.Errorf("expected the value of a non-sensitive variable in plain text, got: %s", out)
+	}
+	if !strings.Contains(out, "0 to create, 1 to update, 0 unchanged.") {
+		t.Errorf("expected a summary, got: %s", out)
+	}
+}
+
+func TestCmdDiffSensitiveVariable(t *testing.T) {
+	resetGlobalFlags()
+	variableServer(t)
+
+	out, err := executeCommand("diff", "-f", writeManifest(t, variableManifest+"  sensitive: true\n"))
+	if !errors.Is(err, errChanges) {
+		t.Fatalf("expected the changes exit status, got %v", err)
+	}
+	if !strings.Contains(out, "-value: (sensitive)\n+value: (sensitive)\n") || !strings.Contains(out, "+sensitive: true\n") {
+		t.Errorf("expected a masked value change, got: %s", out)
+	}
+	if strings.Contains(out, "us-east-1") || strings.Contains(out, "eu-west-1") {
+		t.Errorf("expected values to be masked, got: %s", out)
+	}
+}
+
+func TestCmdDiffNoChangesJSON(t *testing.T) {
+	resetGlobalFlags()
+	variableServer(t)
+
+	manifest := strings.Replace(variableManifest, "eu-west-1", "us-east-1", 1)
+	out, err := executeCommand("diff", "-f", writeManifest(t, manifest), "--output", "json")
+	if err != nil {
+		t.Fatalf("expected no changes, got %v", err)
+	}
+	if !strings.Contains(out, `"changed": false`) || !strings.Contains(out, `"action": "unchanged"`) {
+		t.Errorf("expected a JSON report without changes, got: %s", out)
+	}
+}
@@ -0,0 +1,189 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+
+	terrakube "github.com/terrakube-io/terrakube-go"
+
+	"terrakube/internal/apierror"
+	"terrakube/internal/transport"
+)
+
+// Process exit codes. Code 2 is for commands that report a result through
+// their exit status, such as diff finding changes.
+const (
+	exitCodeError       = 1
+	exitCodeChanges     = 2
+	exitCodeNotLoggedIn = 3
+	exitCodeTimeout     = 4
+	exitCodeAuth        = 5
+	exitCodeNotFound    = 6
+	exitCodeConflict    = 7
+	exitCodeValidation  = 8
+	exitCodeNetwork     = 9
+	exitCodeInterrupted = 130
+)
+
+// errNotLoggedIn is returned when no API URL or token is configured for the
+// profile in use.
+var errNotLoggedIn = errors.New("not logged in")
+
+// errTimeout and errInterrupted report a command cut short by --timeout or
+// by SIGINT/SIGTERM.
+var (
+	errTimeout     = errors.New("timed out")
+	errInterrupted = errors.New("interrupted")
+)
+
+// errChanges is returned by diff when applying the manifests would change
+// resources. It is reported by the exit status only.
+var errChanges = errors.New("changes found")
+
+// apiFailures remembers the last failed API response of the command, see
+// apiError.
+var apiFailures = &transport.Failures{}
+
+// exitCode maps an error returned by a command to the process exit code.
+func exitCode(err error) int {
+	var apiErr *apierror.Error
+	switch {
+	case errors.Is(err, errChanges):
+		return exitCodeChanges
+	case errors.Is(err, errNotLoggedIn):
+		return exitCodeNotLoggedIn
+	case errors.Is(err, errTimeout), errors.Is(err, context.DeadlineExceeded):
+		return exitCodeTimeout
+	case errors.Is(err, errInterrupted), errors.Is(err, context.Canceled):
+		return exitCodeInterrupted
+	case errors.As(err, &apiErr):
+		switch apiErr.Kind {
+		case apierror.Auth:
+			return exitCodeAuth
+		case apierror.NotFound:
+			return exitCodeNotFound
+		case apierror.Conflict:
+			return exitCodeConflict
+		case apierror.Validation:
+			return exitCodeValidation
+		case apierror.Network:
+			return exitCodeNetwork
+		}
+	}
+	return exitCodeError
+}
+
+// jsonErrors reports whether a failed command writes its error to stdout as
+// JSON. It takes an explicit --output json: json is also the default output,
+// and scripts that did not ask for it expect nothing on stdout on failure.
+func jsonErrors() bool {
+	return output == "json" && rootCmd.PersistentFlags().Changed("output")
+}
+
+// errorKind names the failure type of err in JSON error reports.
+func errorKind(err error) string {
+	var apiErr *apierror.Error
+	switch {
+	case errors.Is(err, errNotLoggedIn):
+		return "not_logged_in"
+	case errors.Is(err, errTimeout), errors.Is(err, context.DeadlineExceeded):
+		return "timeout"
+	case errors.Is(err, errInterrupted), errors.Is(err, context.Canceled):
+		return "interrupted"
+	case errors.As(err, &apiErr):
+		return string(apiErr.Kind)
+	default:
+		return string(apierror.Unknown)
+	}
+}
+
+// apiError explains an error returned by the API client: with the JSON:API
+// error document of the failed response, or as a network error when the
+// server could not be reached. The context the client error was wrapped in
+// is kept. Other errors are returned unchanged.
+func apiError(err error) error {
+	var apiErr *apierror.Error
+	if errors.As(err, &apiErr) ||
+		errors.Is(err, errNotLoggedIn) || errors.Is(err, errTimeout) || errors.Is(err, errInterrupted) ||
+		errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled) {
+		return err
+	}
+
+	// The last failed response only explains errors the client returned for
+	// a response, not summaries such as "2 of 5 resources failed".
+	var clientErr *terrakube.APIError
+	if f := apiFailures.Last(); f != nil && errors.As(err, &clientErr) {
+		return explain(err, clientErr, apierror.FromResponse(f.Method, f.URL, f.StatusCode, f.Body, clientErr))
+	}
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		return explain(err, urlErr, apierror.NewNetwork(urlErr))
+	}
+	return err
+}
+
+// explain replaces cause, found in the chain of err, by its explanation,
+// keeping the message err wrapped it in.
+func explain(err, cause error, explanation *apierror.Error) error {
+	if err == cause {
+		return explanation
+	}
+	msg := strings.Replace(err.Error(), cause.Error(), explanation.Error(), 1)
+	return &explainedError{msg: msg, explanation: explanation, err: err}
+}
+
+// explainedError is an error wrapping an explained API failure.
+type explainedError struct {
+	msg         string
+	explanation *apierror.Error
+	err         error
+}
+
+func (e *explainedError) Error() string { return e.msg }
+
+func (e *explainedError) Unwrap() []error { return []error{e.explanation, e.err} }
+
+// errorReport is the JSON form of an error printed with --output json.
+type errorReport struct {
+	Error struct {
+		Kind     string            `json:"kind"`
+		Message  string            `json:"message"`
+		ExitCode int               `json:"exit_code"`
+		Status   int               `json:"status,omitempty"`
+		Errors   []apierror.Detail `json:"errors,omitempty"`
+	} `json:"error"`
+}
+
+// reportError prints err to stderr and returns the exit code for it. With
+// jsonOutput the error is also written to stdout as JSON, so that scripts
+// reading the JSON output can branch on the failure type.
+func reportError(stdout, stderr io.Writer, err error, jsonOutput bool) int {
+	if errors.Is(err, errChanges) {
+		return exitCodeChanges
+	}
+	err = apiError(contextError(err))
+	code := exitCode(err)
+	fmt.Fprintln(stderr, "Error:", err)
+	if !jsonOutput {
+		return code
+	}
+
+	var report errorReport
+	report.Error.Kind = errorKind(err)
+	report.Error.Message = err.Error()
+	report.Error.ExitCode = code
+	var apiErr *apierror.Error
+	if errors.As(err, &apiErr) {
+		report.Error.Status = apiErr.Status
+		report.Error.Errors = apiErr.Errors
+	}
+	enc := json.NewEncoder(stdout)
+	enc.SetIndent("", "  ")
+	_ = enc.Encode(report)
+	return code
+}
@@ -0,0 +1,180 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+func errorServer(t *testing.T, status int, body string) {
+	t.Helper()
+	ts := setupTestServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/vnd.api+json")
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(body))
+	}))
+	t.Cleanup(ts.Close)
+}
+
+func TestReportErrorExitCodes(t *testing.T) {
+	tests := []struct {
+		status int
+		want   int
+	}{
+		{http.StatusUnauthorized, exitCodeAuth},
+		{http.StatusForbidden, exitCodeAuth},
+		{http.StatusNotFound, exitCodeNotFound},
+		{http.StatusConflict, exitCodeConflict},
+		{http.StatusBadRequest, exitCodeValidation},
+		{http.StatusInternalServerError, exitCodeError},
+	}
+	for _, tt := range tests {
+		t.Run(http.StatusText(tt.status), func(t *testing.T) {
+			resetGlobalFlags()
+			errorServer(t, tt.status, `{"errors":[{"detail":"something went wrong"}]}`)
+
+			_, err := executeCommand("organization", "get", "--id", "a1b2c3d4-e5f6-7890-abcd-ef1234567890", "--max-retries", "0")
+			if err == nil {
+				t.Fatal("expected error, got nil")
+			}
+
+			var stdout, stderr strings.Builder
+			if code := reportError(&stdout, &stderr, err, false); code != tt.want {
+				t.Errorf("expected exit code %d, got %d", tt.want, code)
+			}
+			if !strings.Contains(stderr.String(), "something went wrong") {
+				t.Errorf("expected the error detail, got: %s", stderr.String())
+			}
+			if stdout.Len() != 0 {
+				t.Errorf("expected nothing on stdout, got: %s", stdout.String())
+			}
+		})
+	}
+}
+
+func TestReportErrorJSON(t *testing.T) {
+	resetGlobalFlags()
+	errorServer(t, http.StatusUnprocessableEntity,
+		`{"errors":[{"status":"422","title":"Invalid value","detail":"name is required","source":{"pointer":"/data/attributes/name"}}]}`)
+
+	_, err := executeCommand("organization", "create", "--name", "bad name")
+	if err == nil {
+		t.Fatal("expected error, got nil")
+	}
+
+	var stdout, stderr strings.Builder
+	code := reportError(&stdout, &stderr, err, true)
+	if code != exitCodeValidation {
+		t.Errorf("expected exit code %d, got %d", exitCodeValidation, code)
+	}
+
+	var report errorReport
+	if err := json.Unmarshal([]byte(stdout.String()), &report); err != nil {
+		t.Fatalf("expected a JSON error report, got %q: %v", stdout.String(), err)
+	}
+	if report.Error.Kind != "validation" || report.Error.ExitCode != exitCodeValidation || report.Error.Status != 422 {
+		t.Errorf("unexpected report %+v", report.Error)
+	}
+	if len(report.Error.Errors) != 1 || report.Error.Errors[0].Source.Pointer != "/data/attributes/name" {
+		t.Errorf("expected the JSON:API error entries, got %+v", report.Error.Errors)
+	}
+	if !strings.Contains(stderr.String(), "Invalid value: name is required (at /data/attributes/name)") {
+		t.Errorf("expected a readable message on stderr, got: %s", stderr.String())
+	}
+}
+
+func TestJSONErrorsNeedsExplicitOutput(t *testing.T) {
+	tests := []struct {
+		args []string
+		want bool
+	}{
+		{nil, false},
+		{[]string{"--output", "json"}, true},
+		{[]string{"--output", "yaml"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(strings.Join(append([]string{"default"}, tt.args...), " "), func(t *testing.T) {
+			resetGlobalFlags()
+
+			if _, err := executeCommand(append([]string{"organization", "list"}, tt.args...)...); err == nil {
+				t.Fatal("expected a not logged in error, got nil")
+			}
+			if got := jsonErrors(); got != tt.want {
+				t.Errorf("expected jsonErrors() %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestReportErrorOnlyExplainsClientErrors(t *testing.T) {
+	resetGlobalFlags()
+	errorServer(t, http.StatusNotFound, `{"errors":[{"detail":"organization not found"}]}`)
+
+	_, err := executeCommand("organization", "get", "--id", "a1b2c3d4-e5f6-7890-abcd-ef1234567890", "--max-retries", "0")
+	if err == nil {
+		t.Fatal("expected error, got nil")
+	}
+
+	var stdout, stderr strings.Builder
+	if code := reportError(&stdout, &stderr, fmt.Errorf("org.yaml: %w", err), false); code != exitCodeNotFound {
+		t.Errorf("expected exit code %d, got %d", exitCodeNotFound, code)
+	}
+	if !strings.Contains(stderr.String(), "org.yaml: 404 Not Found") || !strings.Contains(stderr.String(), "organization not found") {
+		t.Errorf("expected the explained error in its context, got: %s", stderr.String())
+	}
+
+	// The failed response is still the last one, but the summary did not
+	// come from it.
+	stderr.Reset()
+	if code := reportError(&stdout, &stderr, errors.New("1 of 2 resources failed"), false); code != exitCodeError {
+		t.Errorf("expected exit code %d, got %d", exitCodeError, code)
+	}
+	if got := strings.TrimSpace(stderr.String()); got != "Error: 1 of 2 resources failed" {
+		t.Errorf("expected the summary unchanged, got: %s", got)
+	}
+}
+
+func TestReportErrorNetwork(t *testing.T) {
+	resetGlobalFlags()
+	viper.Set("api_url", "http://127.0.0.1:1")
+	viper.Set("token", "test-token")
+
+	_, err := executeCommand("organization", "list", "--max-retries", "0")
+	if err == nil {
+		t.Fatal("expected error, got nil")
+	}
+
+	var stdout, stderr strings.Builder
+	if code := reportError(&stdout, &stderr, err, true); code != exitCodeNetwork {
+		t.Errorf("expected exit code %d, got %d", exitCodeNetwork, code)
+	}
+	if !strings.Contains(stdout.String(), `"kind": "network"`) {
+		t.Errorf("expected a network error report, got: %s", stdout.String())
+	}
+}
+
+func TestReportErrorNotLoggedIn(t *testing.T) {
+	var stdout, stderr strings.Builder
+	code := reportError(&stdout, &stderr, notLoggedInError("", true), true)
+	if code != exitCodeNotLoggedIn {
+		t.Errorf("expected exit code %d, got %d", exitCodeNotLoggedIn, code)
+	}
+	if !strings.Contains(stdout.String(), `"kind": "not_logged_in"`) {
+		t.Errorf("expected a not_logged_in report, got: %s", stdout.String())
+	}
+}
+
+func TestReportErrorChanges(t *testing.T) {
+	var stdout, stderr strings.Builder
+	if code := reportError(&stdout, &stderr, errChanges, true); code != exitCodeChanges {
+		t.Errorf("expected exit code %d, got %d", exitCodeChanges, code)
+	}
+	if stdout.Len() != 0 || stderr.Len() != 0 {
+		t.Errorf("expected the changes to be reported by the exit code only, got %q and %q", stdout.String(), stderr.String())
+	}
+}
@@ -0,0 +1,75 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"terrakube/internal/resource"
+)
+
+const exportLong = `
+Write an organization and everything configured in it as manifests that
+"apply" reads back.
+
+Every resource under the organization is written to its own YAML file in the
+directory: workspaces with their variables, tags, schedules, access and
+webhooks, teams, templates, modules, providers, collections, notifications,
+agents, VCS connections and SSH keys. Jobs and their history are not
+exported.
+
+Parents and fields such as the template of a schedule refer to resources by
+name, so the manifests can be applied to another organization or server.
+Fields are written even when false or empty, so applying an export resets
+fields that drifted. Secrets, such as sensitive variable values and private
+keys, are written as <secret>; apply leaves them unchanged on existing
+resources and needs the real value to create new ones. Resources without a
+name, such as schedules, are matched by their fields, so one that drifted is
+created again rather than updated.
+`
+
+var exportExamples = `
+Back up an organization
+  %[1]v export --organization acme-corp --dir backup/
+
+Copy an organization to another server
+  %[1]v export -o acme-corp --dir acme/
+  %[1]v apply -f acme/ --context staging
+`
+
+var exportCmd = &cobra.Command{
+	Use:          "export --organization ORG --dir DIR",
+	Short:        "write an organization as manifests",
+	Long:         exportLong,
+	Example:      fmt.Sprintf(exportExamples, rootCmd.Use),
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		org, _ := cmd.Flags().GetString("organization")
+		dir, _ := cmd.Flags().GetString("dir")
+
+		client, err := newClient()
+		if err != nil {
+			return err
+		}
+		manifests, err := resource.Export(getContext(), client, org)
+		if err != nil {
+			return err
+		}
+		paths, err := resource.WriteManifests(dir, manifests)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("%d manifests written to %s\n", len(paths), dir)
+		return nil
+	},
+}
+
+func init() {
+	exportCmd.Flags().StringP("organization", "o", "", "Organization name or ID")
+	exportCmd.Flags().String("dir", "", "Directory to write the manifests to")
+	_ = exportCmd.MarkFlagRequired("organization")
+	_ = exportCmd.MarkFlagRequired("dir")
+
+	rootCmd.AddCommand(exportCmd)
+}
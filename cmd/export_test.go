@@ -0,0 +1,142 @@
+package cmd
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/jsonapi"
+	terrakube "github.com/terrakube-io/terrakube-go"
+
+	"terrakube/internal/resource"
+	"terrakube/testutil"
+)
+
+func TestCmdExportE2E(t *testing.T) {
+	resetGlobalFlags()
+	org := testutil.FixtureOrganization()
+	schedule := testutil.FixtureWorkspaceSchedule()
+	schedule.TemplateID = testutil.FixtureTemplate().ID
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL)
+		}
+		w.Header().Set("Content-Type", "application/vnd.api+json")
+		switch path := r.URL.Path; {
+		case strings.HasSuffix(path, "/organization"):
+			_ = jsonapi.MarshalPayload(w, []*terrakube.Organization{org})
+		case strings.HasSuffix(path, "/workspace"):
+			_ = jsonapi.MarshalPayload(w, []*terrakube.Workspace{testutil.FixtureWorkspace()})
+		case strings.HasSuffix(path, "/variable"):
+			_ = jsonapi.MarshalPayload(w, testutil.FixtureVariableList())
+		case strings.HasSuffix(path, "/template"):
+			_ = jsonapi.MarshalPayload(w, testutil.FixtureTemplateList())
+		case strings.HasSuffix(path, "/schedule"):
+			_ = jsonapi.MarshalPayload(w, []*terrakube.WorkspaceSchedule{schedule})
+		case strings.HasSuffix(path, "/job"), strings.HasSuffix(path, "/history"):
+			t.Errorf("expected no %s to be exported", path)
+		default:
+			_ = jsonapi.MarshalPayload(w, []*terrakube.Workspace{})
+		}
+	})
+	ts := setupTestServer(handler)
+	defer ts.Close()
+
+	dir := t.TempDir()
+	out, err := executeCommand("export", "--organization", org.Name, "--dir", dir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := "7 manifests written to " + dir; !strings.Contains(out, want) {
+		t.Errorf("expected output %q, got: %s", want, out)
+	}
+
+	b, err := os.ReadFile(filepath.Join(dir, "variable--production-vpc--DB_PASSWORD.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(b), "organization: "+org.Name) || !strings.Contains(string(b), "value: <secret>") {
+		t.Errorf("expected the variable to name its parents and hide its value, got:\n%s", b)
+	}
+
+	b, err = os.ReadFile(filepath.Join(dir, "variable--production-vpc--AWS_REGION.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"value: us-east-1", "sensitive: false", "hcl: false"} {
+		if !strings.Contains(string(b), want) {
+			t.Errorf("expected %q in the non-sensitive variable, got:\n%s", want, b)
+		}
+	}
+
+	manifests, err := resource.ReadManifests([]string{dir})
+	if err != nil {
+		t.Fatalf("reading the export back: %v", err)
+	}
+	var found bool
+	for _, m := range manifests {
+		if m.Kind == "workspace-schedule" {
+			found = true
+			if m.ID != "" || m.Spec["template-id"] != "standard-plan" || m.Parents["workspace"] != "production-vpc" {
+				t.Errorf("unexpected schedule manifest: %+v", m)
+			}
+		}
+	}
+	if !found {
+		t.Errorf("expected a workspace-schedule manifest, got %+v", manifests)
+	}
+}
+
+func TestCmdExportApplyToAnotherServer(t *testing.T) {
+	resetGlobalFlags()
+	org := testutil.FixtureOrganization()
+	schedule := testutil.FixtureWorkspaceSchedule()
+	schedule.TemplateID = testutil.FixtureTemplate().ID
+
+	source := setupTestServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/vnd.api+json")
+		switch path := r.URL.Path; {
+		case strings.HasSuffix(path, "/organization"):
+			_ = jsonapi.MarshalPayload(w, []*terrakube.Organization{org})
+		case strings.HasSuffix(path, "/workspace"):
+			_ = jsonapi.MarshalPayload(w, []*terrakube.Workspace{testutil.FixtureWorkspace()})
+		case strings.HasSuffix(path, "/template"):
+			_ = jsonapi.MarshalPayload(w, testutil.FixtureTemplateList())
+		case strings.HasSuffix(path, "/schedule"):
+			_ = jsonapi.MarshalPayload(w, []*terrakube.WorkspaceSchedule{schedule})
+		default:
+			_ = jsonapi.MarshalPayload(w, []*terrakube.Workspace{})
+		}
+	}))
+	dir := t.TempDir()
+	if _, err := executeCommand("export", "--organization", org.Name, "--dir", dir); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	source.Close()
+
+	// The schedule's ID does not exist on the other server.
+	target := &fakeTarget{org: org, store: map[string][]map[string]any{}}
+	ts := setupTestServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.Contains(r.URL.Path, schedule.ID) {
+			t.Errorf("unexpected request by the source ID %s %s", r.Method, r.URL)
+			http.NotFound(w, r)
+			return
+		}
+		target.ServeHTTP(w, r)
+	}))
+	defer ts.Close()
+
+	out, err := executeCommand("apply", "-f", dir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v\n%s", err, out)
+	}
+	if !strings.Contains(out, "workspace-schedule created") {
+		t.Errorf("expected the schedule to be created, got:\n%s", out)
+	}
+	if len(target.store["schedule"]) != 1 {
+		t.Errorf("expected one schedule on the target, got %v", target.store["schedule"])
+	}
+}
@@ -15,15 +15,18 @@ func init() {
 			GetContext: getContext,
 			GetOutput:  func() string { return output },
 		},
-		Name:    "federated-claim",
-		Aliases: []string{"federated-claims", "claim"},
+		Name:       "federated-claim",
+		Aliases:    []string{"federated-claims", "claim"},
+		Collection: "claims",
 		Parents: []resource.ParentScope{
 			{
-				Name:   "federated",
-				Flag:   "federated",
-				IDFlag: "federated-id",
+				Name:     "federated",
+				Flag:     "federated",
+				IDFlag:   "federated-id",
+				Resolver: federatedResolver,
 			},
 		},
+		Identity: "claim-key",
 		Fields: []resource.FieldDef{
 			{StructField: "ClaimKey", Flag: "claim-key", Type: resource.String, Required: true, Description: "Claim key name"},
 			{StructField: "ClaimValue", Flag: "claim-value", Type: resource.String, Required: true, Description: "Claim value"},
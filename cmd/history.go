@@ -35,6 +35,7 @@ func init() {
 				Resolver:  workspaceResolver,
 			},
 		},
+		NoExport: true,
 		Fields: []resource.FieldDef{
 			{StructField: "JobReference", Flag: "job-reference", Type: resource.String, Description: "Job reference"},
 			{StructField: "Output", Flag: "output", Type: resource.String, Description: "Output data"},
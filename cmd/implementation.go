@@ -33,9 +33,10 @@ func init() {
 				Resolver: providerResolver,
 			},
 			{
-				Name:   "provider version",
-				Flag:   "provider-version",
-				IDFlag: "provider-version-id",
+				Name:     "provider version",
+				Flag:     "provider-version",
+				IDFlag:   "provider-version-id",
+				Resolver: providerVersionResolver,
 			},
 		},
 		Fields: []resource.FieldDef{
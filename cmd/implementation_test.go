@@ -98,3 +98,40 @@ func TestCmdImplementationListMissingOrg(t *testing.T) {
 		t.Errorf("expected error to mention organization, got: %v", err)
 	}
 }
+
+func TestCmdImplementationListByProviderVersion(t *testing.T) {
+	resetGlobalFlags()
+
+	var filter string
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/vnd.api+json")
+		if strings.HasSuffix(r.URL.Path, "/version") {
+			filter = r.URL.Query().Get("filter")
+			_ = jsonapi.MarshalPayload(w, []*terrakube.ProviderVersion{{ID: "a7b8c9d0-e1f2-3456-abcd-567890123456", VersionNumber: "1.2.0"}})
+			return
+		}
+		if !strings.HasSuffix(r.URL.Path, "/version/a7b8c9d0-e1f2-3456-abcd-567890123456/implementation") {
+			t.Errorf("unexpected path: %s", r.URL.Path)
+		}
+		_ = jsonapi.MarshalPayload(w, []*terrakube.Implementation{{ID: "im-1", Os: "linux", Arch: "amd64"}})
+	})
+
+	ts := setupTestServer(handler)
+	defer ts.Close()
+
+	out, err := executeCommand(
+		"implementation", "list",
+		"--organization-id", "a1b2c3d4-e5f6-7890-abcd-ef1234567890",
+		"--provider-id", "e5f6a7b8-c9d0-1234-efab-345678901234",
+		"--provider-version", "1.2.0",
+	)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if filter != "versionNumber==1.2.0" {
+		t.Errorf("expected the version to be looked up by number, got filter %q", filter)
+	}
+	if !strings.Contains(out, "im-1") {
+		t.Errorf("expected output to contain 'im-1', got: %s", out)
+	}
+}
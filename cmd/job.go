@@ -35,6 +35,7 @@ func init() {
 				Resolver:  workspaceResolver,
 			},
 		},
+		NoExport: true,
 		Fields: []resource.FieldDef{
 			{StructField: "Command", Flag: "command", Short: "c", Type: resource.String, Required: true, Description: "Command to execute (plan, apply, destroy)"},
 			{StructField: "Output", Flag: "output", Type: resource.String, Description: "Job output log"},
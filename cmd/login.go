@@ -2,13 +2,13 @@ package cmd
 
 import (
 	"fmt"
-	"os"
-	"path/filepath"
-	"strings"
 
 	terrakube "github.com/terrakube-io/terrakube-go"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
+
+	"terrakube/internal/config"
+	"terrakube/internal/transport"
 )
 
 const loginLong = `
@@ -19,6 +19,31 @@ The API URL should be provided in the following format:
   https://your-server:port
 
 Note: Do not include /api/v1 in the URL as it will be added automatically.
+
+When a context is selected with --context or TERRAKUBE_CONTEXT, the
+credentials are stored in that context instead of the top-level settings.
+
+By default the token is written to the config file, which is only readable by
+the current user. Use --credential-store (or the credential_store config key)
+to keep it elsewhere:
+  plaintext          in the config file
+  file               in a file encrypted with a passphrase (credential_file,
+                     default ~/.terrakube-cli.credentials); the passphrase is
+                     read from TERRAKUBE_PASSPHRASE or prompted for
+  credential-helper  by the executable set in credential_helper, which is run
+                     with "get", "store" or "erase" and exchanges JSON on
+                     stdin/stdout
+Only a reference to the token is then stored in the config file.
+
+With --device no PAT is needed: the CLI prints a URL and a code to enter in a
+browser, waits for the OIDC issuer (--issuer, or oidc_issuer in the config) to
+approve the login and stores the access and refresh tokens. The access token
+is refreshed automatically when it expires.
+
+For servers behind a private PKI, --ca-file, --client-cert, --client-key,
+--insecure-skip-verify and --proxy are checked by the connection test and
+saved with the credentials, as ca_file, client_cert, client_key,
+insecure_skip_verify and proxy.
 `
 
 var loginExamples = `
@@ -27,76 +52,127 @@ Login to a local Terrakube server using PAT
 
 Login to a remote Terrakube server
   %v login --api-url https://terrakube.example.com --pat your-pat-token
+
+Login and store the credentials in a named context
+  %v login --context prod --api-url https://terrakube.example.com --pat your-pat-token
+
+Login and keep the token in an encrypted file
+  %v login -a https://terrakube.example.com -t your-pat-token --credential-store file
+
+Login in a browser through the Terrakube identity provider
+  %v login -a https://terrakube.example.com --device --issuer https://terrakube-dex.example.com/dex
+
+Login to a server using a private CA and mutual TLS
+  %v login -a https://terrakube.internal -t your-pat-token --ca-file ca.pem --client-cert cli.pem --client-key cli-key.pem
 `
 
 var loginCmd = &cobra.Command{
-	Use:   "login",
-	Short: "login to Terrakube server",
-	Long:  loginLong,
-	Run: func(cmd *cobra.Command, args []string) {
-		login()
+	Use:          "login",
+	Short:        "login to Terrakube server",
+	Long:         loginLong,
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return login()
 	},
-	Example: fmt.Sprintf(loginExamples, rootCmd.Use),
+	Example: fmt.Sprintf(loginExamples, rootCmd.Use, rootCmd.Use, rootCmd.Use, rootCmd.Use, rootCmd.Use, rootCmd.Use),
 }
 
 var apiURL string
 var patToken string
+var credentialStoreName string
 
 func init() {
 	rootCmd.AddCommand(loginCmd)
 	loginCmd.Flags().StringVarP(&apiURL, "api-url", "a", "", "API URL (required)")
 	_ = loginCmd.MarkFlagRequired("api-url")
 	_ = viper.BindEnv("api-url", "TERRAKUBE_API_URL")
-	loginCmd.Flags().StringVarP(&patToken, "pat", "t", "", "Personal Access Token (required)")
-	_ = loginCmd.MarkFlagRequired("pat")
+	loginCmd.Flags().StringVarP(&patToken, "pat", "t", "", "Personal Access Token (required unless --device)")
 	_ = viper.BindEnv("pat", "TERRAKUBE_PAT")
+	loginCmd.Flags().BoolVar(&deviceLogin, "device", false, "Login in a browser with the OIDC device authorization grant")
+	loginCmd.Flags().StringVar(&oidcIssuer, "issuer", "", "OIDC issuer URL for --device (default from oidc_issuer)")
+	loginCmd.Flags().StringVar(&oidcClientID, "client-id", "", "OIDC client ID for --device (default from oidc_client_id, else "+defaultOIDCClientID+")")
+	loginCmd.MarkFlagsOneRequired("pat", "device")
+	loginCmd.MarkFlagsMutuallyExclusive("pat", "device")
+	loginCmd.Flags().StringVar(&credentialStoreName, "credential-store", "", "Where to keep the token: plaintext, file or credential-helper (default from credential_store, else plaintext)")
 }
 
-func login() {
-	// Strip /api/v1 from the URL if present
-	apiURL = strings.TrimSuffix(apiURL, "/api/v1")
-	apiURL = strings.TrimSuffix(apiURL, "/api/v1/")
+func login() error {
+	apiURL = normalizeAPIURL(apiURL)
 
+	token := patToken
+	var session *deviceSession
+	if deviceLogin {
+		var err error
+		session, err = deviceAuthorize()
+		if err != nil {
+			return fmt.Errorf("device login: %w", err)
+		}
+		token = session.Token.AccessToken
+	}
+
+	hc, err := httpClient()
+	if err != nil {
+		return fmt.Errorf("creating client: %w", err)
+	}
 	client, err := terrakube.NewClient(
 		terrakube.WithEndpoint(apiURL),
-		terrakube.WithToken(patToken),
+		terrakube.WithToken(token),
+		terrakube.WithHTTPClient(hc),
 	)
 	if err != nil {
-		fmt.Printf("Error creating client: %v\n", err)
-		return
+		return fmt.Errorf("creating client: %w", err)
 	}
 
 	// Test the connection by listing organizations
 	ctx := getContext()
 	_, err = client.Organizations.List(ctx, nil)
 	if err != nil {
-		fmt.Printf("Error connecting to Terrakube server: %v\n", err)
-		return
+		if transport.IsCertificateError(err) {
+			return fmt.Errorf("connecting to Terrakube server: %w; the server certificate could not be verified: pass the CA bundle with --ca-file, or the client certificate with --client-cert and --client-key when mutual TLS is required", err)
+		}
+		return fmt.Errorf("connecting to Terrakube server: %w", err)
 	}
 
-	// Save configuration
-	home, err := os.UserHomeDir()
+	// Save configuration, into the selected context when there is one
+	cfg, err := config.Load(configPath())
 	if err != nil {
-		fmt.Printf("Error getting home directory: %v\n", err)
-		return
+		return fmt.Errorf("saving configuration: %w", err)
 	}
 
-	configFile := filepath.Join(home, ".terrakube-cli.yaml")
-	viper.SetConfigFile(configFile)
-	viper.Set("api_url", apiURL)
-	viper.Set("token", patToken)
+	backend := credentialBackend()
+	if credentialStoreName != "" {
+		backend = credentialStoreName
+	}
 
-	err = viper.WriteConfig()
-	if err != nil {
-		// If the config file doesn't exist, try to create it
-		if os.IsNotExist(err) {
-			err = viper.SafeWriteConfig()
+	name := activeContext()
+	if name != "" {
+		if err := config.ValidateContextName(name); err != nil {
+			return fmt.Errorf("saving configuration: %w", err)
 		}
-		if err != nil {
-			fmt.Printf("Error saving configuration: %v\n", err)
-			return
+		cfg.SetContextValue(name, "api_url", apiURL)
+		if cfg.CurrentContext() == "" {
+			cfg.SetCurrentContext(name)
 		}
+		viper.Set("contexts."+name+".api_url", apiURL)
+		viper.Set("contexts."+name+".token", token)
+	} else {
+		cfg.Set("api_url", apiURL)
+		viper.Set("api_url", apiURL)
+		viper.Set("token", token)
+	}
+	saveTLSSettings(cfg, name)
+
+	if err := saveSecret(cfg, backend, name, "token", apiURL, token); err != nil {
+		return fmt.Errorf("saving credentials: %w", err)
+	}
+	if err := saveSession(cfg, backend, name, apiURL, session); err != nil {
+		return fmt.Errorf("saving credentials: %w", err)
+	}
+
+	if err := cfg.Save(); err != nil {
+		return fmt.Errorf("saving configuration: %w", err)
 	}
 
 	fmt.Println("Successfully logged in to Terrakube server")
+	return nil
 }
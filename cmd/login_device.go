@@ -0,0 +1,176 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/viper"
+
+	"terrakube/internal/config"
+	"terrakube/internal/credentials"
+	"terrakube/internal/oidc"
+)
+
+// defaultOIDCClientID is the public client the CLI registers as with the
+// issuer when neither --client-id nor oidc_client_id is set.
+const defaultOIDCClientID = "terrakube-cli"
+
+// refreshLeeway is how long before expiry an access token is refreshed.
+const refreshLeeway = time.Minute
+
+var deviceLogin bool
+var oidcIssuer string
+var oidcClientID string
+
+// deviceSession is the result of a device login.
+type deviceSession struct {
+	Issuer   string
+	ClientID string
+	Token    *oidc.Token
+}
+
+// deviceAuthorize runs the device authorization grant: it prints the
+// verification URL and user code and waits for the user to approve.
+func deviceAuthorize() (*deviceSession, error) {
+	issuer := oidcIssuer
+	if issuer == "" {
+		issuer = profileValue("oidc_issuer")
+	}
+	if issuer == "" {
+		return nil, fmt.Errorf("no OIDC issuer configured, use --issuer")
+	}
+	clientID := oidcClientID
+	if clientID == "" {
+		clientID = profileValue("oidc_client_id")
+	}
+	if clientID == "" {
+		clientID = defaultOIDCClientID
+	}
+
+	hc, err := httpClient()
+	if err != nil {
+		return nil, err
+	}
+	ctx := getContext()
+	provider, err := oidc.Discover(ctx, hc, issuer)
+	if err != nil {
+		return nil, err
+	}
+	dc, err := provider.StartDevice(ctx, clientID, oidc.DefaultScopes)
+	if err != nil {
+		return nil, err
+	}
+
+	fmt.Printf("To sign in, open %s and enter the code %s\n", dc.VerificationURI, dc.UserCode)
+	if dc.VerificationURIComplete != "" {
+		fmt.Printf("or open %s\n", dc.VerificationURIComplete)
+	}
+	fmt.Println("Waiting for authorization...")
+
+	tok, err := provider.PollDevice(ctx, clientID, dc)
+	if err != nil {
+		return nil, err
+	}
+	return &deviceSession{Issuer: issuer, ClientID: clientID, Token: tok}, nil
+}
+
+// saveSession stores the refresh token and OIDC settings of a device login
+// in the named profile. A nil session, from a PAT login, removes them.
+func saveSession(cfg *config.File, backend, name, server string, session *deviceSession) error {
+	if session == nil {
+		if ref := cfg.ProfileValue(name, "refresh_token_ref"); ref != "" {
+			if err := deleteStoredSecret(ref, server); err != nil {
+				return err
+			}
+		}
+		for _, key := range []string{"refresh_token", "refresh_token_ref", "token_expiry", "oidc_issuer", "oidc_client_id"} {
+			cfg.UnsetProfileValue(name, key)
+		}
+		return nil
+	}
+
+	cfg.SetProfileValue(name, "oidc_issuer", session.Issuer)
+	cfg.SetProfileValue(name, "oidc_client_id", session.ClientID)
+	return saveRefreshedToken(cfg, backend, name, server, session.Token)
+}
+
+// saveRefreshedToken stores a token issued by the OIDC issuer in the named
+// profile and in viper, so later clients of this run use it too.
+func saveRefreshedToken(cfg *config.File, backend, name, server string, tok *oidc.Token) error {
+	if err := saveSecret(cfg, backend, name, "token", server, tok.AccessToken); err != nil {
+		return err
+	}
+	if tok.RefreshToken != "" {
+		if err := saveSecret(cfg, backend, name, "refresh_token", server, tok.RefreshToken); err != nil {
+			return err
+		}
+	}
+	if tok.Expiry.IsZero() {
+		cfg.UnsetProfileValue(name, "token_expiry")
+	} else {
+		cfg.SetProfileValue(name, "token_expiry", tok.Expiry.UTC().Format(time.RFC3339))
+	}
+
+	viper.Set(profileKey(name, "token"), tok.AccessToken)
+	viper.Set(profileKey(name, "token_expiry"), cfg.ProfileValue(name, "token_expiry"))
+	return nil
+}
+
+// refreshAccessToken returns a new access token when token came from a
+// device login and expires within refreshLeeway. Otherwise token is
+// returned unchanged.
+func refreshAccessToken(token string) (string, error) {
+	if os.Getenv(envPrefix+"_TOKEN") != "" {
+		return token, nil
+	}
+	issuer := profileValue("oidc_issuer")
+	if issuer == "" {
+		return token, nil
+	}
+	expiry, err := time.Parse(time.RFC3339, profileValue("token_expiry"))
+	if err != nil || time.Until(expiry) > refreshLeeway {
+		return token, nil
+	}
+	refresh, err := profileSecret("refresh_token")
+	if err != nil || refresh == "" {
+		return token, err
+	}
+
+	clientID := profileValue("oidc_client_id")
+	if clientID == "" {
+		clientID = defaultOIDCClientID
+	}
+	hc, err := httpClient()
+	if err != nil {
+		return "", err
+	}
+	ctx := getContext()
+	provider, err := oidc.Discover(ctx, hc, issuer)
+	if err != nil {
+		return "", err
+	}
+	tok, err := provider.Refresh(ctx, clientID, refresh)
+	if err != nil {
+		return "", fmt.Errorf("%w, run \"%s login --device\" again", err, rootCmd.Use)
+	}
+
+	cfg, err := config.Load(configPath())
+	if err != nil {
+		return "", err
+	}
+	name := activeContext()
+	backend := credentials.Plaintext
+	if ref := cfg.ProfileValue(name, "token_ref"); ref != "" {
+		if backend, _, err = credentials.ParseRef(ref); err != nil {
+			return "", err
+		}
+	}
+	if err := saveRefreshedToken(cfg, backend, name, profileValue("api_url"), tok); err != nil {
+		return "", err
+	}
+	if err := cfg.Save(); err != nil {
+		return "", err
+	}
+	return tok.AccessToken, nil
+}
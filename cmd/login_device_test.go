@@ -0,0 +1,112 @@
+package cmd
+
+import (
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/jsonapi"
+	terrakube "github.com/terrakube-io/terrakube-go"
+
+	"terrakube/internal/config"
+	"terrakube/testutil"
+)
+
+func TestCmdLoginDevice(t *testing.T) {
+	resetGlobalFlags()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	cfgFile = path
+	iss := testutil.NewOIDCIssuer(t)
+
+	var authHeader string
+	ts := setupTestServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authHeader = r.Header.Get("Authorization")
+		w.Header().Set("Content-Type", "application/vnd.api+json")
+		_ = jsonapi.MarshalPayload(w, []*terrakube.Organization{})
+	}))
+	defer ts.Close()
+
+	out, err := executeCommand("login", "--api-url", ts.URL, "--device", "--issuer", iss.URL())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(out, "ABCD-EFGH") || !strings.Contains(out, iss.URL()+"/device") {
+		t.Errorf("expected verification URL and user code in output, got: %s", out)
+	}
+	if !strings.Contains(out, "Successfully logged in") {
+		t.Fatalf("expected login to succeed, got: %s", out)
+	}
+	if authHeader != "Bearer device-access-token" {
+		t.Errorf("expected connection test with the device token, got %q", authHeader)
+	}
+
+	cfg, _ := config.Load(path)
+	if got := cfg.Get("token"); got != "device-access-token" {
+		t.Errorf("expected access token to be saved, got %q", got)
+	}
+	if got := cfg.Get("refresh_token"); got != "device-refresh-token" {
+		t.Errorf("expected refresh token to be saved, got %q", got)
+	}
+	if got := cfg.Get("oidc_issuer"); got != iss.URL() {
+		t.Errorf("expected issuer to be saved, got %q", got)
+	}
+	if got := cfg.Get("oidc_client_id"); got != defaultOIDCClientID {
+		t.Errorf("expected default client id, got %q", got)
+	}
+	if _, err := time.Parse(time.RFC3339, cfg.Get("token_expiry")); err != nil {
+		t.Errorf("expected token_expiry to be saved: %v", err)
+	}
+}
+
+func TestCmdLoginPatAndDeviceExclusive(t *testing.T) {
+	resetGlobalFlags()
+	_, err := executeCommand("login", "--api-url", "http://localhost", "--pat", "x", "--device")
+	if err == nil {
+		t.Fatal("expected error when combining --pat and --device")
+	}
+}
+
+func TestCmdRefreshesExpiredToken(t *testing.T) {
+	resetGlobalFlags()
+	iss := testutil.NewOIDCIssuer(t)
+	iss.AccessToken = "fresh-access-token"
+	iss.RefreshToken = "rotated-refresh-token"
+
+	var authHeader string
+	ts := setupTestServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authHeader = r.Header.Get("Authorization")
+		w.Header().Set("Content-Type", "application/vnd.api+json")
+		_ = jsonapi.MarshalPayload(w, []*terrakube.Organization{})
+	}))
+	defer ts.Close()
+
+	path := writeContextsConfig(t, map[string]string{"sso": ts.URL}, "sso")
+	cfg, _ := config.Load(path)
+	cfg.SetContextValue("sso", "refresh_token", "old-refresh-token")
+	cfg.SetContextValue("sso", "oidc_issuer", iss.URL())
+	cfg.SetContextValue("sso", "token_expiry", time.Now().Add(-time.Hour).UTC().Format(time.RFC3339))
+	if err := cfg.Save(); err != nil {
+		t.Fatalf("saving config: %v", err)
+	}
+	cfgFile = path
+
+	if _, err := executeCommand("organization", "list", "--context", "sso"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if iss.Refreshed != "old-refresh-token" {
+		t.Errorf("expected refresh with the stored refresh token, got %q", iss.Refreshed)
+	}
+	if authHeader != "Bearer fresh-access-token" {
+		t.Errorf("expected refreshed token to be used, got %q", authHeader)
+	}
+
+	cfg, _ = config.Load(path)
+	if got := cfg.ContextValue("sso", "token"); got != "fresh-access-token" {
+		t.Errorf("expected refreshed token to be saved, got %q", got)
+	}
+	if got := cfg.ContextValue("sso", "refresh_token"); got != "rotated-refresh-token" {
+		t.Errorf("expected rotated refresh token to be saved, got %q", got)
+	}
+}
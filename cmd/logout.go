@@ -4,21 +4,102 @@ import (
 	"fmt"
 
 	"github.com/spf13/cobra"
+
+	"terrakube/internal/config"
 )
 
 const logoutLong = `
 Remove credentials stored for a remote Terrakube server.
+
+The token and API URL of the context in use are removed from the config file,
+and from the credential store when the token is kept in one.
+When no context is selected the top-level settings are removed instead. Use
+--all to remove the credentials of every context.
 `
 
+var logoutExamples = `
+Logout from the current server
+  %[1]v logout
+
+Logout from a named context
+  %[1]v logout --context prod
+
+Remove the credentials of every context
+  %[1]v logout --all
+`
+
+// secretFields are the profile settings that may live in a credential store.
+var secretFields = []string{"token", "refresh_token"}
+
+// credentialKeys are the settings removed by logout.
+var credentialKeys = []string{
+	"token", "token_ref", "refresh_token", "refresh_token_ref", "token_expiry", "api_url",
+}
+
 var logoutCmd = &cobra.Command{
-	Use:   "logout",
-	Short: "logout from Terrakube server",
-	Long:  logoutLong,
-	Run: func(cmd *cobra.Command, args []string) {
-		fmt.Println("logout ok")
+	Use:          "logout",
+	Short:        "logout from Terrakube server",
+	Long:         logoutLong,
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		all, _ := cmd.Flags().GetBool("all")
+		return logout(all)
 	},
+	Example: fmt.Sprintf(logoutExamples, rootCmd.Use),
 }
 
 func init() {
+	logoutCmd.Flags().Bool("all", false, "Remove the credentials of every context")
 	rootCmd.AddCommand(logoutCmd)
 }
+
+func logout(all bool) error {
+	cfg, err := config.Load(configPath())
+	if err != nil {
+		return err
+	}
+
+	removed := false
+	if all {
+		for _, name := range append(cfg.Contexts(), "") {
+			ok, err := unsetCredentials(cfg, name)
+			if err != nil {
+				return err
+			}
+			removed = ok || removed
+		}
+	} else {
+		removed, err = unsetCredentials(cfg, activeContext())
+		if err != nil {
+			return err
+		}
+	}
+
+	if !removed {
+		return fmt.Errorf("%w: no stored credentials found in %s", errNotLoggedIn, cfg.Path)
+	}
+	if err := cfg.Save(); err != nil {
+		return err
+	}
+
+	fmt.Println("Successfully logged out")
+	return nil
+}
+
+// unsetCredentials removes the credentials of the named context, or the
+// top-level ones when name is empty, including secrets in credential stores.
+func unsetCredentials(cfg *config.File, name string) (bool, error) {
+	for _, field := range secretFields {
+		if ref := cfg.ProfileValue(name, field+"_ref"); ref != "" {
+			if err := deleteStoredSecret(ref, cfg.ProfileValue(name, "api_url")); err != nil {
+				return false, err
+			}
+		}
+	}
+
+	removed := false
+	for _, key := range credentialKeys {
+		removed = cfg.UnsetProfileValue(name, key) || removed
+	}
+	return removed, nil
+}
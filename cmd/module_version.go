@@ -15,8 +15,9 @@ func init() {
 			GetContext: getContext,
 			GetOutput:  func() string { return output },
 		},
-		Name:    "module-version",
-		Aliases: []string{"module-versions"},
+		Name:       "module-version",
+		Aliases:    []string{"module-versions"},
+		Collection: "version",
 		Parents: []resource.ParentScope{
 			{
 				Name:      "organization",
@@ -35,6 +36,7 @@ func init() {
 				Resolver:  moduleResolver,
 			},
 		},
+		Identity: "version",
 		Fields: []resource.FieldDef{
 			{StructField: "Version", Flag: "version", Type: resource.String, Required: true, Description: "Module version"},
 			{StructField: "Commit", Flag: "commit", Type: resource.String, Description: "Commit reference"},
@@ -41,7 +41,7 @@ func init() {
 			{StructField: "Description", Flag: "description", Short: "d", Type: resource.String, Description: "Notification configuration description"},
 			{StructField: "ChannelType", Flag: "channel-type", Type: resource.String, Required: true, Description: "Channel type (SLACK, TEAMS, WEBHOOK)"},
 			{StructField: "DestinationURL", Flag: "destination-url", Type: resource.String, Required: true, Description: "Destination URL"},
-			{StructField: "SigningSecret", Flag: "signing-secret", Type: resource.String, Description: "Signing secret for payload verification"},
+			{StructField: "SigningSecret", Flag: "signing-secret", Type: resource.String, Description: "Signing secret for payload verification", Sensitive: true},
 			{StructField: "Active", Flag: "active", Type: resource.Bool, Description: "Whether notification configuration is active"},
 			{StructField: "MessageStyle", Flag: "message-style", Type: resource.String, Description: "Message style (DETAILED, SIMPLE)"},
 		},
@@ -15,8 +15,9 @@ func init() {
 			GetContext: getContext,
 			GetOutput:  func() string { return output },
 		},
-		Name:    "notification-trigger",
-		Aliases: []string{"nt", "notification-triggers", "trigger", "triggers"},
+		Name:       "notification-trigger",
+		Aliases:    []string{"nt", "notification-triggers", "trigger", "triggers"},
+		Collection: "triggers",
 		Parents: []resource.ParentScope{
 			{
 				Name:      "organization",
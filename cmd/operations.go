@@ -7,32 +7,73 @@ import (
 
 	"github.com/spf13/cobra"
 	terrakube "github.com/terrakube-io/terrakube-go"
+	"gopkg.in/yaml.v3"
+
+	"terrakube/internal/resource"
 )
 
+const operationsLong = `
+Submit a JSON:API atomic operations request, written in JSON or YAML, so that
+all of its operations are applied or none are:
+
+  atomic:operations:
+    - op: add
+      href: /organization/a1b2c3d4-e5f6-7890-abcd-ef1234567890/workspace
+      data:
+        type: workspace
+        lid: vpc
+        attributes:
+          name: staging-vpc
+
+Use "operations validate" to check a file before sending it, and --dry-run to
+print the request that would be sent.
+
+Instead of writing the file by hand, build it with the usual create, update
+and delete commands and --emit-operation: each appends its operation to
+--ops-file rather than calling the API. As names cannot be resolved without
+the API, parents and targets are then given by ID.
+`
+
+var operationsExamples = `
+Submit a batch of operations
+  %[1]v operations -f batch.yaml
+
+Print the JSON request for a YAML batch without sending it
+  %[1]v operations -f batch.yaml --dry-run
+
+Build a batch with the usual commands, then submit it
+  %[1]v workspace create -o a1b2c3d4-e5f6-7890-abcd-ef1234567890 --name staging-vpc --emit-operation --ops-file batch.json
+  %[1]v tag delete -o a1b2c3d4-e5f6-7890-abcd-ef1234567890 --id 0f1e2d3c-4b5a-6978-8796-a5b4c3d2e1f0 --emit-operation --ops-file batch.json
+  %[1]v operations -f batch.json
+`
+
 var operationsCmd = &cobra.Command{
 	Use:     "operations",
 	Short:   "submit JSON:API atomic operations batch requests",
+	Long:    operationsLong,
+	Example: fmt.Sprintf(operationsExamples, rootCmd.Use),
 	Aliases: []string{"ops"},
 	RunE: func(cmd *cobra.Command, _ []string) error {
-		client := newClient()
-		ctx := getContext()
-
 		file, _ := cmd.Flags().GetString("file")
 		if file == "" {
 			return fmt.Errorf("flag --file is required")
 		}
-
-		data, err := os.ReadFile(file)
+		req, err := readAtomicRequest(file)
 		if err != nil {
-			return fmt.Errorf("reading operations file: %w", err)
+			return err
 		}
 
-		var req terrakube.AtomicRequest
-		if err := json.Unmarshal(data, &req); err != nil {
-			return fmt.Errorf("parsing operations JSON: %w", err)
+		if dryRun, _ := cmd.Flags().GetBool("dry-run"); dryRun {
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			return enc.Encode(req)
 		}
 
-		resp, err := client.Operations.Submit(ctx, &req)
+		client, err := newClient()
+		if err != nil {
+			return err
+		}
+		resp, err := client.Operations.Submit(getContext(), req)
 		if err != nil {
 			return err
 		}
@@ -42,8 +83,76 @@ var operationsCmd = &cobra.Command{
 	},
 }
 
+var operationsValidateCmd = &cobra.Command{
+	Use:          "validate -f FILE",
+	Short:        "check an atomic operations file without sending it",
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		file, _ := cmd.Flags().GetString("file")
+		req, err := readAtomicRequest(file)
+		if err != nil {
+			return err
+		}
+		if err := resource.ValidateAtomic(req); err != nil {
+			return fmt.Errorf("%s: %w", file, err)
+		}
+		fmt.Printf("%s: %d operations valid\n", file, len(req.Operations))
+		return nil
+	},
+}
+
+// setupEmitOperation makes the create, update and delete commands append
+// their operation to --ops-file when --emit-operation is set. Other
+// commands would still call the API, so they are refused.
+func setupEmitOperation(cmd *cobra.Command) error {
+	resource.OpsFile = ""
+	if !emitOperation {
+		return nil
+	}
+	if cmd.Annotations[resource.EmitAnnotation] == "" {
+		return fmt.Errorf("%q does not support --emit-operation, only the create, update and delete commands of a resource do", cmd.CommandPath())
+	}
+	if opsFile == "" {
+		return fmt.Errorf("--emit-operation requires --ops-file")
+	}
+	resource.OpsFile = opsFile
+	return nil
+}
+
+// readAtomicRequest reads an atomic operations request from a JSON or YAML
+// file.
+func readAtomicRequest(path string) (*terrakube.AtomicRequest, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading operations file: %w", err)
+	}
+
+	// YAML is a superset of JSON: decode either, then convert to JSON for
+	// the request types.
+	var doc any
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("parsing operations file: %w", err)
+	}
+	data, err = json.Marshal(doc)
+	if err != nil {
+		return nil, fmt.Errorf("parsing operations file: %w", err)
+	}
+	var req terrakube.AtomicRequest
+	if err := json.Unmarshal(data, &req); err != nil {
+		return nil, fmt.Errorf("parsing operations file: %w", err)
+	}
+	return &req, nil
+}
+
 func init() {
-	operationsCmd.Flags().StringP("file", "f", "", "Path to JSON file containing atomic operations")
+	operationsCmd.Flags().StringP("file", "f", "", "Path to JSON or YAML file containing atomic operations")
+	operationsCmd.Flags().Bool("dry-run", false, "Print the request as JSON instead of sending it")
 	_ = operationsCmd.MarkFlagRequired("file")
+
+	operationsValidateCmd.Flags().StringP("file", "f", "", "Path to JSON or YAML file containing atomic operations")
+	_ = operationsValidateCmd.MarkFlagRequired("file")
+	operationsCmd.AddCommand(operationsValidateCmd)
+
 	rootCmd.AddCommand(operationsCmd)
 }
@@ -0,0 +1,198 @@
+package cmd
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"terrakube/testutil"
+)
+
+func writeOperations(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "batch.yaml")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestCmdOperationsDryRunYAML(t *testing.T) {
+	resetGlobalFlags()
+	path := writeOperations(t, `atomic:operations:
+  - op: add
+    href: /organization/`+testutil.FixtureOrganization().ID+`/workspace
+    data:
+      type: workspace
+      lid: vpc
+      attributes:
+        name: staging-vpc
+`)
+
+	out, err := executeCommand("operations", "-f", path, "--dry-run")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, want := range []string{`"atomic:operations": [`, `"op": "add"`, `"lid": "vpc"`, `"name": "staging-vpc"`} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %s in the request, got:\n%s", want, out)
+		}
+	}
+}
+
+func TestCmdOperationsValidate(t *testing.T) {
+	resetGlobalFlags()
+	org := testutil.FixtureOrganization().ID
+	valid := writeOperations(t, `{"atomic:operations": [
+  {"op": "add", "href": "/organization/`+org+`/workspace", "data": {"type": "workspace", "lid": "vpc", "attributes": {"name": "staging-vpc"}}},
+  {"op": "add", "href": "/organization/`+org+`/workspace/vpc/variable", "data": {"type": "variable", "attributes": {"key": "AWS_REGION"}}}
+]}`)
+	out, err := executeCommand("operations", "validate", "-f", valid)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(out, "2 operations valid") {
+		t.Errorf("expected the operations to be valid, got: %s", out)
+	}
+
+	resetGlobalFlags()
+	invalid := writeOperations(t, `atomic:operations:
+  - op: create
+    href: /organization/`+org+`/workspace
+    data: {type: workspace, attributes: {colour: red}}
+  - op: update
+    data: {type: gizmo, lid: missing}
+  - op: add
+    href: /organization/`+org+`/workspace/vpc/variable
+    data: {type: variable, attributes: {key: AWS_REGION}}
+  - op: add
+    href: /organization/`+org+`/workspace
+    data: {type: workspace, lid: vpc, attributes: {name: staging-vpc}}
+`)
+	_, err = executeCommand("operations", "validate", "-f", invalid)
+	if err == nil {
+		t.Fatal("expected validation errors")
+	}
+	for _, want := range []string{
+		`/atomic:operations/0/op: expected add, update or remove, got "create"`,
+		"/atomic:operations/0/data/attributes/colour: unknown attribute of workspace",
+		"/atomic:operations/1: ref or href is required",
+		`/atomic:operations/1/data/type: unknown type "gizmo"`,
+		`/atomic:operations/1/data/lid: lid "missing" is not added by an earlier operation`,
+		`/atomic:operations/2/href: lid "vpc" is not added by an earlier operation`,
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("expected %q in the error, got:\n%v", want, err)
+		}
+	}
+}
+
+func TestCmdEmitOperationE2E(t *testing.T) {
+	resetGlobalFlags()
+	ts := setupTestServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected request %s %s", r.Method, r.URL)
+	}))
+	defer ts.Close()
+	org, ws, variable := testutil.FixtureOrganization().ID, testutil.FixtureWorkspace().ID, testutil.FixtureVariable().ID
+	path := filepath.Join(t.TempDir(), "batch.json")
+
+	out, err := executeCommand("variable", "create", "-o", org, "-w", ws, "--key", "AWS_REGION", "--value", "eu-west-1",
+		"--category", "ENV", "--emit-operation", "--ops-file", path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(out, "add variable appended to "+path+" (1 operations)") {
+		t.Errorf("unexpected output: %s", out)
+	}
+
+	if _, err := executeCommand("variable", "delete", "-o", org, "-w", ws, "--id", variable, "--emit-operation", "--ops-file", path); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	req, err := readAtomicRequest(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(req.Operations) != 2 {
+		t.Fatalf("expected 2 operations, got %+v", req.Operations)
+	}
+	add, remove := req.Operations[0], req.Operations[1]
+	href := "/organization/" + org + "/workspace/" + ws + "/variable"
+	if add.Op != "add" || add.Href != href || !strings.Contains(string(add.Data), `"attributes":{"category":"ENV","key":"AWS_REGION","value":"eu-west-1"}`) {
+		t.Errorf("unexpected add operation: %s %s %s", add.Op, add.Href, add.Data)
+	}
+	if remove.Op != "remove" || remove.Href != href+"/"+variable {
+		t.Errorf("unexpected remove operation: %s %s", remove.Op, remove.Href)
+	}
+
+	if _, err := executeCommand("operations", "validate", "-f", path); err != nil {
+		t.Errorf("expected the emitted operations to be valid: %v", err)
+	}
+}
+
+func TestCmdEmitOperationRequiresFile(t *testing.T) {
+	resetGlobalFlags()
+	_, err := executeCommand("tag", "delete", "-o", testutil.FixtureOrganization().ID, "--id", "t-1", "--emit-operation")
+	if err == nil || !strings.Contains(err.Error(), "--emit-operation requires --ops-file") {
+		t.Errorf("expected a missing --ops-file error, got %v", err)
+	}
+}
+
+func TestCmdEmitOperationWithoutAPI(t *testing.T) {
+	resetGlobalFlags()
+	cfgFile = filepath.Join(t.TempDir(), "config.yaml")
+	org, ws := testutil.FixtureOrganization().ID, testutil.FixtureWorkspace().ID
+	path := filepath.Join(t.TempDir(), "batch.json")
+
+	// Not logged in: emitting must not need a client.
+	if _, err := executeCommand("variable", "update", "-o", org, "-w", ws, testutil.FixtureVariable().ID, "--value", "eu-west-1",
+		"--emit-operation", "--ops-file", path); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	_, err := executeCommand("variable", "delete", "-o", testutil.FixtureOrganization().Name, "-w", ws, "--id", "v-1",
+		"--emit-operation", "--ops-file", path)
+	if err == nil || !strings.Contains(err.Error(), "is not an ID") {
+		t.Errorf("expected a parent name to be rejected, got %v", err)
+	}
+	_, err = executeCommand("workspace", "delete", "-o", org, "production-vpc", "--emit-operation", "--ops-file", path)
+	if err == nil || !strings.Contains(err.Error(), "is not an ID") {
+		t.Errorf("expected a target name to be rejected, got %v", err)
+	}
+	_, err = executeCommand("workspace", "edit", "-o", org, "--id", ws, "--emit-operation", "--ops-file", path)
+	if err == nil || !strings.Contains(err.Error(), "does not support --emit-operation") {
+		t.Errorf("expected edit to be refused, got %v", err)
+	}
+
+	req, err := readAtomicRequest(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(req.Operations) != 1 || req.Operations[0].Op != "update" {
+		t.Errorf("expected only the update operation, got %+v", req.Operations)
+	}
+}
+
+func TestCmdEmitOperationRefusesOtherCommands(t *testing.T) {
+	resetGlobalFlags()
+	ts := setupTestServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected request %s %s", r.Method, r.URL)
+	}))
+	defer ts.Close()
+	dir := t.TempDir()
+	manifest := filepath.Join(dir, "infra.yaml")
+	if err := os.WriteFile(manifest, []byte("kind: organization\nspec:\n  name: acme-corp\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "batch.json")
+
+	_, err := executeCommand("apply", "-f", manifest, "--emit-operation", "--ops-file", path)
+	if err == nil || !strings.Contains(err.Error(), `"terrakube apply" does not support --emit-operation`) {
+		t.Errorf("expected apply to be refused, got %v", err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("expected no operations file, got %v", err)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"io"
 	"net/http"
+	"strconv"
 	"strings"
 	"testing"
 
@@ -43,6 +44,61 @@ func TestCmdOrganizationListE2E(t *testing.T) {
 	}
 }
 
+// pagedOrganizations serves the organization fixtures a page at a time and
+// records the requested pages.
+func pagedOrganizations(t *testing.T, pages *[]string) {
+	t.Helper()
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*pages = append(*pages, r.URL.Query().Get("page[number]"))
+		number, _ := strconv.Atoi(r.URL.Query().Get("page[number]"))
+		size, _ := strconv.Atoi(r.URL.Query().Get("page[size]"))
+		orgs := testutil.FixtureOrganizationList()
+		start, end := min((number-1)*size, len(orgs)), min(number*size, len(orgs))
+
+		w.Header().Set("Content-Type", "application/vnd.api+json")
+		_ = jsonapi.MarshalPayload(w, orgs[start:end])
+	})
+	ts := setupTestServer(handler)
+	t.Cleanup(ts.Close)
+}
+
+func TestCmdOrganizationListAll(t *testing.T) {
+	resetGlobalFlags()
+	var pages []string
+	pagedOrganizations(t, &pages)
+
+	out, err := executeCommand("organization", "list", "--all", "--page-size", "2", "--output", "tsv")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if strings.Join(pages, ",") != "1,2" {
+		t.Errorf("expected pages 1 and 2 to be requested, got %v", pages)
+	}
+	for _, name := range []string{"acme-corp", "globex-corp", "initech"} {
+		if !strings.Contains(out, name) {
+			t.Errorf("expected output to contain %q, got: %s", name, out)
+		}
+	}
+}
+
+func TestCmdOrganizationListLimit(t *testing.T) {
+	resetGlobalFlags()
+	var pages []string
+	pagedOrganizations(t, &pages)
+
+	out, err := executeCommand("organization", "list", "--limit", "2", "--page-size", "1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var orgs []map[string]any
+	if err := json.Unmarshal([]byte(out), &orgs); err != nil {
+		t.Fatalf("expected a JSON array, got %q: %v", out, err)
+	}
+	if len(orgs) != 2 || len(pages) != 2 {
+		t.Errorf("expected 2 organizations from 2 pages, got %d from %v", len(orgs), pages)
+	}
+}
+
 func TestCmdOrganizationGetE2E(t *testing.T) {
 	resetGlobalFlags()
 
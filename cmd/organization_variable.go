@@ -15,8 +15,9 @@ func init() {
 			GetContext: getContext,
 			GetOutput:  func() string { return output },
 		},
-		Name:    "organization-variable",
-		Aliases: []string{"org-var", "org-vars", "organization-variables"},
+		Name:       "organization-variable",
+		Aliases:    []string{"org-var", "org-vars", "organization-variables"},
+		Collection: "globalvar",
 		Parents: []resource.ParentScope{{
 			Name:      "organization",
 			Flag:      "organization",
@@ -25,9 +26,10 @@ func init() {
 			IDFlag:    "organization-id",
 			Resolver:  orgResolver,
 		}},
+		Identity: "key",
 		Fields: []resource.FieldDef{
 			{StructField: "Key", Flag: "key", Short: "k", Type: resource.String, Required: true, Description: "Variable key"},
-			{StructField: "Value", Flag: "value", Short: "v", Type: resource.String, Required: true, Description: "Variable value"},
+			{StructField: "Value", Flag: "value", Short: "v", Type: resource.String, Required: true, Description: "Variable value", SensitiveIf: "sensitive"},
 			{StructField: "Description", Flag: "description", Short: "d", Type: resource.String, Description: "Variable description"},
 			{StructField: "Category", Flag: "category", Type: resource.String, Required: true, Description: "Variable category (ENV, TERRAFORM)"},
 			{StructField: "Sensitive", Flag: "sensitive", Type: resource.Bool, Description: "Whether the variable is sensitive"},
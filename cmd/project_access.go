@@ -26,9 +26,10 @@ func init() {
 				Resolver:  orgResolver,
 			},
 			{
-				Name:   "project",
-				Flag:   "project",
-				IDFlag: "project-id",
+				Name:     "project",
+				Flag:     "project",
+				IDFlag:   "project-id",
+				Resolver: projectResolver,
 			},
 		},
 		Fields: []resource.FieldDef{
@@ -15,8 +15,9 @@ func init() {
 			GetContext: getContext,
 			GetOutput:  func() string { return output },
 		},
-		Name:    "provider-version",
-		Aliases: []string{"provider-versions"},
+		Name:       "provider-version",
+		Aliases:    []string{"provider-versions"},
+		Collection: "version",
 		Parents: []resource.ParentScope{
 			{
 				Name:      "organization",
@@ -33,6 +34,7 @@ func init() {
 				Resolver: providerResolver,
 			},
 		},
+		Identity: "version-number",
 		Fields: []resource.FieldDef{
 			{StructField: "VersionNumber", Flag: "version-number", Type: resource.String, Required: true, Description: "Provider version number"},
 			{StructField: "Protocols", Flag: "protocols", Type: resource.String, Description: "Supported protocols"},
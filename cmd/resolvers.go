@@ -2,64 +2,62 @@ package cmd
 
 import (
 	"context"
-	"fmt"
 
 	terrakube "github.com/terrakube-io/terrakube-go"
+
+	"terrakube/internal/resource"
 )
 
-func collectionResolver(ctx context.Context, c *terrakube.Client, resolvedParentIDs []string, name string) (string, error) {
-	cols, err := c.Collections.List(ctx, resolvedParentIDs[0], &terrakube.ListOptions{Filter: "name==" + name})
-	if err != nil {
-		return "", err
-	}
-	if len(cols) == 0 {
-		return "", fmt.Errorf("no collection found with name %q", name)
-	}
-	if len(cols) > 1 {
-		return "", fmt.Errorf("multiple collections match name %q, use --collection with the ID", name)
-	}
-	return cols[0].ID, nil
-}
+// Resolvers for the --<parent> flags, which accept a UUID or a name. Webhooks
+// are looked up by path and provider versions by version number.
+var (
+	orgResolver = resource.NameResolver("organization", "organization", "name",
+		func(ctx context.Context, c *terrakube.Client, _ []string, opts *terrakube.ListOptions) ([]*terrakube.Organization, error) {
+			return c.Organizations.List(ctx, opts)
+		})
+
+	workspaceResolver = resource.NameResolver("workspace", "workspace", "name",
+		func(ctx context.Context, c *terrakube.Client, pIDs []string, opts *terrakube.ListOptions) ([]*terrakube.Workspace, error) {
+			return c.Workspaces.List(ctx, pIDs[0], opts)
+		})
+
+	collectionResolver = resource.NameResolver("collection", "collection", "name",
+		func(ctx context.Context, c *terrakube.Client, pIDs []string, opts *terrakube.ListOptions) ([]*terrakube.Collection, error) {
+			return c.Collections.List(ctx, pIDs[0], opts)
+		})
+
+	providerResolver = resource.NameResolver("provider", "provider", "name",
+		func(ctx context.Context, c *terrakube.Client, pIDs []string, opts *terrakube.ListOptions) ([]*terrakube.Provider, error) {
+			return c.Providers.List(ctx, pIDs[0], opts)
+		})
 
-func providerResolver(ctx context.Context, c *terrakube.Client, resolvedParentIDs []string, name string) (string, error) {
-	provs, err := c.Providers.List(ctx, resolvedParentIDs[0], &terrakube.ListOptions{Filter: "name==" + name})
-	if err != nil {
-		return "", err
-	}
-	if len(provs) == 0 {
-		return "", fmt.Errorf("no provider found with name %q", name)
-	}
-	if len(provs) > 1 {
-		return "", fmt.Errorf("multiple providers match name %q, use --provider with the ID", name)
-	}
-	return provs[0].ID, nil
-}
+	providerVersionResolver = resource.NameResolver("provider-version", "provider-version", "versionNumber",
+		func(ctx context.Context, c *terrakube.Client, pIDs []string, opts *terrakube.ListOptions) ([]*terrakube.ProviderVersion, error) {
+			return c.ProviderVersions.List(ctx, pIDs[0], pIDs[1], opts)
+		})
 
-func moduleResolver(ctx context.Context, c *terrakube.Client, resolvedParentIDs []string, name string) (string, error) {
-	mods, err := c.Modules.List(ctx, resolvedParentIDs[0], &terrakube.ListOptions{Filter: "name==" + name})
-	if err != nil {
-		return "", err
-	}
-	if len(mods) == 0 {
-		return "", fmt.Errorf("no module found with name %q", name)
-	}
-	if len(mods) > 1 {
-		return "", fmt.Errorf("multiple modules match name %q, use --module with the ID", name)
-	}
-	return mods[0].ID, nil
-}
+	moduleResolver = resource.NameResolver("module", "module", "name",
+		func(ctx context.Context, c *terrakube.Client, pIDs []string, opts *terrakube.ListOptions) ([]*terrakube.Module, error) {
+			return c.Modules.List(ctx, pIDs[0], opts)
+		})
 
-func notificationConfigurationResolver(ctx context.Context, c *terrakube.Client, resolvedParentIDs []string, name string) (string, error) {
-	configs, err := c.NotificationConfigurations.List(ctx, resolvedParentIDs[0], &terrakube.ListOptions{Filter: "name==" + name})
-	if err != nil {
-		return "", err
-	}
-	if len(configs) == 0 {
-		return "", fmt.Errorf("no notification-configuration found with name %q", name)
-	}
-	if len(configs) > 1 {
-		return "", fmt.Errorf("multiple notification-configurations match name %q, use --notification-configuration with the ID", name)
-	}
-	return configs[0].ID, nil
-}
+	notificationConfigurationResolver = resource.NameResolver("notification-configuration", "notification-configuration", "name",
+		func(ctx context.Context, c *terrakube.Client, pIDs []string, opts *terrakube.ListOptions) ([]*terrakube.NotificationConfiguration, error) {
+			return c.NotificationConfigurations.List(ctx, pIDs[0], opts)
+		})
 
+	webhookResolver = resource.NameResolver("webhook", "webhook", "path",
+		func(ctx context.Context, c *terrakube.Client, pIDs []string, opts *terrakube.ListOptions) ([]*terrakube.Webhook, error) {
+			return c.Webhooks.List(ctx, pIDs[0], pIDs[1], opts)
+		})
+
+	federatedResolver = resource.NameResolver("federated", "federated", "name",
+		func(ctx context.Context, c *terrakube.Client, _ []string, opts *terrakube.ListOptions) ([]*terrakube.Federated, error) {
+			return c.Federated.List(ctx, opts)
+		})
+
+	projectResolver = resource.NameResolver("project", "project", "name",
+		func(ctx context.Context, c *terrakube.Client, pIDs []string, opts *terrakube.ListOptions) ([]*terrakube.Project, error) {
+			return c.Projects.List(ctx, pIDs[0], opts)
+		})
+)
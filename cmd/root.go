@@ -2,13 +2,17 @@ package cmd
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"os"
+	"os/signal"
 	"path/filepath"
 	"reflect"
 	"regexp"
 	"strings"
+	"syscall"
+	"time"
 
 	"github.com/fatih/color"
 	"github.com/spf13/cobra"
@@ -24,6 +28,9 @@ var cfgFile string
 var output string
 var hideNulls bool
 var verbose bool
+var timeout time.Duration
+var emitOperation bool
+var opsFile string
 var envPrefix string = "TERRAKUBE"
 
 // rootCmd represents the base command when called without any subcommands
@@ -32,14 +39,45 @@ var rootCmd = &cobra.Command{
 	Short: "terrakube command line tool",
 	Long: `
 terrakube is a CLI to handle remote terraform workspace and modules in organizations
-and handle all the lifecycle (plan, apply, destroy).`,
+and handle all the lifecycle (plan, apply, destroy).
+
+Exit codes:
+  0    success
+  1    other errors
+  2    diff found changes
+  3    not logged in
+  4    timed out (--timeout)
+  5    authentication or permission denied by the API
+  6    resource not found
+  7    conflict with the current state of a resource
+  8    request rejected as invalid
+  9    API server unreachable
+  130  interrupted
+
+With an explicit --output json a failed command also writes the error to
+stdout as {"error": {"kind": ..., "message": ..., "exit_code": ..., "errors":
+[...]}}. The default json output leaves stdout empty on failure.`,
 	SilenceErrors: true,
 }
 
 // Execute adds all child commands to the root command and sets flags appropriately.
 // This is called by main.main(). It only needs to happen once to the rootCmd.
 func Execute() {
-	cobra.CheckErr(rootCmd.Execute())
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	defer func() { cancelCommand() }()
+	go func() {
+		// Restore the default handlers so a second Ctrl-C exits right away.
+		<-ctx.Done()
+		stop()
+	}()
+
+	err := rootCmd.ExecuteContext(ctx)
+	if err != nil {
+		code := reportError(os.Stdout, os.Stderr, err, jsonErrors())
+		stop()
+		os.Exit(code)
+	}
 }
 
 func init() {
@@ -51,7 +89,13 @@ func init() {
 	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default is $HOME/.terrakube-cli.yaml)")
 	rootCmd.PersistentFlags().StringVar(&output, "output", "json", "Output format: json, yaml, table, tsv, or none")
 	rootCmd.PersistentFlags().BoolVar(&hideNulls, "hide-nulls", true, "Hide null values in JSON output")
-	rootCmd.PersistentFlags().BoolVar(&verbose, "verbose", false, "Enable verbose output")
+	rootCmd.PersistentFlags().BoolVar(&verbose, "verbose", false, "Enable verbose output, including a line per API request")
+	rootCmd.PersistentFlags().DurationVar(&timeout, "timeout", 0, "Maximum time for the whole command, e.g. 30s or 2m (0 means no limit, default from timeout in the config file)")
+	rootCmd.PersistentFlags().BoolVar(&emitOperation, "emit-operation", false, "Append the atomic operation of create, update and delete commands to --ops-file instead of calling the API")
+	rootCmd.PersistentFlags().StringVar(&opsFile, "ops-file", "", "Atomic operations file written by --emit-operation, for \"operations -f\"")
+	rootCmd.PersistentPreRunE = func(cmd *cobra.Command, _ []string) error {
+		return startCommandContext(cmd)
+	}
 	_ = viper.BindPFlag("output", rootCmd.Flags().Lookup("output"))
 	_ = viper.BindPFlag("hide-nulls", rootCmd.PersistentFlags().Lookup("hide-nulls"))
 	_ = rootCmd.Flags().BoolP("toggle", "t", false, "Help message for toggle")
@@ -98,28 +142,104 @@ func initConfig() {
 	postInitCommands(rootCmd.Commands())
 }
 
-func newClient() *terrakube.Client {
-	endpoint := viper.GetString("api_url")
-	if endpoint == "" {
-		endpoint = "http://localhost:8080"
+func newClient() (*terrakube.Client, error) {
+	name := activeContext()
+	if name != "" && !viper.IsSet("contexts."+name) {
+		return nil, fmt.Errorf("%w: context %q not found in %s", errNotLoggedIn, name, configPath())
+	}
+
+	endpoint := profileValue("api_url")
+	token, err := resolveToken()
+	if err != nil {
+		return nil, err
+	}
+	if endpoint == "" || token == "" {
+		return nil, notLoggedInError(name, endpoint == "")
 	}
-	token := viper.GetString("token")
-	if token == "" {
-		token = "test-token"
+	if token, err = refreshAccessToken(token); err != nil {
+		return nil, err
+	}
+	warnTokenExpiry(token)
+	hc, err := httpClient()
+	if err != nil {
+		return nil, err
 	}
+
 	c, err := terrakube.NewClient(
 		terrakube.WithEndpoint(endpoint),
 		terrakube.WithToken(token),
+		terrakube.WithHTTPClient(hc),
 	)
 	if err != nil {
-		fmt.Printf("Error creating client: %v\n", err)
-		os.Exit(1)
+		return nil, fmt.Errorf("creating client: %w", err)
 	}
-	return c
+	return c, nil
+}
+
+// notLoggedInError explains which setting is missing and how to provide it.
+func notLoggedInError(name string, missingURL bool) error {
+	setting, env := "token", envPrefix+"_TOKEN"
+	if missingURL {
+		setting, env = "API URL", envPrefix+"_API_URL"
+	}
+	if name != "" {
+		return fmt.Errorf("%w: no %s configured for context %q, run \"%s login --context %s\" or set %s",
+			errNotLoggedIn, setting, name, rootCmd.Use, name, env)
+	}
+	return fmt.Errorf("%w: no %s configured, run \"%s login\" or set %s", errNotLoggedIn, setting, rootCmd.Use, env)
+}
+
+// commandCtx is the context of the running command. It is cancelled on
+// SIGINT/SIGTERM and bounded by --timeout.
+var commandCtx = context.Background()
+var cancelCommand context.CancelFunc = func() {}
+var commandTimeout time.Duration
+
+// startCommandContext derives commandCtx from the context the command was
+// executed with, applying --timeout or the timeout config key.
+func startCommandContext(cmd *cobra.Command) error {
+	commandTimeout = timeout
+	if !cmd.Flags().Changed("timeout") {
+		d, err := durationSetting("timeout", timeout)
+		if err != nil {
+			return err
+		}
+		commandTimeout = d
+	}
+
+	if err := setupResolutionCache(); err != nil {
+		return err
+	}
+	if err := setupEmitOperation(cmd); err != nil {
+		return err
+	}
+
+	apiFailures.Reset()
+	cancelCommand()
+	commandCtx, cancelCommand = cmd.Context(), func() {}
+	if commandTimeout > 0 {
+		commandCtx, cancelCommand = context.WithTimeout(commandCtx, commandTimeout)
+	}
+	return nil
 }
 
 func getContext() context.Context {
-	return context.Background()
+	return commandCtx
+}
+
+// contextError explains an error caused by the command context ending: the
+// timeout expiring or the user interrupting the CLI.
+func contextError(err error) error {
+	switch {
+	case errors.Is(err, errTimeout), errors.Is(err, errInterrupted):
+		return err
+	case errors.Is(commandCtx.Err(), context.DeadlineExceeded):
+		return fmt.Errorf("%w after %s: %v", errTimeout, commandTimeout, err)
+	case errors.Is(commandCtx.Err(), context.Canceled):
+		return fmt.Errorf("%w: %v", errInterrupted, err)
+	default:
+		return err
+	}
 }
 
 func renderOutput(result interface{}, format string) {
@@ -0,0 +1,88 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// hangingServer blocks every request until the client gives up.
+func hangingServer(t *testing.T) {
+	t.Helper()
+	ts := setupTestServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(5 * time.Second):
+		}
+	}))
+	t.Cleanup(ts.Close)
+}
+
+func TestCmdTimeoutFlag(t *testing.T) {
+	resetGlobalFlags()
+	hangingServer(t)
+
+	start := time.Now()
+	_, err := executeCommand("organization", "list", "--timeout", "100ms")
+	if err == nil {
+		t.Fatal("expected error when the timeout expires, got nil")
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("expected the request to be cancelled quickly, took %s", elapsed)
+	}
+
+	err = contextError(err)
+	if !errors.Is(err, errTimeout) {
+		t.Fatalf("expected timeout error, got: %v", err)
+	}
+	if !strings.Contains(err.Error(), "100ms") {
+		t.Errorf("expected error to mention the timeout, got: %v", err)
+	}
+	if code := exitCode(err); code != exitCodeTimeout {
+		t.Errorf("expected exit code %d, got %d", exitCodeTimeout, code)
+	}
+}
+
+func TestCmdTimeoutConfig(t *testing.T) {
+	resetGlobalFlags()
+	hangingServer(t)
+	viper.Set("timeout", "100ms")
+	t.Cleanup(func() { viper.Set("timeout", "0s") })
+
+	_, err := executeCommand("organization", "list")
+	if !errors.Is(contextError(err), errTimeout) {
+		t.Fatalf("expected timeout error from the config key, got: %v", err)
+	}
+}
+
+func TestCmdTimeoutConfigInvalid(t *testing.T) {
+	resetGlobalFlags()
+	viper.Set("timeout", "soon")
+	t.Cleanup(func() { viper.Set("timeout", "0s") })
+
+	_, err := executeCommand("organization", "list")
+	if err == nil || !strings.Contains(err.Error(), "invalid timeout") {
+		t.Fatalf("expected invalid timeout error, got: %v", err)
+	}
+}
+
+func TestContextErrorInterrupted(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	old := commandCtx
+	commandCtx = ctx
+	t.Cleanup(func() { commandCtx = old })
+
+	err := contextError(errors.New("request failed"))
+	if !errors.Is(err, errInterrupted) {
+		t.Fatalf("expected interrupted error, got: %v", err)
+	}
+	if code := exitCode(err); code != exitCodeInterrupted {
+		t.Errorf("expected exit code %d, got %d", exitCodeInterrupted, code)
+	}
+}
@@ -27,7 +27,7 @@ func init() {
 		Fields: []resource.FieldDef{
 			{StructField: "Name", Flag: "name", Short: "n", Type: resource.String, Required: true, Description: "SSH key name"},
 			{StructField: "Description", Flag: "description", Short: "d", Type: resource.String, Description: "SSH key description"},
-			{StructField: "PrivateKey", Flag: "private-key", Type: resource.String, Required: true, Description: "SSH private key"},
+			{StructField: "PrivateKey", Flag: "private-key", Type: resource.String, Required: true, Description: "SSH private key", Sensitive: true},
 			{StructField: "SSHType", Flag: "ssh-type", Type: resource.String, Required: true, Description: "SSH key type (rsa, ed25519)"},
 		},
 		List: func(ctx context.Context, c *terrakube.Client, pIDs []string, opts *terrakube.ListOptions) ([]*terrakube.SSH, error) {
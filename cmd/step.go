@@ -34,6 +34,7 @@ func init() {
 				RawID:     true,
 			},
 		},
+		NoExport: true,
 		Fields: []resource.FieldDef{
 			{StructField: "Name", Flag: "name", Short: "n", Type: resource.String, Required: true, Description: "Step name"},
 			{StructField: "Output", Flag: "output", Type: resource.String, Description: "Step output"},
@@ -0,0 +1,171 @@
+package cmd
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/spf13/viper"
+
+	"terrakube/internal/config"
+	"terrakube/internal/transport"
+)
+
+var maxRetries int
+var debugHTTP bool
+var debugFile string
+
+// debugLog is the open --debug-file, kept for the rest of the run.
+var debugLog *os.File
+
+// insecureWarned is set once the insecure-skip-verify warning was printed.
+var insecureWarned bool
+
+// tlsSettings maps the TLS and proxy flags to their profile config keys.
+var tlsSettings = []struct{ flag, key string }{
+	{"ca-file", "ca_file"},
+	{"client-cert", "client_cert"},
+	{"client-key", "client_key"},
+	{"insecure-skip-verify", "insecure_skip_verify"},
+	{"proxy", "proxy"},
+}
+
+func init() {
+	rootCmd.PersistentFlags().IntVar(&maxRetries, "max-retries", transport.DefaultMaxRetries, "Retries for failed idempotent API requests, 0 disables retries (default from max_retries in the config file)")
+	rootCmd.PersistentFlags().BoolVar(&debugHTTP, "debug", false, "Trace API requests and responses to stderr, with credentials redacted")
+	rootCmd.PersistentFlags().StringVar(&debugFile, "debug-file", "", "Append the --debug trace to this file instead of stderr (implies --debug)")
+	rootCmd.PersistentFlags().String("ca-file", "", "PEM bundle of CAs trusted for the API server (default from ca_file)")
+	rootCmd.PersistentFlags().String("client-cert", "", "Client certificate for mutual TLS (default from client_cert)")
+	rootCmd.PersistentFlags().String("client-key", "", "Private key of --client-cert (default from client_key)")
+	rootCmd.PersistentFlags().Bool("insecure-skip-verify", false, "Do not verify the server certificate, INSECURE (default from insecure_skip_verify)")
+	rootCmd.PersistentFlags().String("proxy", "", "Proxy URL for API requests, instead of HTTPS_PROXY (default from proxy)")
+}
+
+// httpClient returns the HTTP client used for API and OIDC requests. Retries
+// are configured by --max-retries and the max_retries, retry_base_delay,
+// retry_max_delay and retry_all_methods config keys. --debug traces every
+// attempt and --verbose prints one line per attempt. TLS and proxy settings
+// come from tlsOptions.
+func httpClient() (*http.Client, error) {
+	opts, err := tlsOptions()
+	if err != nil {
+		return nil, err
+	}
+	if opts.InsecureSkipVerify && !insecureWarned {
+		insecureWarned = true
+		color.New(color.FgRed, color.Bold).Fprintln(os.Stderr,
+			"WARNING: TLS certificate verification is disabled (insecure-skip-verify). "+
+				"The connection, including your token, can be intercepted.")
+	}
+	base, err := transport.NewBase(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	next, err := debugTransport(base)
+	if err != nil {
+		return nil, err
+	}
+
+	retry := &transport.Retry{
+		Next:       next,
+		MaxRetries: maxRetries,
+		AllMethods: viper.GetBool("retry_all_methods"),
+	}
+	if !rootCmd.PersistentFlags().Changed("max-retries") && viper.IsSet("max_retries") {
+		retry.MaxRetries = viper.GetInt("max_retries")
+	}
+
+	if retry.BaseDelay, err = durationSetting("retry_base_delay", transport.DefaultBaseDelay); err != nil {
+		return nil, err
+	}
+	if retry.MaxDelay, err = durationSetting("retry_max_delay", transport.DefaultMaxDelay); err != nil {
+		return nil, err
+	}
+	if verbose || debugHTTP {
+		retry.Logf = func(format string, args ...any) {
+			fmt.Fprintf(os.Stderr, "Retrying: "+format+"\n", args...)
+		}
+	}
+
+	return &http.Client{Transport: &transport.Query{Next: apiFailures.Transport(retry)}}, nil
+}
+
+// tlsOptions returns the TLS and proxy settings: the --ca-file,
+// --client-cert, --client-key, --insecure-skip-verify and --proxy flags, or
+// the matching keys of the active profile.
+func tlsOptions() (transport.Options, error) {
+	values := make(map[string]string, len(tlsSettings))
+	for _, s := range tlsSettings {
+		if f := rootCmd.PersistentFlags().Lookup(s.flag); f.Changed {
+			values[s.key] = f.Value.String()
+		} else {
+			values[s.key] = profileValue(s.key)
+		}
+	}
+
+	opts := transport.Options{
+		CAFile:     values["ca_file"],
+		ClientCert: values["client_cert"],
+		ClientKey:  values["client_key"],
+		Proxy:      values["proxy"],
+	}
+	if v := values["insecure_skip_verify"]; v != "" {
+		insecure, err := strconv.ParseBool(v)
+		if err != nil {
+			return opts, fmt.Errorf("invalid insecure_skip_verify %q: %w", v, err)
+		}
+		opts.InsecureSkipVerify = insecure
+	}
+	return opts, nil
+}
+
+// saveTLSSettings stores the TLS and proxy flags given on the command line in
+// the named profile, so later commands use them too. cfg is not saved.
+func saveTLSSettings(cfg *config.File, name string) {
+	for _, s := range tlsSettings {
+		if f := rootCmd.PersistentFlags().Lookup(s.flag); f.Changed {
+			cfg.SetProfileValue(name, s.key, f.Value.String())
+		}
+	}
+}
+
+// debugTransport wraps next with the request trace selected by --debug,
+// --debug-file or --verbose.
+func debugTransport(next http.RoundTripper) (http.RoundTripper, error) {
+	switch {
+	case debugFile != "":
+		if debugLog == nil || debugLog.Name() != debugFile {
+			if debugLog != nil {
+				_ = debugLog.Close()
+			}
+			f, err := os.OpenFile(debugFile, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o600)
+			if err != nil {
+				return nil, fmt.Errorf("opening debug file: %w", err)
+			}
+			debugLog = f
+		}
+		return &transport.Debug{Next: next, Out: debugLog}, nil
+	case debugHTTP:
+		return &transport.Debug{Next: next, Out: os.Stderr}, nil
+	case verbose:
+		return &transport.Debug{Next: next, Out: os.Stderr, Summary: true}, nil
+	default:
+		return next, nil
+	}
+}
+
+// durationSetting reads a duration config key such as "30s".
+func durationSetting(key string, def time.Duration) (time.Duration, error) {
+	if !viper.IsSet(key) {
+		return def, nil
+	}
+	d, err := time.ParseDuration(viper.GetString(key))
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s %q in config: %w", key, viper.GetString(key), err)
+	}
+	return d, nil
+}
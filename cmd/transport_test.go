@@ -0,0 +1,201 @@
+package cmd
+
+import (
+	"encoding/pem"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/google/jsonapi"
+	"github.com/spf13/viper"
+	terrakube "github.com/terrakube-io/terrakube-go"
+
+	"terrakube/internal/config"
+)
+
+// unavailableOnce answers the first request with 503 and then lists no
+// organizations.
+func unavailableOnce(t *testing.T) *atomic.Int32 {
+	t.Helper()
+	var calls atomic.Int32
+	ts := setupTestServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Content-Type", "application/vnd.api+json")
+		_ = jsonapi.MarshalPayload(w, []*terrakube.Organization{})
+	}))
+	t.Cleanup(ts.Close)
+
+	viper.Set("retry_base_delay", "1ms")
+	t.Cleanup(func() { viper.Set("retry_base_delay", "500ms") })
+	return &calls
+}
+
+func TestCmdRetriesTransientFailure(t *testing.T) {
+	resetGlobalFlags()
+	calls := unavailableOnce(t)
+
+	oldStderr := os.Stderr
+	r, w, _ := os.Pipe()
+	os.Stderr = w
+	_, err := executeCommand("organization", "list", "--verbose")
+	_ = w.Close()
+	os.Stderr = oldStderr
+	stderr, _ := io.ReadAll(r)
+	verbose = false
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if calls.Load() != 2 {
+		t.Errorf("expected 2 attempts, got %d", calls.Load())
+	}
+	if !strings.Contains(string(stderr), "503 Service Unavailable, retrying in") {
+		t.Errorf("expected retry to be logged under --verbose, got: %s", stderr)
+	}
+}
+
+func TestCmdMaxRetriesZero(t *testing.T) {
+	resetGlobalFlags()
+	calls := unavailableOnce(t)
+
+	if _, err := executeCommand("organization", "list", "--max-retries", "0"); err == nil {
+		t.Fatal("expected error without retries, got nil")
+	}
+	if calls.Load() != 1 {
+		t.Errorf("expected 1 attempt, got %d", calls.Load())
+	}
+}
+
+func TestCmdMaxRetriesConfig(t *testing.T) {
+	resetGlobalFlags()
+	calls := unavailableOnce(t)
+	viper.Set("max_retries", 0)
+	t.Cleanup(func() { viper.Set("max_retries", 3) })
+
+	if _, err := executeCommand("organization", "list"); err == nil {
+		t.Fatal("expected error with max_retries 0, got nil")
+	}
+	if calls.Load() != 1 {
+		t.Errorf("expected 1 attempt, got %d", calls.Load())
+	}
+}
+
+func TestCmdDebugFile(t *testing.T) {
+	resetGlobalFlags()
+	unavailableOnce(t)
+	path := filepath.Join(t.TempDir(), "debug.log")
+
+	if _, err := executeCommand("organization", "list", "--debug-file", path); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("reading debug file: %v", err)
+	}
+	trace := string(data)
+	if strings.Count(trace, "--> GET ") != 2 {
+		t.Errorf("expected both attempts to be traced, got:\n%s", trace)
+	}
+	if !strings.Contains(trace, "<-- 503 Service Unavailable GET ") {
+		t.Errorf("expected the failed attempt in the trace, got:\n%s", trace)
+	}
+	if !strings.Contains(trace, "Authorization: Bearer [REDACTED]") || strings.Contains(trace, "test-token") {
+		t.Errorf("expected the token to be redacted, got:\n%s", trace)
+	}
+}
+
+// tlsServer starts an HTTPS server listing no organizations and returns it
+// with its certificate written as a CA bundle.
+func tlsServer(t *testing.T) (*httptest.Server, string) {
+	t.Helper()
+	ts := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/vnd.api+json")
+		_ = jsonapi.MarshalPayload(w, []*terrakube.Organization{})
+	}))
+	// Rejected handshakes are expected, keep them out of the test output.
+	ts.Config.ErrorLog = log.New(io.Discard, "", 0)
+	ts.StartTLS()
+	t.Cleanup(ts.Close)
+
+	ca := filepath.Join(t.TempDir(), "ca.pem")
+	data := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ts.Certificate().Raw})
+	if err := os.WriteFile(ca, data, 0o600); err != nil {
+		t.Fatalf("writing CA file: %v", err)
+	}
+	return ts, ca
+}
+
+func TestCmdLoginCAFile(t *testing.T) {
+	resetGlobalFlags()
+	ts, ca := tlsServer(t)
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	cfgFile = path
+
+	out, err := executeCommand("login", "--api-url", ts.URL, "--pat", "my-pat", "--ca-file", ca)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(out, "Successfully logged in") {
+		t.Fatalf("expected login to succeed, got: %s", out)
+	}
+
+	cfg, err := config.Load(path)
+	if err != nil {
+		t.Fatalf("loading config: %v", err)
+	}
+	if got := cfg.Get("ca_file"); got != ca {
+		t.Errorf("expected ca_file %q to be saved, got %q", ca, got)
+	}
+}
+
+func TestCmdLoginUntrustedCertificate(t *testing.T) {
+	resetGlobalFlags()
+	ts, _ := tlsServer(t)
+	cfgFile = filepath.Join(t.TempDir(), "config.yaml")
+
+	out, err := executeCommand("login", "--api-url", ts.URL, "--pat", "my-pat", "--max-retries", "0")
+	if err == nil || strings.Contains(out, "Successfully logged in") {
+		t.Fatalf("expected login to fail, got: %s", out)
+	}
+	if !strings.Contains(err.Error(), "--ca-file") {
+		t.Errorf("expected a hint about --ca-file, got: %v", err)
+	}
+	var stdout, stderr strings.Builder
+	if code := reportError(&stdout, &stderr, err, false); code != exitCodeNetwork {
+		t.Errorf("expected exit code %d, got %d", exitCodeNetwork, code)
+	}
+}
+
+func TestCmdInsecureSkipVerifyProfile(t *testing.T) {
+	resetGlobalFlags()
+	ts, _ := tlsServer(t)
+	cfgFile = writeContextsConfig(t, map[string]string{"internal": ts.URL}, "internal")
+	viper.Set("contexts.internal.insecure_skip_verify", true)
+	t.Cleanup(func() { viper.Set("contexts.internal.insecure_skip_verify", false) })
+	insecureWarned = false
+
+	oldStderr := os.Stderr
+	r, w, _ := os.Pipe()
+	os.Stderr = w
+	_, err := executeCommand("organization", "list", "--context", "internal")
+	_ = w.Close()
+	os.Stderr = oldStderr
+	stderr, _ := io.ReadAll(r)
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(string(stderr), "WARNING: TLS certificate verification is disabled") {
+		t.Errorf("expected an insecure warning, got: %s", stderr)
+	}
+}
@@ -35,9 +35,10 @@ func init() {
 				Resolver:  workspaceResolver,
 			},
 		},
+		Identity: "key",
 		Fields: []resource.FieldDef{
 			{StructField: "Key", Flag: "key", Short: "k", Type: resource.String, Required: true, Description: "Variable key"},
-			{StructField: "Value", Flag: "value", Short: "v", Type: resource.String, Required: true, Description: "Variable value"},
+			{StructField: "Value", Flag: "value", Short: "v", Type: resource.String, Required: true, Description: "Variable value", SensitiveIf: "sensitive"},
 			{StructField: "Description", Flag: "description", Short: "d", Type: resource.String, Description: "Variable description"},
 			{StructField: "Category", Flag: "category", Short: "c", Type: resource.String, Required: true, Description: "Variable category (ENV, TERRAFORM)"},
 			{StructField: "Sensitive", Flag: "sensitive", Short: "s", Type: resource.Bool, Description: "Whether the variable is sensitive"},
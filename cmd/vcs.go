@@ -30,14 +30,14 @@ func init() {
 			{StructField: "VcsType", Flag: "vcs-type", Type: resource.String, Required: true, Description: "VCS type (GITHUB, GITLAB, BITBUCKET, AZURE_DEVOPS)"},
 			{StructField: "ConnectionType", Flag: "connection-type", Type: resource.String, Required: true, Description: "Connection type (OAUTH, SSH)"},
 			{StructField: "ClientID", Flag: "client-id", Type: resource.String, Required: true, Description: "OAuth client ID"},
-			{StructField: "ClientSecret", Flag: "client-secret", Type: resource.String, Required: true, Description: "OAuth client secret"},
-			{StructField: "PrivateKey", Flag: "private-key", Type: resource.String, Description: "SSH private key"},
+			{StructField: "ClientSecret", Flag: "client-secret", Type: resource.String, Required: true, Description: "OAuth client secret", Sensitive: true},
+			{StructField: "PrivateKey", Flag: "private-key", Type: resource.String, Description: "SSH private key", Sensitive: true},
 			{StructField: "Endpoint", Flag: "endpoint", Type: resource.String, Required: true, Description: "VCS endpoint URL"},
 			{StructField: "APIURL", Flag: "vcs-api-url", Type: resource.String, Required: true, Description: "VCS API URL"},
 			{StructField: "Status", Flag: "status", Type: resource.String, Description: "VCS connection status"},
 			{StructField: "Callback", Flag: "callback", Type: resource.String, Description: "OAuth callback URL"},
-			{StructField: "AccessToken", Flag: "access-token", Type: resource.String, Description: "Access token"},
-			{StructField: "RefreshToken", Flag: "refresh-token", Type: resource.String, Description: "Refresh token"},
+			{StructField: "AccessToken", Flag: "access-token", Type: resource.String, Description: "Access token", Sensitive: true},
+			{StructField: "RefreshToken", Flag: "refresh-token", Type: resource.String, Description: "Refresh token", Sensitive: true},
 			{StructField: "TokenExpiration", Flag: "token-expiration", Type: resource.String, Description: "Token expiration timestamp"},
 			{StructField: "RedirectURL", Flag: "redirect-url", Type: resource.String, Description: "OAuth redirect URL"},
 		},
@@ -177,7 +177,7 @@ func TestCmdVCSDeleteE2E(t *testing.T) {
 func TestCmdVCSListMissingOrg(t *testing.T) {
 	resetGlobalFlags()
 
-	// Need a valid server so newClient() doesn't os.Exit(1) on empty URL.
+	// Need a valid server so newClient() doesn't fail with "not logged in".
 	handler := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
 		w.WriteHeader(http.StatusOK)
 	})
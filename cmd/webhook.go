@@ -35,10 +35,11 @@ func init() {
 				Resolver:  workspaceResolver,
 			},
 		},
+		Identity: "path",
 		Fields: []resource.FieldDef{
 			{StructField: "Path", Flag: "path", Type: resource.String, Description: "Webhook path"},
 			{StructField: "Branch", Flag: "branch", Type: resource.String, Description: "Branch to watch"},
-			{StructField: "TemplateID", Flag: "template-id", Type: resource.String, Description: "Template ID"},
+			{StructField: "TemplateID", Flag: "template-id", Type: resource.String, Description: "Template ID", Ref: "template"},
 			{StructField: "RemoteHookID", Flag: "remote-hook-id", Type: resource.String, Description: "Remote hook ID"},
 			{StructField: "Event", Flag: "event", Type: resource.String, Description: "Event type"},
 		},
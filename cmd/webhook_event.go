@@ -15,8 +15,9 @@ func init() {
 			GetContext: getContext,
 			GetOutput:  func() string { return output },
 		},
-		Name:    "webhook-event",
-		Aliases: []string{"webhook-events"},
+		Name:       "webhook-event",
+		Aliases:    []string{"webhook-events"},
+		Collection: "events",
 		Parents: []resource.ParentScope{
 			{
 				Name:      "organization",
@@ -35,9 +36,10 @@ func init() {
 				Resolver:  workspaceResolver,
 			},
 			{
-				Name:   "webhook",
-				Flag:   "webhook",
-				IDFlag: "webhook-id",
+				Name:     "webhook",
+				Flag:     "webhook",
+				IDFlag:   "webhook-id",
+				Resolver: webhookResolver,
 			},
 		},
 		Fields: []resource.FieldDef{
@@ -46,7 +48,7 @@ func init() {
 			{StructField: "Path", Flag: "path", Type: resource.String, Description: "Webhook event path"},
 			{StructField: "PathType", Flag: "path-type", Type: resource.String, Description: "Path matching type (REGEX, EXACT, GLOB)"},
 			{StructField: "Priority", Flag: "priority", Type: resource.Int, Description: "Priority"},
-			{StructField: "TemplateID", Flag: "template-id", Type: resource.String, Description: "Template ID"},
+			{StructField: "TemplateID", Flag: "template-id", Type: resource.String, Description: "Template ID", Ref: "template"},
 			{StructField: "PRWorkflowEnabled", Flag: "pr-workflow-enabled", Type: resource.Bool, Description: "Whether PR workflow is enabled"},
 			{StructField: "PRApplyEnabled", Flag: "pr-apply-enabled", Type: resource.Bool, Description: "Whether PR apply is enabled"},
 		},
@@ -0,0 +1,142 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"terrakube/internal/jwt"
+)
+
+const whoamiLong = `
+Show the identity stored in the token of the active profile.
+
+The token is decoded locally and its signature is not verified, so the output
+only describes what the token claims. No request is sent to the server.
+
+Every command warns on stderr when the token expires within the
+token_expiry_warning window of the config file (default 72h, 0 disables the
+warning).
+`
+
+var whoamiExamples = `
+Show the identity of the current token
+  %[1]v whoami
+
+Show the identity as a table
+  %[1]v whoami --output table
+`
+
+// defaultExpiryWarning is used when token_expiry_warning is not set.
+const defaultExpiryWarning = 72 * time.Hour
+
+// identity is the output of whoami.
+type identity struct {
+	Subject   string   `json:"subject" yaml:"subject"`
+	Email     string   `json:"email,omitempty" yaml:"email,omitempty"`
+	Groups    []string `json:"groups,omitempty" yaml:"groups,omitempty"`
+	Issuer    string   `json:"issuer,omitempty" yaml:"issuer,omitempty"`
+	ExpiresAt string   `json:"expires_at,omitempty" yaml:"expires_at,omitempty"`
+	ExpiresIn string   `json:"expires_in,omitempty" yaml:"expires_in,omitempty"`
+}
+
+var expiryWarned bool
+
+var whoamiCmd = &cobra.Command{
+	Use:          "whoami",
+	Short:        "show the identity of the current token",
+	Long:         whoamiLong,
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		token, err := resolveToken()
+		if err != nil {
+			return err
+		}
+		if token == "" {
+			return notLoggedInError(activeContext(), false)
+		}
+
+		claims, err := jwt.Decode(token)
+		if err != nil {
+			return err
+		}
+
+		renderOutput(newIdentity(claims, time.Now()), output)
+		return nil
+	},
+	Example: fmt.Sprintf(whoamiExamples, rootCmd.Use),
+}
+
+func init() {
+	rootCmd.AddCommand(whoamiCmd)
+}
+
+func newIdentity(claims *jwt.Claims, now time.Time) identity {
+	id := identity{
+		Subject: claims.Subject,
+		Email:   claims.Email,
+		Groups:  claims.Groups,
+		Issuer:  claims.Issuer,
+	}
+	if exp, ok := claims.Expiry(); ok {
+		id.ExpiresAt = exp.UTC().Format(time.RFC3339)
+		if left := exp.Sub(now); left > 0 {
+			id.ExpiresIn = left.Round(time.Second).String()
+		} else {
+			id.ExpiresIn = "expired"
+		}
+	}
+	return id
+}
+
+// warnTokenExpiry prints a warning on stderr, once per run, when token is a
+// JWT expiring within the token_expiry_warning window.
+func warnTokenExpiry(token string) {
+	if expiryWarned {
+		return
+	}
+	if msg := tokenExpiryWarning(token, time.Now(), expiryWarningWindow()); msg != "" {
+		expiryWarned = true
+		fmt.Fprintln(os.Stderr, "Warning:", msg)
+	}
+}
+
+func tokenExpiryWarning(token string, now time.Time, window time.Duration) string {
+	if window <= 0 {
+		return ""
+	}
+	claims, err := jwt.Decode(token)
+	if err != nil {
+		return ""
+	}
+	exp, ok := claims.Expiry()
+	if !ok {
+		return ""
+	}
+
+	left := exp.Sub(now)
+	switch {
+	case left <= 0:
+		return fmt.Sprintf("the token expired %s ago, run \"%s login\" again", (-left).Round(time.Second), rootCmd.Use)
+	case left <= window:
+		return fmt.Sprintf("the token expires in %s", left.Round(time.Second))
+	default:
+		return ""
+	}
+}
+
+func expiryWarningWindow() time.Duration {
+	if !viper.IsSet("token_expiry_warning") {
+		return defaultExpiryWarning
+	}
+	value := viper.GetString("token_expiry_warning")
+	window, err := time.ParseDuration(value)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: invalid token_expiry_warning %q, using %s\n", value, defaultExpiryWarning)
+		return defaultExpiryWarning
+	}
+	return window
+}
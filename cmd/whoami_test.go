@@ -0,0 +1,101 @@
+package cmd
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+func testJWT(claims string) string {
+	enc := base64.RawURLEncoding
+	return enc.EncodeToString([]byte(`{"alg":"RS256"}`)) + "." + enc.EncodeToString([]byte(claims)) + ".sig"
+}
+
+func TestCmdWhoami(t *testing.T) {
+	resetGlobalFlags()
+	exp := time.Now().Add(48 * time.Hour).Unix()
+	viper.Set("token", testJWT(`{"sub":"jane","email":"jane@example.com","groups":["TERRAKUBE_ADMIN","devs"],"iss":"Terrakube","exp":`+jsonInt(exp)+`}`))
+
+	out, err := executeCommand("whoami")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var id identity
+	if err := json.Unmarshal([]byte(out), &id); err != nil {
+		t.Fatalf("invalid JSON output: %v\n%s", err, out)
+	}
+	if id.Subject != "jane" || id.Email != "jane@example.com" || id.Issuer != "Terrakube" {
+		t.Errorf("unexpected identity %+v", id)
+	}
+	if len(id.Groups) != 2 || id.Groups[0] != "TERRAKUBE_ADMIN" {
+		t.Errorf("expected groups, got %v", id.Groups)
+	}
+	if id.ExpiresAt != time.Unix(exp, 0).UTC().Format(time.RFC3339) {
+		t.Errorf("unexpected expires_at %q", id.ExpiresAt)
+	}
+	if !strings.HasPrefix(id.ExpiresIn, "47h") && !strings.HasPrefix(id.ExpiresIn, "48h") {
+		t.Errorf("unexpected expires_in %q", id.ExpiresIn)
+	}
+}
+
+func TestCmdWhoamiTSV(t *testing.T) {
+	resetGlobalFlags()
+	viper.Set("token", testJWT(`{"sub":"ci","groups":["a","b"],"iss":"Terrakube"}`))
+
+	out, err := executeCommand("whoami", "--output", "tsv")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := strings.TrimRight(out, "\n"); got != "ci\t\ta,b\tTerrakube\t\t" {
+		t.Errorf("unexpected tsv output %q", got)
+	}
+}
+
+func TestCmdWhoamiNotJWT(t *testing.T) {
+	resetGlobalFlags()
+	viper.Set("token", "opaque-token")
+
+	if _, err := executeCommand("whoami"); err == nil || !strings.Contains(err.Error(), "not a JWT") {
+		t.Errorf("expected not a JWT error, got %v", err)
+	}
+}
+
+func TestTokenExpiryWarning(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	token := func(exp time.Time) string { return testJWT(`{"sub":"x","exp":` + jsonInt(exp.Unix()) + `}`) }
+
+	tests := []struct {
+		name   string
+		token  string
+		window time.Duration
+		want   string
+	}{
+		{name: "inside window", token: token(now.Add(2 * time.Hour)), window: 72 * time.Hour, want: "expires in 2h0m0s"},
+		{name: "outside window", token: token(now.Add(100 * time.Hour)), window: 72 * time.Hour},
+		{name: "expired", token: token(now.Add(-time.Minute)), window: 72 * time.Hour, want: "expired 1m0s ago"},
+		{name: "disabled", token: token(now.Add(time.Hour)), window: 0},
+		{name: "no expiry", token: testJWT(`{"sub":"x"}`), window: 72 * time.Hour},
+		{name: "opaque", token: "pat", window: 72 * time.Hour},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tokenExpiryWarning(tt.token, now, tt.window)
+			if tt.want == "" && got != "" {
+				t.Errorf("expected no warning, got %q", got)
+			}
+			if !strings.Contains(got, tt.want) {
+				t.Errorf("expected warning containing %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func jsonInt(n int64) string {
+	b, _ := json.Marshal(n)
+	return string(b)
+}
@@ -15,8 +15,9 @@ func init() {
 			GetContext: getContext,
 			GetOutput:  func() string { return output },
 		},
-		Name:    "workspace-access",
-		Aliases: []string{"workspace-accesses"},
+		Name:       "workspace-access",
+		Aliases:    []string{"workspace-accesses"},
+		Collection: "access",
 		Parents: []resource.ParentScope{
 			{
 				Name:      "organization",
@@ -15,8 +15,9 @@ func init() {
 			GetContext: getContext,
 			GetOutput:  func() string { return output },
 		},
-		Name:    "workspace-schedule",
-		Aliases: []string{"workspace-schedules"},
+		Name:       "workspace-schedule",
+		Aliases:    []string{"workspace-schedules"},
+		Collection: "schedule",
 		Parents: []resource.ParentScope{
 			{
 				Name:      "organization",
@@ -37,7 +38,7 @@ func init() {
 		},
 		Fields: []resource.FieldDef{
 			{StructField: "Schedule", Flag: "schedule", Type: resource.String, Required: true, Description: "Cron expression"},
-			{StructField: "TemplateID", Flag: "template-id", Type: resource.String, Required: true, Description: "Template reference ID"},
+			{StructField: "TemplateID", Flag: "template-id", Type: resource.String, Required: true, Description: "Template reference ID", Ref: "template"},
 		},
 		List: func(ctx context.Context, c *terrakube.Client, pIDs []string, opts *terrakube.ListOptions) ([]*terrakube.WorkspaceSchedule, error) {
 			return c.WorkspaceSchedules.List(ctx, pIDs[1], opts)
@@ -117,7 +117,7 @@ func TestCmdWorkspaceTagDeleteE2E(t *testing.T) {
 func TestCmdWorkspaceTagListMissingOrg(t *testing.T) {
 	resetGlobalFlags()
 
-	// Need a valid server so newClient() doesn't os.Exit(1) on empty URL.
+	// Need a valid server so newClient() doesn't fail with "not logged in".
 	handler := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
 		w.WriteHeader(http.StatusOK)
 	})
@@ -222,7 +222,7 @@ func TestCmdWorkspaceTagNameResolution(t *testing.T) {
 func TestCmdWorkspaceTagAlias(t *testing.T) {
 	resetGlobalFlags()
 
-	// Need a valid server so newClient() doesn't os.Exit(1).
+	// Need a valid server so newClient() doesn't fail with "not logged in".
 	handler := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
 		w.WriteHeader(http.StatusOK)
 	})
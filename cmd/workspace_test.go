@@ -8,6 +8,7 @@ import (
 	"testing"
 
 	"github.com/google/jsonapi"
+	terrakube "github.com/terrakube-io/terrakube-go"
 
 	"terrakube/testutil"
 )
@@ -175,3 +176,120 @@ func TestCmdWorkspaceListMissingOrg(t *testing.T) {
 		t.Errorf("expected error to mention organization, got: %v", err)
 	}
 }
+
+func TestCmdWorkspaceListSortAndFields(t *testing.T) {
+	resetGlobalFlags()
+
+	var sort, fields string
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sort = r.URL.Query().Get("sort")
+		fields = r.URL.Query().Get("fields[workspace]")
+		w.Header().Set("Content-Type", "application/vnd.api+json")
+		_ = jsonapi.MarshalPayload(w, testutil.FixtureWorkspaceList())
+	})
+	ts := setupTestServer(handler)
+	defer ts.Close()
+
+	out, err := executeCommand("workspace", "list", "--organization-id", "a1b2c3d4-e5f6-7890-abcd-ef1234567890",
+		"--sort", "-name", "--fields", "name", "--output", "tsv")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if sort != "-name" || fields != "name" {
+		t.Errorf("expected sort=-name and fields[workspace]=name, got sort=%q fields=%q", sort, fields)
+	}
+	for _, line := range strings.Split(strings.TrimRight(out, "\n"), "\n") {
+		if cols := strings.Split(line, "\t"); len(cols) != 2 {
+			t.Errorf("expected ID and name columns, got %q", line)
+		}
+	}
+}
+
+func TestCmdWorkspaceListInvalidSort(t *testing.T) {
+	resetGlobalFlags()
+
+	hits := 0
+	ts := setupTestServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { hits++ }))
+	defer ts.Close()
+
+	_, err := executeCommand("workspace", "list", "--organization", "acme-corp", "--sort", "nmae")
+	if err == nil || !strings.Contains(err.Error(), `workspace has no attribute "nmae"`) {
+		t.Errorf("expected an invalid sort error, got %v", err)
+	}
+	if hits != 0 {
+		t.Errorf("expected no request to be sent, got %d", hits)
+	}
+}
+
+func TestCmdWorkspaceListWhere(t *testing.T) {
+	resetGlobalFlags()
+
+	var filter string
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		filter = r.URL.Query().Get("filter")
+		w.Header().Set("Content-Type", "application/vnd.api+json")
+		_ = jsonapi.MarshalPayload(w, testutil.FixtureWorkspaceList())
+	})
+	ts := setupTestServer(handler)
+	defer ts.Close()
+
+	_, err := executeCommand("workspace", "list", "--organization-id", "a1b2c3d4-e5f6-7890-abcd-ef1234567890",
+		"--where", "name~=prod-*", "--where", "branch=in:main,release 1", "--filter", "folder==/")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := `(folder==/);name==prod-*;branch=in=(main,"release 1")`; filter != want {
+		t.Errorf("expected filter %q, got %q", want, filter)
+	}
+}
+
+func TestCmdWorkspaceGetByName(t *testing.T) {
+	resetGlobalFlags()
+
+	var paths []string
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path+"?"+r.URL.Query().Get("filter"))
+		w.Header().Set("Content-Type", "application/vnd.api+json")
+		if strings.HasSuffix(r.URL.Path, "/workspace") {
+			_ = jsonapi.MarshalPayload(w, []*terrakube.Workspace{testutil.FixtureWorkspace()})
+			return
+		}
+		_ = jsonapi.MarshalPayload(w, testutil.FixtureWorkspace())
+	})
+	ts := setupTestServer(handler)
+	defer ts.Close()
+
+	out, err := executeCommand("workspace", "get", "production-vpc", "--organization-id", "a1b2c3d4-e5f6-7890-abcd-ef1234567890")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(out, "production-vpc") {
+		t.Errorf("expected output to contain 'production-vpc', got: %s", out)
+	}
+	want := []string{
+		"/api/v1/organization/a1b2c3d4-e5f6-7890-abcd-ef1234567890/workspace?name==production-vpc",
+		"/api/v1/organization/a1b2c3d4-e5f6-7890-abcd-ef1234567890/workspace/d4e5f6a7-b8c9-0123-defa-234567890123?",
+	}
+	if strings.Join(paths, "\n") != strings.Join(want, "\n") {
+		t.Errorf("unexpected requests:\n%s", strings.Join(paths, "\n"))
+	}
+}
+
+func TestCmdWorkspaceDeleteAmbiguousName(t *testing.T) {
+	resetGlobalFlags()
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			t.Errorf("expected no %s request", r.Method)
+		}
+		w.Header().Set("Content-Type", "application/vnd.api+json")
+		_ = jsonapi.MarshalPayload(w, testutil.FixtureWorkspaceList())
+	})
+	ts := setupTestServer(handler)
+	defer ts.Close()
+
+	_, err := executeCommand("workspace", "delete", "vpc", "--organization-id", "a1b2c3d4-e5f6-7890-abcd-ef1234567890")
+	if err == nil || !strings.Contains(err.Error(), `multiple workspaces match name "vpc"`) {
+		t.Errorf("expected an ambiguity error, got %v", err)
+	}
+}
@@ -0,0 +1,188 @@
+// Package apierror turns failed Terrakube API calls into readable errors
+// classified by kind.
+package apierror
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// Kind classifies a failure so that callers can branch on it.
+type Kind string
+
+// Error kinds.
+const (
+	Auth       Kind = "auth"
+	NotFound   Kind = "not_found"
+	Conflict   Kind = "conflict"
+	Validation Kind = "validation"
+	Network    Kind = "network"
+	Server     Kind = "server"
+	Unknown    Kind = "error"
+)
+
+// maxBody is the number of bytes of a non JSON:API error body kept in the
+// message.
+const maxBody = 200
+
+// Detail is one entry of a JSON:API errors array.
+type Detail struct {
+	Status string  `json:"status,omitempty"`
+	Code   string  `json:"code,omitempty"`
+	Title  string  `json:"title,omitempty"`
+	Detail string  `json:"detail,omitempty"`
+	Source *Source `json:"source,omitempty"`
+}
+
+// Source locates the part of the request an error detail is about.
+type Source struct {
+	Pointer   string `json:"pointer,omitempty"`
+	Parameter string `json:"parameter,omitempty"`
+}
+
+// Error is a failed API call.
+type Error struct {
+	Kind Kind
+
+	// Status is the HTTP status code, zero for network errors.
+	Status int
+	Method string
+	URL    string
+
+	// Errors are the entries of the JSON:API error document, if any.
+	Errors []Detail
+
+	// Body is the start of an error body that is not a JSON:API document.
+	Body string
+
+	// Err is the error returned by the client.
+	Err error
+}
+
+// FromResponse builds an Error from the status and body of a failed
+// response. err is the error the client returned for it.
+func FromResponse(method, url string, status int, body []byte, err error) *Error {
+	e := &Error{
+		Kind:   KindForStatus(status),
+		Status: status,
+		Method: method,
+		URL:    url,
+		Errors: ParseDocument(body),
+		Err:    err,
+	}
+	if len(e.Errors) == 0 {
+		e.Body = strings.TrimSpace(string(body))
+		if len(e.Body) > maxBody {
+			e.Body = e.Body[:maxBody] + "..."
+		}
+	}
+	return e
+}
+
+// NewNetwork wraps an error raised before the server answered.
+func NewNetwork(err error) *Error {
+	return &Error{Kind: Network, Err: err}
+}
+
+// KindForStatus classifies an HTTP status code.
+func KindForStatus(status int) Kind {
+	switch {
+	case status == http.StatusUnauthorized, status == http.StatusForbidden:
+		return Auth
+	case status == http.StatusNotFound, status == http.StatusGone:
+		return NotFound
+	case status == http.StatusConflict, status == http.StatusPreconditionFailed:
+		return Conflict
+	case status == http.StatusBadRequest, status == http.StatusUnprocessableEntity:
+		return Validation
+	case status >= 500:
+		return Server
+	default:
+		return Unknown
+	}
+}
+
+// ParseDocument returns the entries of a JSON:API error document. Plain
+// strings in the errors array, as sent by some Elide versions, become the
+// detail of an entry. Anything else yields nil.
+func ParseDocument(body []byte) []Detail {
+	var doc struct {
+		Errors []json.RawMessage `json:"errors"`
+	}
+	if json.Unmarshal(body, &doc) != nil {
+		return nil
+	}
+
+	details := make([]Detail, 0, len(doc.Errors))
+	for _, raw := range doc.Errors {
+		var d Detail
+		var s string
+		switch {
+		case json.Unmarshal(raw, &s) == nil:
+			d.Detail = s
+		case json.Unmarshal(raw, &d) == nil:
+		default:
+			continue
+		}
+		details = append(details, d)
+	}
+	return details
+}
+
+func (e *Error) Error() string {
+	if e.Kind == Network {
+		return fmt.Sprintf("network error: %v", e.Err)
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "%d %s", e.Status, http.StatusText(e.Status))
+	if e.Method != "" {
+		fmt.Fprintf(&b, " (%s %s)", e.Method, e.URL)
+	}
+
+	var msgs []string
+	for _, d := range e.Errors {
+		if msg := d.String(); msg != "" {
+			msgs = append(msgs, msg)
+		}
+	}
+	if len(msgs) == 0 && e.Body != "" {
+		msgs = append(msgs, e.Body)
+	}
+	if len(msgs) > 0 {
+		b.WriteString(": ")
+		b.WriteString(strings.Join(msgs, "; "))
+	}
+	return b.String()
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// String formats an error detail as "title: detail (at pointer)".
+func (d Detail) String() string {
+	msg := d.Detail
+	if d.Title != "" && d.Title != d.Detail {
+		if msg == "" {
+			msg = d.Title
+		} else {
+			msg = d.Title + ": " + msg
+		}
+	}
+	if msg == "" && d.Code != "" {
+		msg = d.Code
+	}
+	if d.Source != nil {
+		switch {
+		case d.Source.Pointer != "":
+			msg += " (at " + d.Source.Pointer + ")"
+		case d.Source.Parameter != "":
+			msg += " (parameter " + d.Source.Parameter + ")"
+		}
+	}
+	return msg
+}
+
@@ -0,0 +1,86 @@
+package apierror
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestKindForStatus(t *testing.T) {
+	tests := []struct {
+		status int
+		want   Kind
+	}{
+		{http.StatusUnauthorized, Auth},
+		{http.StatusForbidden, Auth},
+		{http.StatusNotFound, NotFound},
+		{http.StatusConflict, Conflict},
+		{http.StatusBadRequest, Validation},
+		{http.StatusUnprocessableEntity, Validation},
+		{http.StatusInternalServerError, Server},
+		{http.StatusTeapot, Unknown},
+	}
+	for _, tt := range tests {
+		if got := KindForStatus(tt.status); got != tt.want {
+			t.Errorf("KindForStatus(%d) = %q, want %q", tt.status, got, tt.want)
+		}
+	}
+}
+
+func TestParseDocument(t *testing.T) {
+	body := `{"errors":[
+		{"status":"422","title":"Invalid value","detail":"name is required","source":{"pointer":"/data/attributes/name"}},
+		"Unknown identifier 'abc' for organization"
+	]}`
+	details := ParseDocument([]byte(body))
+	if len(details) != 2 {
+		t.Fatalf("expected 2 details, got %d", len(details))
+	}
+	if got := details[0].String(); got != "Invalid value: name is required (at /data/attributes/name)" {
+		t.Errorf("unexpected first detail %q", got)
+	}
+	if got := details[1].String(); got != "Unknown identifier 'abc' for organization" {
+		t.Errorf("unexpected second detail %q", got)
+	}
+
+	for _, body := range []string{`<html>Bad Gateway</html>`, `{"error":"server error"}`, ``} {
+		if details := ParseDocument([]byte(body)); len(details) != 0 {
+			t.Errorf("expected no details for %q, got %v", body, details)
+		}
+	}
+}
+
+func TestFromResponse(t *testing.T) {
+	clientErr := errors.New("API error: status 404")
+	e := FromResponse(http.MethodGet, "https://terrakube.example.com/api/v1/organization/abc", 404,
+		[]byte(`{"errors":[{"detail":"Unknown identifier 'abc' for organization"}]}`), clientErr)
+
+	if e.Kind != NotFound {
+		t.Errorf("expected kind %q, got %q", NotFound, e.Kind)
+	}
+	want := "404 Not Found (GET https://terrakube.example.com/api/v1/organization/abc): Unknown identifier 'abc' for organization"
+	if e.Error() != want {
+		t.Errorf("expected message %q, got %q", want, e.Error())
+	}
+	if !errors.Is(e, clientErr) {
+		t.Error("expected the client error to be wrapped")
+	}
+}
+
+func TestFromResponse_PlainBody(t *testing.T) {
+	e := FromResponse(http.MethodGet, "https://terrakube.example.com", 502, []byte(strings.Repeat("x", 300)), nil)
+	if e.Kind != Server {
+		t.Errorf("expected kind %q, got %q", Server, e.Kind)
+	}
+	if !strings.HasSuffix(e.Error(), ": "+strings.Repeat("x", maxBody)+"...") {
+		t.Errorf("expected the truncated body in the message, got %q", e.Error())
+	}
+}
+
+func TestNewNetwork(t *testing.T) {
+	e := NewNetwork(errors.New("connection refused"))
+	if e.Kind != Network || e.Error() != "network error: connection refused" {
+		t.Errorf("unexpected network error %q (%s)", e.Error(), e.Kind)
+	}
+}
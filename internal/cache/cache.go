@@ -0,0 +1,171 @@
+// Package cache persists name to ID resolutions between runs of the CLI.
+package cache
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Key identifies a resolution: a name of a resource type within a scope, on
+// a server and profile. Scope is the IDs of the parents the name was
+// resolved under.
+type Key struct {
+	Server  string `json:"server"`
+	Profile string `json:"profile,omitempty"`
+	Type    string `json:"type"`
+	Scope   string `json:"scope,omitempty"`
+	Name    string `json:"name"`
+}
+
+// Entry is a cached resolution.
+type Entry struct {
+	Key
+	ID      string    `json:"id"`
+	Expires time.Time `json:"expires"`
+}
+
+// file is the on-disk layout of the cache.
+type file struct {
+	Entries []Entry `json:"entries"`
+}
+
+// File is a resolution cache stored as JSON at Path. Entries expire TTL after
+// they are written.
+type File struct {
+	Path string
+	TTL  time.Duration
+
+	now func() time.Time
+}
+
+// New returns a cache at path whose entries live for ttl.
+func New(path string, ttl time.Duration) *File {
+	return &File{Path: path, TTL: ttl, now: time.Now}
+}
+
+// DefaultPath returns the cache file under the user cache directory,
+// $XDG_CACHE_HOME/terrakube on Linux.
+func DefaultPath() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "terrakube", "resolutions.json"), nil
+}
+
+// Get returns the ID cached for key, unless it expired.
+func (f *File) Get(key Key) (string, bool) {
+	entries, err := f.Entries()
+	if err != nil {
+		return "", false
+	}
+	for _, e := range entries {
+		if e.Key == key {
+			return e.ID, true
+		}
+	}
+	return "", false
+}
+
+// Put caches id for key.
+func (f *File) Put(key Key, id string) error {
+	entries, err := f.Entries()
+	if err != nil {
+		return err
+	}
+	kept := entries[:0]
+	for _, e := range entries {
+		if e.Key != key {
+			kept = append(kept, e)
+		}
+	}
+	kept = append(kept, Entry{Key: key, ID: id, Expires: f.now().Add(f.TTL)})
+	return f.save(kept)
+}
+
+// Invalidate drops the entries of a resource type on a server and profile.
+func (f *File) Invalidate(server, profile, typ string) error {
+	entries, err := f.Entries()
+	if err != nil {
+		return err
+	}
+	kept := entries[:0]
+	for _, e := range entries {
+		if e.Server != server || e.Profile != profile || e.Type != typ {
+			kept = append(kept, e)
+		}
+	}
+	if len(kept) == len(entries) {
+		return nil
+	}
+	return f.save(kept)
+}
+
+// Entries returns the entries that have not expired.
+func (f *File) Entries() ([]Entry, error) {
+	data, err := os.ReadFile(f.Path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading cache: %w", err)
+	}
+	var c file
+	if err := json.Unmarshal(data, &c); err != nil {
+		// A corrupt cache is dropped rather than failing commands.
+		return nil, nil
+	}
+	now := f.now()
+	entries := c.Entries[:0]
+	for _, e := range c.Entries {
+		if now.Before(e.Expires) {
+			entries = append(entries, e)
+		}
+	}
+	return entries, nil
+}
+
+// Clear removes the cache file.
+func (f *File) Clear() error {
+	if err := os.Remove(f.Path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("clearing cache: %w", err)
+	}
+	return nil
+}
+
+// save writes entries to a temporary file renamed over Path, so concurrent
+// readers never see a partial cache.
+func (f *File) save(entries []Entry) error {
+	if entries == nil {
+		entries = []Entry{}
+	}
+	data, err := json.MarshalIndent(file{Entries: entries}, "", "  ")
+	if err != nil {
+		return err
+	}
+	dir := filepath.Dir(f.Path)
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return fmt.Errorf("writing cache: %w", err)
+	}
+	tmp, err := os.CreateTemp(dir, ".resolutions-*")
+	if err != nil {
+		return fmt.Errorf("writing cache: %w", err)
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("writing cache: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("writing cache: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), f.Path); err != nil {
+		return fmt.Errorf("writing cache: %w", err)
+	}
+	return nil
+}
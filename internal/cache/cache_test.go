@@ -0,0 +1,100 @@
+package cache
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func testFile(t *testing.T) (*File, *time.Time) {
+	t.Helper()
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	f := New(filepath.Join(t.TempDir(), "terrakube", "resolutions.json"), time.Minute)
+	f.now = func() time.Time { return now }
+	return f, &now
+}
+
+func TestFile_PutGet(t *testing.T) {
+	f, _ := testFile(t)
+	key := Key{Server: "https://a", Profile: "prod", Type: "workspace", Scope: "org-1", Name: "vpc"}
+
+	if _, ok := f.Get(key); ok {
+		t.Fatal("expected a miss on an empty cache")
+	}
+	if err := f.Put(key, "ws-1"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := f.Put(key, "ws-2"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if id, ok := f.Get(key); !ok || id != "ws-2" {
+		t.Errorf("expected ws-2, got %q, %v", id, ok)
+	}
+
+	other := key
+	other.Profile = "staging"
+	if _, ok := f.Get(other); ok {
+		t.Error("expected entries to be kept per profile")
+	}
+}
+
+func TestFile_Expiry(t *testing.T) {
+	f, now := testFile(t)
+	key := Key{Server: "https://a", Type: "organization", Name: "acme"}
+	_ = f.Put(key, "org-1")
+
+	*now = now.Add(59 * time.Second)
+	if _, ok := f.Get(key); !ok {
+		t.Fatal("expected a hit before the TTL")
+	}
+	*now = now.Add(time.Second)
+	if _, ok := f.Get(key); ok {
+		t.Error("expected the entry to expire after the TTL")
+	}
+}
+
+func TestFile_Invalidate(t *testing.T) {
+	f, _ := testFile(t)
+	ws := Key{Server: "https://a", Type: "workspace", Scope: "org-1", Name: "vpc"}
+	org := Key{Server: "https://a", Type: "organization", Name: "acme"}
+	otherServer := Key{Server: "https://b", Type: "workspace", Scope: "org-1", Name: "vpc"}
+	for _, k := range []Key{ws, org, otherServer} {
+		_ = f.Put(k, "id")
+	}
+
+	if err := f.Invalidate("https://a", "", "workspace"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, ok := f.Get(ws); ok {
+		t.Error("expected the workspace entry to be dropped")
+	}
+	if _, ok := f.Get(org); !ok {
+		t.Error("expected other types to be kept")
+	}
+	if _, ok := f.Get(otherServer); !ok {
+		t.Error("expected other servers to be kept")
+	}
+}
+
+func TestFile_ClearAndCorrupt(t *testing.T) {
+	f, _ := testFile(t)
+	if err := f.Clear(); err != nil {
+		t.Fatalf("clearing a missing cache: %v", err)
+	}
+
+	_ = f.Put(Key{Type: "organization", Name: "acme"}, "org-1")
+	if err := f.Clear(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := os.Stat(f.Path); !os.IsNotExist(err) {
+		t.Errorf("expected the cache file to be removed, got %v", err)
+	}
+
+	if err := os.WriteFile(f.Path, []byte("{not json"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if entries, err := f.Entries(); err != nil || len(entries) != 0 {
+		t.Errorf("expected a corrupt cache to read as empty, got %v, %v", entries, err)
+	}
+}
@@ -0,0 +1,235 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// File is the CLI configuration file (~/.terrakube-cli.yaml by default).
+// Keys the CLI does not manage are preserved so flag defaults stored by
+// users survive a rewrite.
+type File struct {
+	Path string
+	data map[string]any
+}
+
+// Load reads the config file at path. A missing file yields an empty config.
+func Load(path string) (*File, error) {
+	f := &File{Path: path, data: map[string]any{}}
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return f, nil
+		}
+		return nil, fmt.Errorf("reading config file: %w", err)
+	}
+	if err := yaml.Unmarshal(b, &f.data); err != nil {
+		return nil, fmt.Errorf("parsing config file %s: %w", path, err)
+	}
+	if f.data == nil {
+		f.data = map[string]any{}
+	}
+	return f, nil
+}
+
+// Save writes the config file, readable only by the current user.
+func (f *File) Save() error {
+	b, err := yaml.Marshal(f.data)
+	if err != nil {
+		return fmt.Errorf("encoding config file: %w", err)
+	}
+
+	return WriteFile(f.Path, b)
+}
+
+// WriteFile atomically replaces the file at path with data, readable only by
+// the current user. It refuses to replace anything but a regular file.
+func WriteFile(path string, data []byte) error {
+	if fi, err := os.Stat(path); err == nil && !fi.Mode().IsRegular() {
+		return fmt.Errorf("%s is not a regular file", path)
+	}
+
+	dir := filepath.Dir(path)
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(path)+"-*")
+	if err != nil {
+		return fmt.Errorf("writing %s: %w", path, err)
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+
+	if err := tmp.Chmod(0o600); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("writing %s: %w", path, err)
+	}
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("writing %s: %w", path, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("writing %s: %w", path, err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("writing %s: %w", path, err)
+	}
+	return nil
+}
+
+// Get returns a top-level string value.
+func (f *File) Get(key string) string {
+	return stringValue(f.data[key])
+}
+
+// Set stores a top-level value.
+func (f *File) Set(key, value string) {
+	f.data[key] = value
+}
+
+// Unset removes a top-level value and reports whether it was present.
+func (f *File) Unset(key string) bool {
+	_, ok := f.data[key]
+	delete(f.data, key)
+	return ok
+}
+
+// CurrentContext returns the name of the context selected by "context use".
+func (f *File) CurrentContext() string {
+	return f.Get("current-context")
+}
+
+// SetCurrentContext selects the context used when none is given explicitly.
+// An empty name clears the selection.
+func (f *File) SetCurrentContext(name string) {
+	if name == "" {
+		delete(f.data, "current-context")
+		return
+	}
+	f.data["current-context"] = name
+}
+
+// Contexts returns the names of all configured contexts, sorted.
+func (f *File) Contexts() []string {
+	contexts := f.contexts(false)
+	names := make([]string, 0, len(contexts))
+	for name := range contexts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// HasContext reports whether a context with the given name exists.
+func (f *File) HasContext(name string) bool {
+	_, ok := f.contexts(false)[name]
+	return ok
+}
+
+// ContextValue returns a value stored in the named context.
+func (f *File) ContextValue(name, key string) string {
+	ctx, _ := f.contexts(false)[name].(map[string]any)
+	return stringValue(ctx[key])
+}
+
+// SetContextValue stores a value in the named context, creating it if needed.
+func (f *File) SetContextValue(name, key, value string) {
+	contexts := f.contexts(true)
+	ctx, ok := contexts[name].(map[string]any)
+	if !ok {
+		ctx = map[string]any{}
+		contexts[name] = ctx
+	}
+	ctx[key] = value
+}
+
+// UnsetContextValue removes a value from the named context and reports
+// whether it was present.
+func (f *File) UnsetContextValue(name, key string) bool {
+	ctx, ok := f.contexts(false)[name].(map[string]any)
+	if !ok {
+		return false
+	}
+	_, ok = ctx[key]
+	delete(ctx, key)
+	return ok
+}
+
+// ProfileValue returns a value of the named context, or the top-level value
+// when name is empty.
+func (f *File) ProfileValue(name, key string) string {
+	if name == "" {
+		return f.Get(key)
+	}
+	return f.ContextValue(name, key)
+}
+
+// SetProfileValue stores a value in the named context, or at the top level
+// when name is empty.
+func (f *File) SetProfileValue(name, key, value string) {
+	if name == "" {
+		f.Set(key, value)
+		return
+	}
+	f.SetContextValue(name, key, value)
+}
+
+// UnsetProfileValue removes a value from the named context, or from the top
+// level when name is empty, and reports whether it was present.
+func (f *File) UnsetProfileValue(name, key string) bool {
+	if name == "" {
+		return f.Unset(key)
+	}
+	return f.UnsetContextValue(name, key)
+}
+
+// DeleteContext removes the named context and reports whether it existed.
+// Deleting the current context clears the selection.
+func (f *File) DeleteContext(name string) bool {
+	contexts := f.contexts(false)
+	if _, ok := contexts[name]; !ok {
+		return false
+	}
+	delete(contexts, name)
+	if len(contexts) == 0 {
+		delete(f.data, "contexts")
+	}
+	if f.CurrentContext() == name {
+		f.SetCurrentContext("")
+	}
+	return true
+}
+
+// ValidateContextName rejects names that cannot be addressed as config keys.
+func ValidateContextName(name string) error {
+	if name == "" {
+		return fmt.Errorf("context name must not be empty")
+	}
+	if strings.ContainsAny(name, "./ \t") {
+		return fmt.Errorf("invalid context name %q: must not contain dots, slashes or whitespace", name)
+	}
+	return nil
+}
+
+func (f *File) contexts(create bool) map[string]any {
+	contexts, ok := f.data["contexts"].(map[string]any)
+	if !ok && create {
+		contexts = map[string]any{}
+		f.data["contexts"] = contexts
+	}
+	return contexts
+}
+
+func stringValue(v any) string {
+	switch val := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return val
+	default:
+		return fmt.Sprintf("%v", val)
+	}
+}
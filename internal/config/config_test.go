@@ -0,0 +1,87 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoad_MissingFile(t *testing.T) {
+	cfg, err := Load(filepath.Join(t.TempDir(), "missing.yaml"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(cfg.Contexts()) != 0 {
+		t.Errorf("expected no contexts, got %v", cfg.Contexts())
+	}
+}
+
+func TestSave_PreservesUnknownKeys(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte("organization-id: abc\noutput: table\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	cfg.SetContextValue("dev", "api_url", "https://dev.example.com")
+	if err := cfg.Save(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	b, _ := os.ReadFile(path)
+	if !strings.Contains(string(b), "organization-id: abc") {
+		t.Errorf("expected unknown key to survive, got:\n%s", b)
+	}
+	fi, _ := os.Stat(path)
+	if fi.Mode().Perm() != 0o600 {
+		t.Errorf("expected mode 0600, got %v", fi.Mode().Perm())
+	}
+
+	reloaded, _ := Load(path)
+	if got := reloaded.ContextValue("dev", "api_url"); got != "https://dev.example.com" {
+		t.Errorf("expected api_url to round-trip, got %q", got)
+	}
+}
+
+func TestSave_RefusesNonRegularFile(t *testing.T) {
+	cfg, _ := Load(os.DevNull)
+	cfg.Set("token", "x")
+	if err := cfg.Save(); err == nil {
+		t.Fatal("expected error saving over a non-regular file")
+	}
+}
+
+func TestDeleteContext_ClearsCurrent(t *testing.T) {
+	cfg, _ := Load(filepath.Join(t.TempDir(), "config.yaml"))
+	cfg.SetContextValue("dev", "api_url", "a")
+	cfg.SetContextValue("prod", "api_url", "b")
+	cfg.SetCurrentContext("prod")
+
+	if !cfg.DeleteContext("prod") {
+		t.Fatal("expected prod to be deleted")
+	}
+	if cfg.CurrentContext() != "" {
+		t.Errorf("expected current context to be cleared, got %q", cfg.CurrentContext())
+	}
+	if cfg.DeleteContext("prod") {
+		t.Error("expected second delete to report missing context")
+	}
+	if names := cfg.Contexts(); len(names) != 1 || names[0] != "dev" {
+		t.Errorf("expected [dev], got %v", names)
+	}
+}
+
+func TestValidateContextName(t *testing.T) {
+	for _, name := range []string{"", "a.b", "a/b", "has space"} {
+		if err := ValidateContextName(name); err == nil {
+			t.Errorf("expected %q to be rejected", name)
+		}
+	}
+	if err := ValidateContextName("prod-eu"); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
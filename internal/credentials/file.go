@@ -0,0 +1,197 @@
+package credentials
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"golang.org/x/crypto/scrypt"
+
+	"terrakube/internal/config"
+)
+
+// scrypt cost parameters. Tests lower scryptN to keep key derivation fast.
+var (
+	scryptN = 1 << 15
+	scryptR = 8
+	scryptP = 1
+)
+
+const (
+	fileVersion = 1
+	keyLen      = 32
+	saltLen     = 16
+)
+
+// encryptedFile is the on-disk layout of the encrypted credential file.
+type encryptedFile struct {
+	Version    int    `json:"version"`
+	N          int    `json:"n"`
+	R          int    `json:"r"`
+	P          int    `json:"p"`
+	Salt       []byte `json:"salt"`
+	Nonce      []byte `json:"nonce"`
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+// FileStore keeps tokens in a file encrypted with AES-256-GCM. The key is
+// derived from a passphrase with scrypt and a random salt that is renewed on
+// every write.
+type FileStore struct {
+	Path string
+
+	// Passphrase is called at most once, when the file is first read or
+	// written.
+	Passphrase func() (string, error)
+
+	passphrase string
+}
+
+// NewFile returns an encrypted store at path.
+func NewFile(path string, passphrase func() (string, error)) *FileStore {
+	return &FileStore{Path: path, Passphrase: passphrase}
+}
+
+// Get returns the token stored for key.
+func (s *FileStore) Get(key string) (string, error) {
+	secrets, err := s.load()
+	if err != nil {
+		return "", err
+	}
+	secret, ok := secrets[key]
+	if !ok {
+		return "", ErrNotFound
+	}
+	return secret, nil
+}
+
+// Set stores the token for key.
+func (s *FileStore) Set(key, secret string) error {
+	secrets, err := s.load()
+	if err != nil {
+		return err
+	}
+	secrets[key] = secret
+	return s.save(secrets)
+}
+
+// Delete removes the token stored for key.
+func (s *FileStore) Delete(key string) error {
+	secrets, err := s.load()
+	if err != nil {
+		return err
+	}
+	if _, ok := secrets[key]; !ok {
+		return ErrNotFound
+	}
+	delete(secrets, key)
+	return s.save(secrets)
+}
+
+func (s *FileStore) getPassphrase() (string, error) {
+	if s.passphrase != "" {
+		return s.passphrase, nil
+	}
+	if s.Passphrase == nil {
+		return "", errors.New("no passphrase configured for the credential file")
+	}
+	p, err := s.Passphrase()
+	if err != nil {
+		return "", err
+	}
+	if p == "" {
+		return "", errors.New("passphrase must not be empty")
+	}
+	s.passphrase = p
+	return p, nil
+}
+
+func (s *FileStore) load() (map[string]string, error) {
+	b, err := os.ReadFile(s.Path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return map[string]string{}, nil
+		}
+		return nil, fmt.Errorf("reading credential file: %w", err)
+	}
+
+	var ef encryptedFile
+	if err := json.Unmarshal(b, &ef); err != nil {
+		return nil, fmt.Errorf("parsing credential file %s: %w", s.Path, err)
+	}
+	if ef.Version != fileVersion {
+		return nil, fmt.Errorf("unsupported credential file version %d", ef.Version)
+	}
+
+	passphrase, err := s.getPassphrase()
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := newGCM(passphrase, ef.Salt, ef.N, ef.R, ef.P)
+	if err != nil {
+		return nil, err
+	}
+	plain, err := gcm.Open(nil, ef.Nonce, ef.Ciphertext, nil)
+	if err != nil {
+		return nil, fmt.Errorf("decrypting credential file %s: wrong passphrase or corrupted file", s.Path)
+	}
+
+	secrets := map[string]string{}
+	if err := json.Unmarshal(plain, &secrets); err != nil {
+		return nil, fmt.Errorf("parsing credential file %s: %w", s.Path, err)
+	}
+	return secrets, nil
+}
+
+func (s *FileStore) save(secrets map[string]string) error {
+	passphrase, err := s.getPassphrase()
+	if err != nil {
+		return err
+	}
+	plain, err := json.Marshal(secrets)
+	if err != nil {
+		return fmt.Errorf("encoding credentials: %w", err)
+	}
+
+	ef := encryptedFile{
+		Version: fileVersion,
+		N:       scryptN,
+		R:       scryptR,
+		P:       scryptP,
+		Salt:    make([]byte, saltLen),
+	}
+	if _, err := rand.Read(ef.Salt); err != nil {
+		return err
+	}
+	gcm, err := newGCM(passphrase, ef.Salt, ef.N, ef.R, ef.P)
+	if err != nil {
+		return err
+	}
+	ef.Nonce = make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(ef.Nonce); err != nil {
+		return err
+	}
+	ef.Ciphertext = gcm.Seal(nil, ef.Nonce, plain, nil)
+
+	b, err := json.MarshalIndent(ef, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encoding credential file: %w", err)
+	}
+	return config.WriteFile(s.Path, b)
+}
+
+func newGCM(passphrase string, salt []byte, n, r, p int) (cipher.AEAD, error) {
+	key, err := scrypt.Key([]byte(passphrase), salt, n, r, p, keyLen)
+	if err != nil {
+		return nil, fmt.Errorf("deriving credential key: %w", err)
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
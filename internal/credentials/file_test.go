@@ -0,0 +1,83 @@
+package credentials
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func init() {
+	scryptN = 1 << 10
+}
+
+func staticPassphrase(p string) func() (string, error) {
+	return func() (string, error) { return p, nil }
+}
+
+func TestFileStore_RoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "credentials")
+	s := NewFile(path, staticPassphrase("correct horse"))
+
+	if err := s.Set("prod", "prod-secret"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := s.Set("", "default-secret"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	b, _ := os.ReadFile(path)
+	if strings.Contains(string(b), "prod-secret") {
+		t.Fatalf("expected secret to be encrypted, got:\n%s", b)
+	}
+	fi, _ := os.Stat(path)
+	if fi.Mode().Perm() != 0o600 {
+		t.Errorf("expected mode 0600, got %v", fi.Mode().Perm())
+	}
+
+	reopened := NewFile(path, staticPassphrase("correct horse"))
+	got, err := reopened.Get("prod")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got != "prod-secret" {
+		t.Errorf("expected prod-secret, got %q", got)
+	}
+
+	if err := reopened.Delete("prod"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := reopened.Get("prod"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound after delete, got %v", err)
+	}
+	if got, _ := reopened.Get(""); got != "default-secret" {
+		t.Errorf("expected default secret to be kept, got %q", got)
+	}
+}
+
+func TestFileStore_WrongPassphrase(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "credentials")
+	if err := NewFile(path, staticPassphrase("right")).Set("dev", "secret"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	_, err := NewFile(path, staticPassphrase("wrong")).Get("dev")
+	if err == nil || !strings.Contains(err.Error(), "wrong passphrase") {
+		t.Errorf("expected wrong passphrase error, got %v", err)
+	}
+}
+
+func TestFileStore_MissingFile(t *testing.T) {
+	called := false
+	s := NewFile(filepath.Join(t.TempDir(), "credentials"), func() (string, error) {
+		called = true
+		return "x", nil
+	})
+	if _, err := s.Get("dev"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+	if called {
+		t.Error("expected no passphrase prompt when the file does not exist")
+	}
+}
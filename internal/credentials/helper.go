@@ -0,0 +1,96 @@
+package credentials
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// HelperRequest is written as JSON to the standard input of a credential
+// helper. The action ("get", "store" or "erase") is passed as the last
+// command line argument.
+type HelperRequest struct {
+	Key    string `json:"key"`
+	Server string `json:"server,omitempty"`
+	Secret string `json:"secret,omitempty"`
+}
+
+// HelperResponse is read as JSON from the standard output of a credential
+// helper answering "get". Empty output means no secret is stored.
+type HelperResponse struct {
+	Secret string `json:"secret"`
+}
+
+// HelperStore delegates storage to an external executable, in the spirit of
+// git credential helpers.
+type HelperStore struct {
+	Path   string
+	Args   []string
+	Server string
+}
+
+// NewHelper returns a store running command, which is split on whitespace
+// into the executable and its leading arguments.
+func NewHelper(command, server string) (*HelperStore, error) {
+	fields := strings.Fields(command)
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("no credential helper configured")
+	}
+	return &HelperStore{Path: fields[0], Args: fields[1:], Server: server}, nil
+}
+
+// Get asks the helper for the token stored for key.
+func (s *HelperStore) Get(key string) (string, error) {
+	out, err := s.run("get", HelperRequest{Key: key, Server: s.Server})
+	if err != nil {
+		return "", err
+	}
+	if len(bytes.TrimSpace(out)) == 0 {
+		return "", ErrNotFound
+	}
+
+	var resp HelperResponse
+	if err := json.Unmarshal(out, &resp); err != nil {
+		return "", fmt.Errorf("parsing credential helper output: %w", err)
+	}
+	if resp.Secret == "" {
+		return "", ErrNotFound
+	}
+	return resp.Secret, nil
+}
+
+// Set asks the helper to store the token for key.
+func (s *HelperStore) Set(key, secret string) error {
+	_, err := s.run("store", HelperRequest{Key: key, Server: s.Server, Secret: secret})
+	return err
+}
+
+// Delete asks the helper to erase the token stored for key.
+func (s *HelperStore) Delete(key string) error {
+	_, err := s.run("erase", HelperRequest{Key: key, Server: s.Server})
+	return err
+}
+
+func (s *HelperStore) run(action string, req HelperRequest) ([]byte, error) {
+	in, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+
+	args := append(append([]string{}, s.Args...), action)
+	cmd := exec.Command(s.Path, args...)
+	cmd.Stdin = bytes.NewReader(in)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("credential helper %s %s: %w: %s", s.Path, action, err, msg)
+		}
+		return nil, fmt.Errorf("credential helper %s %s: %w", s.Path, action, err)
+	}
+	return stdout.Bytes(), nil
+}
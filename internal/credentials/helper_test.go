@@ -0,0 +1,104 @@
+package credentials
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestHelperProcess is not a real test. It is run as a credential helper by
+// the tests below and keeps secrets in the JSON file named by
+// TERRAKUBE_TEST_HELPER_FILE.
+func TestHelperProcess(t *testing.T) {
+	path := os.Getenv("TERRAKUBE_TEST_HELPER_FILE")
+	if path == "" {
+		return
+	}
+	defer os.Exit(0)
+
+	var req HelperRequest
+	if err := json.NewDecoder(os.Stdin).Decode(&req); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	secrets := map[string]string{}
+	if b, err := os.ReadFile(path); err == nil {
+		_ = json.Unmarshal(b, &secrets)
+	}
+	id := req.Server + "|" + req.Key
+
+	switch os.Args[len(os.Args)-1] {
+	case "get":
+		if secret, ok := secrets[id]; ok {
+			_ = json.NewEncoder(os.Stdout).Encode(HelperResponse{Secret: secret})
+		}
+	case "store":
+		secrets[id] = req.Secret
+	case "erase":
+		delete(secrets, id)
+	default:
+		fmt.Fprintln(os.Stderr, "unknown action")
+		os.Exit(2)
+	}
+
+	b, _ := json.Marshal(secrets)
+	_ = os.WriteFile(path, b, 0o600)
+}
+
+func newTestHelper(t *testing.T) *HelperStore {
+	t.Helper()
+	t.Setenv("TERRAKUBE_TEST_HELPER_FILE", filepath.Join(t.TempDir(), "helper.json"))
+	return &HelperStore{
+		Path:   os.Args[0],
+		Args:   []string{"-test.run=TestHelperProcess", "--"},
+		Server: "https://terrakube.example.com",
+	}
+}
+
+func TestHelperStore_RoundTrip(t *testing.T) {
+	s := newTestHelper(t)
+
+	if _, err := s.Get("prod"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected ErrNotFound before store, got %v", err)
+	}
+	if err := s.Set("prod", "prod-secret"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	got, err := s.Get("prod")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got != "prod-secret" {
+		t.Errorf("expected prod-secret, got %q", got)
+	}
+	if err := s.Delete("prod"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := s.Get("prod"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound after erase, got %v", err)
+	}
+}
+
+func TestHelperStore_Failure(t *testing.T) {
+	s := &HelperStore{Path: filepath.Join(t.TempDir(), "missing-helper")}
+	if _, err := s.Get("prod"); err == nil {
+		t.Fatal("expected error for missing helper executable")
+	}
+}
+
+func TestNewHelper_SplitsArguments(t *testing.T) {
+	s, err := NewHelper("/usr/bin/pass-helper --store team", "https://x")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if s.Path != "/usr/bin/pass-helper" || len(s.Args) != 2 || s.Args[1] != "team" {
+		t.Errorf("unexpected helper %+v", s)
+	}
+	if _, err := NewHelper("  ", ""); err == nil {
+		t.Error("expected error for empty helper command")
+	}
+}
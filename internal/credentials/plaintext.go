@@ -0,0 +1,40 @@
+package credentials
+
+import "terrakube/internal/config"
+
+// PlaintextStore keeps secrets in clear text in the profiles of the config
+// file itself, which is always written with 0600 permissions.
+type PlaintextStore struct {
+	Config *config.File
+}
+
+// NewPlaintext returns a store that reads and writes the profiles of cfg.
+func NewPlaintext(cfg *config.File) *PlaintextStore {
+	return &PlaintextStore{Config: cfg}
+}
+
+// Get returns the secret stored for key.
+func (s *PlaintextStore) Get(key string) (string, error) {
+	name, field := SplitKey(key)
+	secret := s.Config.ProfileValue(name, field)
+	if secret == "" {
+		return "", ErrNotFound
+	}
+	return secret, nil
+}
+
+// Set stores the secret for key and saves the config file.
+func (s *PlaintextStore) Set(key, secret string) error {
+	name, field := SplitKey(key)
+	s.Config.SetProfileValue(name, field, secret)
+	return s.Config.Save()
+}
+
+// Delete removes the secret stored for key and saves the config file.
+func (s *PlaintextStore) Delete(key string) error {
+	name, field := SplitKey(key)
+	if !s.Config.UnsetProfileValue(name, field) {
+		return ErrNotFound
+	}
+	return s.Config.Save()
+}
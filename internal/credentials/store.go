@@ -0,0 +1,64 @@
+// Package credentials stores API tokens outside of, or alongside, the CLI
+// config file.
+package credentials
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Backend names accepted by the credential-store setting.
+const (
+	Plaintext = "plaintext"
+	File      = "file"
+	Helper    = "credential-helper"
+)
+
+// ErrNotFound is returned by Get when no secret is stored under a key.
+var ErrNotFound = errors.New("credential not found")
+
+// Store keeps secrets by key. Keys are built by Key from a context name,
+// empty for the top-level profile, and the profile setting they replace.
+type Store interface {
+	Get(key string) (string, error)
+	Set(key, secret string) error
+	Delete(key string) error
+}
+
+// Key returns the store key of a profile setting. The token of a context is
+// stored under the context name, other secrets under "name/field".
+func Key(name, field string) string {
+	if field == "token" {
+		return name
+	}
+	return name + "/" + field
+}
+
+// SplitKey is the inverse of Key.
+func SplitKey(key string) (name, field string) {
+	if name, field, ok := strings.Cut(key, "/"); ok {
+		return name, field
+	}
+	return key, "token"
+}
+
+// Ref returns the reference written to the config file in place of the
+// secret, e.g. "file:prod".
+func Ref(backend, key string) string {
+	if key == "" {
+		return backend
+	}
+	return backend + ":" + key
+}
+
+// ParseRef splits a reference created by Ref into backend and key.
+func ParseRef(ref string) (backend, key string, err error) {
+	backend, key, _ = strings.Cut(ref, ":")
+	switch backend {
+	case File, Helper:
+		return backend, key, nil
+	default:
+		return "", "", fmt.Errorf("invalid credential reference %q", ref)
+	}
+}
@@ -0,0 +1,58 @@
+package credentials
+
+import "testing"
+
+func TestParseRef(t *testing.T) {
+	tests := []struct {
+		ref     string
+		backend string
+		key     string
+		wantErr bool
+	}{
+		{ref: "file:prod", backend: File, key: "prod"},
+		{ref: "file", backend: File, key: ""},
+		{ref: "credential-helper:dev", backend: Helper, key: "dev"},
+		{ref: "plaintext:dev", wantErr: true},
+		{ref: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.ref, func(t *testing.T) {
+			backend, key, err := ParseRef(tt.ref)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if backend != tt.backend || key != tt.key {
+				t.Errorf("expected %q/%q, got %q/%q", tt.backend, tt.key, backend, key)
+			}
+			if Ref(backend, key) != tt.ref {
+				t.Errorf("expected Ref to round-trip to %q, got %q", tt.ref, Ref(backend, key))
+			}
+		})
+	}
+}
+
+func TestKey(t *testing.T) {
+	tests := []struct {
+		name, field, key string
+	}{
+		{name: "prod", field: "token", key: "prod"},
+		{name: "", field: "token", key: ""},
+		{name: "prod", field: "refresh_token", key: "prod/refresh_token"},
+		{name: "", field: "refresh_token", key: "/refresh_token"},
+	}
+	for _, tt := range tests {
+		if got := Key(tt.name, tt.field); got != tt.key {
+			t.Errorf("Key(%q, %q) = %q, want %q", tt.name, tt.field, got, tt.key)
+		}
+		name, field := SplitKey(tt.key)
+		if name != tt.name || field != tt.field {
+			t.Errorf("SplitKey(%q) = %q, %q", tt.key, name, field)
+		}
+	}
+}
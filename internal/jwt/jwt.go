@@ -0,0 +1,54 @@
+// Package jwt decodes the claims of Terrakube tokens without verifying their
+// signature. It is only meant for showing token details to the user.
+package jwt
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ErrNotJWT is returned when a token does not have the three dot separated
+// parts of a JWT.
+var ErrNotJWT = errors.New("token is not a JWT")
+
+// Claims are the registered and Terrakube specific claims of a token.
+type Claims struct {
+	Subject   string   `json:"sub"`
+	Email     string   `json:"email"`
+	Name      string   `json:"name"`
+	Groups    []string `json:"groups"`
+	Issuer    string   `json:"iss"`
+	ExpiresAt int64    `json:"exp"`
+	IssuedAt  int64    `json:"iat"`
+}
+
+// Decode returns the claims of token. The signature is not verified.
+func Decode(token string) (*Claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, ErrNotJWT
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+	if err != nil {
+		return nil, fmt.Errorf("%w: decoding payload: %v", ErrNotJWT, err)
+	}
+
+	var claims Claims
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return nil, fmt.Errorf("%w: parsing claims: %v", ErrNotJWT, err)
+	}
+	return &claims, nil
+}
+
+// Expiry returns the expiry time and whether the token has one.
+func (c *Claims) Expiry() (time.Time, bool) {
+	if c.ExpiresAt == 0 {
+		return time.Time{}, false
+	}
+	return time.Unix(c.ExpiresAt, 0), true
+}
@@ -0,0 +1,51 @@
+package jwt
+
+import (
+	"encoding/base64"
+	"errors"
+	"testing"
+	"time"
+)
+
+func encode(payload string) string {
+	enc := base64.RawURLEncoding
+	return enc.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`)) + "." +
+		enc.EncodeToString([]byte(payload)) + ".c2lnbmF0dXJl"
+}
+
+func TestDecode(t *testing.T) {
+	token := encode(`{"sub":"jane","email":"jane@example.com","groups":["admins","devs"],"iss":"Terrakube","exp":1893456000}`)
+
+	claims, err := Decode(token)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if claims.Subject != "jane" || claims.Email != "jane@example.com" || claims.Issuer != "Terrakube" {
+		t.Errorf("unexpected claims %+v", claims)
+	}
+	if len(claims.Groups) != 2 || claims.Groups[1] != "devs" {
+		t.Errorf("expected groups [admins devs], got %v", claims.Groups)
+	}
+	exp, ok := claims.Expiry()
+	if !ok || !exp.Equal(time.Unix(1893456000, 0)) {
+		t.Errorf("unexpected expiry %v %v", exp, ok)
+	}
+}
+
+func TestDecode_NoExpiry(t *testing.T) {
+	claims, err := Decode(encode(`{"sub":"ci"}`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, ok := claims.Expiry(); ok {
+		t.Error("expected no expiry")
+	}
+}
+
+func TestDecode_Invalid(t *testing.T) {
+	for _, token := range []string{"plain-pat", "a.b", "a.!!!.c", "a." + base64.RawURLEncoding.EncodeToString([]byte("not json")) + ".c"} {
+		if _, err := Decode(token); !errors.Is(err, ErrNotJWT) {
+			t.Errorf("Decode(%q): expected ErrNotJWT, got %v", token, err)
+		}
+	}
+}
@@ -0,0 +1,219 @@
+// Package oidc implements the parts of OpenID Connect discovery and the
+// OAuth2 device authorization grant (RFC 8628) used by "login --device".
+package oidc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// DefaultScopes are requested when no scopes are configured. offline_access
+// asks for a refresh token.
+var DefaultScopes = []string{"openid", "profile", "email", "groups", "offline_access"}
+
+const deviceGrantType = "urn:ietf:params:oauth:grant-type:device_code"
+
+// Provider holds the endpoints of an OpenID Connect issuer.
+type Provider struct {
+	Issuer                      string `json:"issuer"`
+	DeviceAuthorizationEndpoint string `json:"device_authorization_endpoint"`
+	TokenEndpoint               string `json:"token_endpoint"`
+
+	// HTTPClient is used for all requests, http.DefaultClient when nil.
+	HTTPClient *http.Client `json:"-"`
+}
+
+// DeviceCode is the response of the device authorization endpoint.
+type DeviceCode struct {
+	DeviceCode              string `json:"device_code"`
+	UserCode                string `json:"user_code"`
+	VerificationURI         string `json:"verification_uri"`
+	VerificationURIComplete string `json:"verification_uri_complete"`
+	ExpiresIn               int    `json:"expires_in"`
+	Interval                int    `json:"interval"`
+}
+
+// Token is the response of the token endpoint.
+type Token struct {
+	AccessToken  string    `json:"access_token"`
+	RefreshToken string    `json:"refresh_token"`
+	IDToken      string    `json:"id_token"`
+	TokenType    string    `json:"token_type"`
+	ExpiresIn    int       `json:"expires_in"`
+	Expiry       time.Time `json:"-"`
+}
+
+// Error is an OAuth2 error response.
+type Error struct {
+	Code        string `json:"error"`
+	Description string `json:"error_description"`
+}
+
+func (e *Error) Error() string {
+	if e.Description != "" {
+		return fmt.Sprintf("%s: %s", e.Code, e.Description)
+	}
+	return e.Code
+}
+
+// Discover reads the OpenID configuration of issuer.
+func Discover(ctx context.Context, httpClient *http.Client, issuer string) (*Provider, error) {
+	wellKnown := strings.TrimSuffix(issuer, "/") + "/.well-known/openid-configuration"
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, wellKnown, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &Provider{HTTPClient: httpClient}
+	resp, err := p.client().Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("discovering OIDC issuer: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("discovering OIDC issuer: %s returned %s", wellKnown, resp.Status)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(p); err != nil {
+		return nil, fmt.Errorf("parsing OIDC configuration: %w", err)
+	}
+	if p.DeviceAuthorizationEndpoint == "" {
+		return nil, fmt.Errorf("OIDC issuer %s does not support the device authorization grant", issuer)
+	}
+	if p.TokenEndpoint == "" {
+		return nil, fmt.Errorf("OIDC issuer %s has no token endpoint", issuer)
+	}
+	return p, nil
+}
+
+// StartDevice requests a device and user code.
+func (p *Provider) StartDevice(ctx context.Context, clientID string, scopes []string) (*DeviceCode, error) {
+	form := url.Values{"client_id": {clientID}}
+	if len(scopes) > 0 {
+		form.Set("scope", strings.Join(scopes, " "))
+	}
+
+	var dc DeviceCode
+	if err := p.post(ctx, p.DeviceAuthorizationEndpoint, form, &dc); err != nil {
+		return nil, fmt.Errorf("starting device authorization: %w", err)
+	}
+	return &dc, nil
+}
+
+// PollDevice polls the token endpoint until the user approved or denied the
+// request, or the device code expired. The first request is sent right away,
+// later ones honor the interval and slow_down responses.
+func (p *Provider) PollDevice(ctx context.Context, clientID string, dc *DeviceCode) (*Token, error) {
+	if dc.ExpiresIn > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(dc.ExpiresIn)*time.Second)
+		defer cancel()
+	}
+
+	interval := time.Duration(dc.Interval) * time.Second
+	if dc.Interval == 0 {
+		interval = 5 * time.Second
+	}
+	form := url.Values{
+		"grant_type":  {deviceGrantType},
+		"device_code": {dc.DeviceCode},
+		"client_id":   {clientID},
+	}
+
+	for {
+		tok, err := p.token(ctx, form)
+		if err == nil {
+			return tok, nil
+		}
+
+		var oauthErr *Error
+		if !errors.As(err, &oauthErr) {
+			return nil, err
+		}
+		switch oauthErr.Code {
+		case "authorization_pending":
+		case "slow_down":
+			interval += 5 * time.Second
+		default:
+			return nil, fmt.Errorf("device authorization failed: %w", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("device authorization: %w", ctx.Err())
+		case <-time.After(interval):
+		}
+	}
+}
+
+// Refresh exchanges a refresh token for a new token. The returned token
+// keeps the old refresh token when the issuer does not rotate it.
+func (p *Provider) Refresh(ctx context.Context, clientID, refreshToken string) (*Token, error) {
+	tok, err := p.token(ctx, url.Values{
+		"grant_type":    {"refresh_token"},
+		"refresh_token": {refreshToken},
+		"client_id":     {clientID},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("refreshing token: %w", err)
+	}
+	if tok.RefreshToken == "" {
+		tok.RefreshToken = refreshToken
+	}
+	return tok, nil
+}
+
+func (p *Provider) token(ctx context.Context, form url.Values) (*Token, error) {
+	var tok Token
+	if err := p.post(ctx, p.TokenEndpoint, form, &tok); err != nil {
+		return nil, err
+	}
+	if tok.AccessToken == "" {
+		return nil, errors.New("token response has no access_token")
+	}
+	if tok.ExpiresIn > 0 {
+		tok.Expiry = time.Now().Add(time.Duration(tok.ExpiresIn) * time.Second)
+	}
+	return &tok, nil
+}
+
+func (p *Provider) post(ctx context.Context, endpoint string, form url.Values, out any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := p.client().Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		oauthErr := &Error{}
+		if json.Unmarshal(body, oauthErr) == nil && oauthErr.Code != "" {
+			return oauthErr
+		}
+		return fmt.Errorf("%s returned %s", endpoint, resp.Status)
+	}
+	return json.Unmarshal(body, out)
+}
+
+func (p *Provider) client() *http.Client {
+	if p.HTTPClient != nil {
+		return p.HTTPClient
+	}
+	return http.DefaultClient
+}
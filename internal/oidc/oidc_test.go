@@ -0,0 +1,82 @@
+package oidc
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"terrakube/testutil"
+)
+
+func TestDeviceFlow(t *testing.T) {
+	iss := testutil.NewOIDCIssuer(t)
+	iss.Pending = 1
+	ctx := context.Background()
+
+	p, err := Discover(ctx, nil, iss.URL()+"/")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	dc, err := p.StartDevice(ctx, "terrakube-cli", DefaultScopes)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if dc.UserCode != "ABCD-EFGH" || !strings.HasSuffix(dc.VerificationURI, "/device") {
+		t.Errorf("unexpected device code %+v", dc)
+	}
+
+	tok, err := p.PollDevice(ctx, "terrakube-cli", dc)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if tok.AccessToken != "device-access-token" || tok.RefreshToken != "device-refresh-token" {
+		t.Errorf("unexpected token %+v", tok)
+	}
+	if iss.Polls != 2 {
+		t.Errorf("expected 2 polls, got %d", iss.Polls)
+	}
+	if time.Until(tok.Expiry) < 59*time.Minute {
+		t.Errorf("expected expiry in about an hour, got %v", tok.Expiry)
+	}
+}
+
+func TestDeviceFlowDenied(t *testing.T) {
+	iss := testutil.NewOIDCIssuer(t)
+	iss.Deny = true
+	ctx := context.Background()
+
+	p, _ := Discover(ctx, nil, iss.URL())
+	dc, _ := p.StartDevice(ctx, "terrakube-cli", nil)
+	_, err := p.PollDevice(ctx, "terrakube-cli", dc)
+
+	var oauthErr *Error
+	if !errors.As(err, &oauthErr) || oauthErr.Code != "access_denied" {
+		t.Fatalf("expected access_denied, got %v", err)
+	}
+}
+
+func TestRefreshKeepsRefreshToken(t *testing.T) {
+	iss := testutil.NewOIDCIssuer(t)
+	iss.RefreshToken = ""
+	ctx := context.Background()
+
+	p, _ := Discover(ctx, nil, iss.URL())
+	tok, err := p.Refresh(ctx, "terrakube-cli", "old-refresh")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if iss.Refreshed != "old-refresh" {
+		t.Errorf("expected refresh token to be presented, got %q", iss.Refreshed)
+	}
+	if tok.RefreshToken != "old-refresh" {
+		t.Errorf("expected refresh token to be kept, got %q", tok.RefreshToken)
+	}
+}
+
+func TestDiscoverUnreachable(t *testing.T) {
+	if _, err := Discover(context.Background(), nil, "http://127.0.0.1:1"); err == nil {
+		t.Fatal("expected error for unreachable issuer")
+	}
+}
@@ -0,0 +1,145 @@
+package output
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// fieldSet is a selection of resource fields by jsonapi attribute or
+// relation name, as given to --fields. The ID is always kept.
+type fieldSet map[string]bool
+
+func newFieldSet(fields []string) fieldSet {
+	if len(fields) == 0 {
+		return nil
+	}
+	set := make(fieldSet, len(fields))
+	for _, f := range fields {
+		set[f] = true
+	}
+	return set
+}
+
+// keepStructField reports whether a struct field is selected.
+func (s fieldSet) keepStructField(field reflect.StructField) bool {
+	if s == nil || field.Name == "ID" {
+		return true
+	}
+	parts := strings.Split(field.Tag.Get("jsonapi"), ",")
+	return len(parts) >= 2 && s[parts[1]]
+}
+
+// rows is extractRows without the columns of fields that are not selected.
+func (s fieldSet) rows(data any) ([][]string, []string) {
+	rows, headers := extractRows(data)
+	t := elemType(reflect.TypeOf(data))
+	if s == nil || t == nil || t.Kind() != reflect.Struct {
+		return rows, headers
+	}
+
+	var keep []int
+	for i, h := range headers {
+		if f, ok := t.FieldByName(h); !ok || s.keepStructField(f) {
+			keep = append(keep, i)
+		}
+	}
+	filtered := make([][]string, len(rows))
+	for r, row := range rows {
+		for _, i := range keep {
+			filtered[r] = append(filtered[r], row[i])
+		}
+	}
+	kept := make([]string, 0, len(keep))
+	for _, i := range keep {
+		kept = append(kept, headers[i])
+	}
+	return filtered, kept
+}
+
+// filterJSON drops unselected attributes and relationships from unwrapped
+// JSON:API resources, a single object or an array.
+func (s fieldSet) filterJSON(raw json.RawMessage) (json.RawMessage, error) {
+	if s == nil {
+		return raw, nil
+	}
+	var v any
+	if err := json.Unmarshal(raw, &v); err != nil {
+		return raw, err
+	}
+	switch val := v.(type) {
+	case []any:
+		for _, item := range val {
+			s.filterResource(item)
+		}
+	default:
+		s.filterResource(val)
+	}
+	return json.Marshal(v)
+}
+
+func (s fieldSet) filterResource(v any) {
+	obj, ok := v.(map[string]any)
+	if !ok {
+		return
+	}
+	for _, section := range []string{"attributes", "relationships"} {
+		members, ok := obj[section].(map[string]any)
+		if !ok {
+			continue
+		}
+		for name := range members {
+			if !s[name] {
+				delete(members, name)
+			}
+		}
+	}
+}
+
+// filterYAML drops unselected fields from the YAML encoding of data, where
+// keys are the lowercased struct field names.
+func (s fieldSet) filterYAML(data any) (any, error) {
+	t := elemType(reflect.TypeOf(data))
+	if s == nil || t == nil || t.Kind() != reflect.Struct {
+		return data, nil
+	}
+	keep := map[string]bool{}
+	for i := 0; i < t.NumField(); i++ {
+		if f := t.Field(i); f.IsExported() && s.keepStructField(f) {
+			keep[strings.ToLower(f.Name)] = true
+		}
+	}
+
+	var node yaml.Node
+	if err := node.Encode(data); err != nil {
+		return nil, err
+	}
+	mappings := []*yaml.Node{&node}
+	if node.Kind == yaml.SequenceNode {
+		mappings = node.Content
+	}
+	for _, m := range mappings {
+		if m.Kind != yaml.MappingNode {
+			continue
+		}
+		var content []*yaml.Node
+		for i := 0; i+1 < len(m.Content); i += 2 {
+			if keep[m.Content[i].Value] {
+				content = append(content, m.Content[i], m.Content[i+1])
+			}
+		}
+		m.Content = content
+	}
+	return &node, nil
+}
+
+// elemType returns the struct type of a resource, a slice of resources or
+// pointers to them.
+func elemType(t reflect.Type) reflect.Type {
+	for t != nil && (t.Kind() == reflect.Pointer || t.Kind() == reflect.Slice) {
+		t = t.Elem()
+	}
+	return t
+}
@@ -0,0 +1,57 @@
+package output
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestRenderFields_TSV(t *testing.T) {
+	var buf bytes.Buffer
+	if err := RenderFields(&buf, resourceSlice(), "tsv", []string{"name", "count"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	first := strings.SplitN(buf.String(), "\n", 2)[0]
+	if first != "abc-123\ttest-item\t42" {
+		t.Errorf("expected ID, name and count columns, got %q", first)
+	}
+}
+
+func TestRenderFields_Table(t *testing.T) {
+	var buf bytes.Buffer
+	if err := RenderFields(&buf, singleResource(), "table", []string{"name"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	out := buf.String()
+	if !strings.Contains(out, "NAME") || strings.Contains(out, "DESCRIPTION") || strings.Contains(out, "COUNT") {
+		t.Errorf("expected only the ID and NAME columns, got:\n%s", out)
+	}
+}
+
+func TestRenderFields_JSON(t *testing.T) {
+	var buf bytes.Buffer
+	if err := RenderFields(&buf, resourceSlice(), "json", []string{"name"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var items []struct {
+		ID         string         `json:"id"`
+		Attributes map[string]any `json:"attributes"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &items); err != nil {
+		t.Fatalf("unexpected JSON %q: %v", buf.String(), err)
+	}
+	if items[0].ID != "abc-123" || len(items[0].Attributes) != 1 || items[0].Attributes["name"] != "test-item" {
+		t.Errorf("expected only the name attribute, got %+v", items[0])
+	}
+}
+
+func TestRenderFields_YAML(t *testing.T) {
+	var buf bytes.Buffer
+	if err := RenderFields(&buf, singleResource(), "yaml", []string{"active"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if buf.String() != "id: abc-123\nactive: true\n" {
+		t.Errorf("expected only id and active, got:\n%s", buf.String())
+	}
+}
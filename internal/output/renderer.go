@@ -20,15 +20,25 @@ var HideNulls = true
 // Render writes data to w in the specified format.
 // Supported formats: json, yaml, table, tsv, none.
 func Render(w io.Writer, data any, format string) error {
+	return RenderFields(w, data, format, nil)
+}
+
+// RenderFields is Render showing only the given fields, by jsonapi attribute
+// or relation name, besides the ID. All fields are shown when fields is empty.
+func RenderFields(w io.Writer, data any, format string, fields []string) error {
+	return render(w, data, format, newFieldSet(fields))
+}
+
+func render(w io.Writer, data any, format string, fields fieldSet) error {
 	switch format {
 	case "json":
-		return renderJSON(w, data)
+		return renderJSON(w, data, fields)
 	case "yaml":
-		return renderYAML(w, data)
+		return renderYAML(w, data, fields)
 	case "table":
-		return renderTable(w, data)
+		return renderTable(w, data, fields)
 	case "tsv":
-		return renderTSV(w, data)
+		return renderTSV(w, data, fields)
 	case "none":
 		return nil
 	default:
@@ -36,8 +46,8 @@ func Render(w io.Writer, data any, format string) error {
 	}
 }
 
-func renderJSON(w io.Writer, data any) error {
-	b, err := marshalJSONAPI(data)
+func renderJSON(w io.Writer, data any, fields fieldSet) error {
+	b, err := marshalJSONAPI(data, fields)
 	if err != nil {
 		return fmt.Errorf("failed to marshal json: %w", err)
 	}
@@ -48,7 +58,7 @@ func renderJSON(w io.Writer, data any) error {
 // marshalJSONAPI serializes data using the JSON:API format for backwards
 // compatibility, then unwraps the "data" envelope so the output is just
 // the resource array or object (matching the original CLI output).
-func marshalJSONAPI(data any) ([]byte, error) {
+func marshalJSONAPI(data any, fields fieldSet) ([]byte, error) {
 	v := reflect.ValueOf(data)
 
 	// Handle nil/empty slices — return "null" to match old behavior.
@@ -91,6 +101,10 @@ func marshalJSONAPI(data any) ([]byte, error) {
 			return nil, err2
 		}
 	}
+	raw, err := fields.filterJSON(raw)
+	if err != nil {
+		return nil, err
+	}
 
 	return json.MarshalIndent(raw, "", "    ")
 }
@@ -152,7 +166,11 @@ func stripNullsValue(v any) any {
 	}
 }
 
-func renderYAML(w io.Writer, data any) error {
+func renderYAML(w io.Writer, data any, fields fieldSet) error {
+	data, err := fields.filterYAML(data)
+	if err != nil {
+		return fmt.Errorf("failed to marshal yaml: %w", err)
+	}
 	b, err := yaml.Marshal(data)
 	if err != nil {
 		return fmt.Errorf("failed to marshal yaml: %w", err)
@@ -161,23 +179,31 @@ func renderYAML(w io.Writer, data any) error {
 	return err
 }
 
-func renderTable(w io.Writer, data any) error {
-	rows, headers := extractRows(data)
+func renderTable(w io.Writer, data any, fields fieldSet) error {
+	rows, headers := fields.rows(data)
 	if len(rows) == 0 {
 		return nil
 	}
+	table := newTable(w, headers)
+	table.AppendBulk(rows)
+	table.SetCaption(true, tableCaption)
+	table.Render()
+	return nil
+}
+
+// tableCaption is printed under tables to separate them from what follows.
+const tableCaption = " "
+
+func newTable(w io.Writer, headers []string) *tablewriter.Table {
 	table := tablewriter.NewWriter(w)
 	table.SetHeader(headers)
-	table.AppendBulk(rows)
 	table.SetBorders(tablewriter.Border{Left: true, Top: false, Right: true, Bottom: false})
-	table.SetCaption(true, " ")
 	table.SetCenterSeparator("|")
-	table.Render()
-	return nil
+	return table
 }
 
-func renderTSV(w io.Writer, data any) error {
-	rows, _ := extractRows(data)
+func renderTSV(w io.Writer, data any, fields fieldSet) error {
+	rows, _ := fields.rows(data)
 	for _, row := range rows {
 		if _, err := fmt.Fprintln(w, strings.Join(row, "\t")); err != nil {
 			return err
@@ -187,29 +213,41 @@ func renderTSV(w io.Writer, data any) error {
 }
 
 // extractRows converts a struct or slice of structs into string rows and headers.
-// ID is always the first column. Fields tagged with jsonapi "relation,..." are skipped.
+// ID, when the struct has one, is always the first column. Fields tagged with
+// jsonapi "relation,..." are skipped.
 func extractRows(data any) ([][]string, []string) {
 	v := reflect.ValueOf(data)
-	headers := []string{"ID"}
+	var headers []string
 	var rows [][]string
 
 	if v.Kind() == reflect.Slice {
 		for i := 0; i < v.Len(); i++ {
 			elem := v.Index(i).Interface()
 			d := reflect.Indirect(reflect.ValueOf(elem))
-			row := []string{d.FieldByName("ID").String()}
+			row, h := idColumn(d)
+			if i == 0 {
+				headers = h
+			}
 			row, headers = appendFields(d, row, headers, i == 0)
 			rows = append(rows, row)
 		}
 	} else {
 		d := reflect.Indirect(v)
-		row := []string{d.FieldByName("ID").String()}
-		row, headers = appendFields(d, row, headers, true)
+		row, h := idColumn(d)
+		row, headers = appendFields(d, row, h, true)
 		rows = append(rows, row)
 	}
 	return rows, headers
 }
 
+func idColumn(d reflect.Value) ([]string, []string) {
+	id := d.FieldByName("ID")
+	if !id.IsValid() {
+		return nil, nil
+	}
+	return []string{id.String()}, []string{"ID"}
+}
+
 func appendFields(d reflect.Value, row []string, headers []string, buildHeaders bool) ([]string, []string) {
 	for j := 0; j < d.NumField(); j++ {
 		field := d.Type().Field(j)
@@ -247,6 +285,12 @@ func formatFieldValue(v reflect.Value) string {
 		}
 	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
 		return fmt.Sprintf("%d", v.Int())
+	case reflect.Slice:
+		items := make([]string, v.Len())
+		for i := range items {
+			items[i] = formatFieldValue(v.Index(i))
+		}
+		return strings.Join(items, ",")
 	default:
 		return v.String()
 	}
@@ -241,3 +241,33 @@ func TestRenderTSV_BoolFormatting(t *testing.T) {
 		t.Errorf("expected true in tsv output, got:\n%s", out)
 	}
 }
+
+func TestRenderTSV_NoIDField(t *testing.T) {
+	type entry struct {
+		Name    string
+		Current bool
+	}
+	var buf bytes.Buffer
+	err := Render(&buf, []entry{{Name: "dev", Current: true}}, "tsv")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := strings.TrimSpace(buf.String()); got != "dev\ttrue" {
+		t.Errorf("expected %q, got %q", "dev\ttrue", got)
+	}
+}
+
+func TestRenderTSV_SliceField(t *testing.T) {
+	type entry struct {
+		Name   string
+		Groups []string
+	}
+	var buf bytes.Buffer
+	err := Render(&buf, entry{Name: "jane", Groups: []string{"admins", "devs"}}, "tsv")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := strings.TrimSpace(buf.String()); got != "jane\tadmins,devs" {
+		t.Errorf("expected %q, got %q", "jane\tadmins,devs", got)
+	}
+}
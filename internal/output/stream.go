@@ -0,0 +1,88 @@
+package output
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+
+	"github.com/kataras/tablewriter"
+)
+
+// Stream renders a list that arrives page by page. Table and TSV rows are
+// written as soon as a page is added; JSON and YAML need the whole list to
+// form a single document and are written by Close.
+type Stream struct {
+	w      io.Writer
+	format string
+	fields fieldSet
+
+	// items collects the pages of buffered formats.
+	items reflect.Value
+
+	// table is set once the table header was written.
+	table *tablewriter.Table
+}
+
+// NewStream returns a Stream writing to w in format, showing only fields
+// as RenderFields does.
+func NewStream(w io.Writer, format string, fields []string) *Stream {
+	return &Stream{w: w, format: format, fields: newFieldSet(fields)}
+}
+
+// Add renders or collects one page, a slice of resources.
+func (s *Stream) Add(page any) error {
+	v := reflect.ValueOf(page)
+	if v.Kind() != reflect.Slice {
+		return fmt.Errorf("stream page must be a slice, got %T", page)
+	}
+
+	switch s.format {
+	case "table":
+		return s.renderTablePage(page)
+	case "tsv":
+		return renderTSV(s.w, page, s.fields)
+	default:
+		if !s.items.IsValid() {
+			s.items = reflect.MakeSlice(v.Type(), 0, v.Len())
+		}
+		s.items = reflect.AppendSlice(s.items, v)
+		return nil
+	}
+}
+
+// Close writes buffered formats.
+func (s *Stream) Close() error {
+	switch s.format {
+	case "table":
+		if s.table != nil {
+			_, err := fmt.Fprintln(s.w, tableCaption)
+			return err
+		}
+		return nil
+	case "tsv":
+		return nil
+	}
+	if !s.items.IsValid() {
+		return render(s.w, []any{}, s.format, s.fields)
+	}
+	return render(s.w, s.items.Interface(), s.format, s.fields)
+}
+
+// renderTablePage writes the rows of a page. The first non-empty page is
+// rendered with the header, later rows are aligned to its columns.
+func (s *Stream) renderTablePage(page any) error {
+	rows, headers := s.fields.rows(page)
+	if len(rows) == 0 {
+		return nil
+	}
+	if s.table == nil {
+		s.table = newTable(s.w, headers)
+		s.table.AppendBulk(rows)
+		s.table.Render()
+		return nil
+	}
+	for _, row := range rows {
+		s.table.RenderRowOnce(row)
+	}
+	return nil
+}
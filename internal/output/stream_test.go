@@ -0,0 +1,89 @@
+package output
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestStreamTable_SinglePageMatchesRender(t *testing.T) {
+	var want, got bytes.Buffer
+	if err := Render(&want, resourceSlice(), "table"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	s := NewStream(&got, "table", nil)
+	if err := s.Add(resourceSlice()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := s.Close(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got.String() != want.String() {
+		t.Errorf("expected streamed table to match Render:\n%s\ngot:\n%s", want.String(), got.String())
+	}
+}
+
+func TestStreamTable_HeaderOnce(t *testing.T) {
+	var buf bytes.Buffer
+	s := NewStream(&buf, "table", nil)
+	for _, page := range [][]*sampleResource{{}, resourceSlice()[:1], resourceSlice()[1:]} {
+		if err := s.Add(page); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	_ = s.Close()
+
+	out := buf.String()
+	if strings.Count(out, "NAME") != 1 {
+		t.Errorf("expected a single header, got:\n%s", out)
+	}
+	if !strings.Contains(out, "abc-123") || !strings.Contains(out, "def-456") {
+		t.Errorf("expected rows of both pages, got:\n%s", out)
+	}
+}
+
+func TestStreamTSV_WritesEachPage(t *testing.T) {
+	var buf bytes.Buffer
+	s := NewStream(&buf, "tsv", nil)
+	if err := s.Add(resourceSlice()[:1]); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.HasPrefix(buf.String(), "abc-123\t") {
+		t.Errorf("expected the first page before Close, got %q", buf.String())
+	}
+	_ = s.Add(resourceSlice()[1:])
+	_ = s.Close()
+	if lines := strings.Split(strings.TrimRight(buf.String(), "\n"), "\n"); len(lines) != 2 {
+		t.Errorf("expected 2 rows, got %q", buf.String())
+	}
+}
+
+func TestStreamJSON_SingleDocument(t *testing.T) {
+	var buf bytes.Buffer
+	s := NewStream(&buf, "json", nil)
+	_ = s.Add(resourceSlice()[:1])
+	if buf.Len() != 0 {
+		t.Errorf("expected JSON to wait for Close, got %q", buf.String())
+	}
+	_ = s.Add(resourceSlice()[1:])
+	if err := s.Close(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var items []map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &items); err != nil {
+		t.Fatalf("expected a JSON array, got %q: %v", buf.String(), err)
+	}
+	if len(items) != 2 {
+		t.Errorf("expected 2 items, got %d", len(items))
+	}
+}
+
+func TestStream_NotASlice(t *testing.T) {
+	s := NewStream(&bytes.Buffer{}, "json", nil)
+	if err := s.Add(singleResource()); err == nil {
+		t.Error("expected error for a non-slice page, got nil")
+	}
+}
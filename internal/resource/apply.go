@@ -0,0 +1,649 @@
+package resource
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+
+	terrakube "github.com/terrakube-io/terrakube-go"
+)
+
+// Action is what applying a manifest does.
+type Action string
+
+const (
+	Create    Action = "create"
+	Update    Action = "update"
+	Delete    Action = "delete"
+	Unchanged Action = "unchanged"
+)
+
+// FieldChange is a field whose value a manifest changes. Old is nil when the
+// resource is created or the field is not set on the server.
+type FieldChange struct {
+	Field     string
+	Old       any
+	New       any
+	Sensitive bool
+}
+
+// Change is the planned effect of a manifest.
+type Change struct {
+	// Name is kind/identity, such as workspace/production-vpc, or kind/ID.
+	Name string
+
+	Manifest  Manifest
+	Kind      Kind
+	Action    Action
+	ID        string
+	ParentIDs []string
+	Fields    []FieldChange
+
+	// object is the resource to send: every spec field on create, the ID and
+	// changed fields on update.
+	object any
+}
+
+// SortManifests orders manifests so that parents are applied before their
+// children: organizations first, then resources with more parent levels.
+// Deletions come last, children before their parents. Manifests of the same
+// level keep their order.
+func SortManifests(manifests []Manifest) []Manifest {
+	depth := func(m Manifest) int {
+		d := 0
+		if k, ok := LookupKind(m.Kind); ok {
+			d = kindDepth(k)
+		}
+		if m.Delete {
+			return len(kinds) - d
+		}
+		return d
+	}
+	sorted := slices.Clone(manifests)
+	slices.SortStableFunc(sorted, func(a, b Manifest) int {
+		if a.Delete != b.Delete {
+			if a.Delete {
+				return 1
+			}
+			return -1
+		}
+		return depth(a) - depth(b)
+	})
+	return sorted
+}
+
+// Plan works out what applying m would change.
+func Plan(ctx context.Context, c *terrakube.Client, m Manifest) (*Change, error) {
+	ch, err := planManifest(ctx, c, m)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", m.Source, err)
+	}
+	return ch, nil
+}
+
+// Apply creates, updates or deletes the resources of the manifests, parents first,
+// writing a line per resource to w.
+func Apply(ctx context.Context, c *terrakube.Client, manifests []Manifest, w io.Writer) error {
+	for _, m := range SortManifests(manifests) {
+		ch, err := Plan(ctx, c, m)
+		if err != nil {
+			return err
+		}
+		status, err := execute(ctx, c, ch)
+		if err != nil {
+			return fmt.Errorf("%s: %w", m.Source, err)
+		}
+		if _, err := fmt.Fprintf(w, "%s %s\n", ch.Name, status); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ApplyEach is Apply carrying on past failures. Resources whose manifests
+// hold a required SecretPlaceholder are skipped, as are those under a
+// resource that was skipped or failed. The error counts the failures.
+func ApplyEach(ctx context.Context, c *terrakube.Client, manifests []Manifest, w io.Writer) error {
+	notApplied := make(map[string]bool)
+	failures := 0
+	for _, m := range SortManifests(manifests) {
+		name, status := m.Name(), ""
+		ch, err := planManifest(ctx, c, m)
+		if err == nil {
+			name = ch.Name
+			status, err = execute(ctx, c, ch)
+		}
+
+		var notFound *NotFoundError
+		var placeholder *PlaceholderError
+		switch {
+		case err == nil:
+		case errors.As(err, &notFound) && notApplied[notFound.Kind+"/"+notFound.Value]:
+			status = fmt.Sprintf("skipped: %s/%s was not applied", notFound.Kind, notFound.Value)
+		case errors.As(err, &placeholder):
+			status = "skipped: " + placeholder.Error()
+		default:
+			status = "failed: " + err.Error()
+			failures++
+		}
+		if err != nil {
+			notApplied[name] = true
+		}
+		if _, err := fmt.Fprintf(w, "%s %s\n", name, status); err != nil {
+			return err
+		}
+	}
+	if failures > 0 {
+		return fmt.Errorf("%d of %d resources failed", failures, len(manifests))
+	}
+	return nil
+}
+
+func planManifest(ctx context.Context, c *terrakube.Client, m Manifest) (*Change, error) {
+	k, ok := LookupKind(m.Kind)
+	if !ok {
+		return nil, fmt.Errorf("unknown kind %q", m.Kind)
+	}
+	return k.Plan(ctx, c, m)
+}
+
+// execute applies a planned change and returns its status: created,
+// updated, deleted or unchanged.
+func execute(ctx context.Context, c *terrakube.Client, ch *Change) (string, error) {
+	if ch.Action == Unchanged {
+		return status(ch.Action), nil
+	}
+	_, err := ch.Kind.Execute(ctx, c, ch)
+	return status(ch.Action), err
+}
+
+// status is the past tense of an action, as printed by apply.
+func status(a Action) string {
+	switch a {
+	case Create:
+		return "created"
+	case Update:
+		return "updated"
+	case Delete:
+		return "deleted"
+	}
+	return "unchanged"
+}
+
+// Plan implements Kind.
+func (k *kind[T]) Plan(ctx context.Context, c *terrakube.Client, m Manifest) (*Change, error) {
+	cfg := k.cfg
+	spec, placeholders, err := k.specValues(m.Spec)
+	if err != nil {
+		return nil, err
+	}
+	parentIDs, err := k.resolveManifestParents(ctx, c, m.Parents)
+	if err != nil {
+		return nil, err
+	}
+	if err := k.resolveRefs(ctx, c, parentIDs, spec); err != nil {
+		return nil, err
+	}
+
+	existing, err := k.find(ctx, c, parentIDs, m, spec)
+	if err != nil {
+		return nil, err
+	}
+	if m.Delete {
+		return k.planDelete(m, spec, parentIDs, existing)
+	}
+	if existing == nil {
+		return k.planCreate(m, spec, placeholders, parentIDs)
+	}
+
+	ch := &Change{Name: k.changeName(m, spec), Manifest: m, Kind: k, ParentIDs: parentIDs}
+	ch.ID = itemID(existing)
+	obj := new(T)
+	setStructField(obj, "ID", ch.ID)
+	for _, f := range cfg.Fields {
+		v, ok := spec[f.Flag]
+		if !ok {
+			continue
+		}
+		old := fieldValue(existing, f)
+		if reflect.DeepEqual(old, v) {
+			continue
+		}
+		setFieldValue(obj, f, v)
+		fc := FieldChange{Field: f.Flag, Old: old, New: v, Sensitive: isSecret(cfg.Fields, f, existing, spec)}
+		if !fieldSet(existing, f) {
+			fc.Old = nil
+		}
+		ch.Fields = append(ch.Fields, fc)
+	}
+	ch.Action, ch.object = Unchanged, obj
+	if len(ch.Fields) > 0 {
+		if cfg.Update == nil {
+			return nil, fmt.Errorf("%s resources cannot be updated", cfg.Name)
+		}
+		ch.Action = Update
+	}
+	return ch, nil
+}
+
+// planPending plans the creation of the resource of m under a parent that
+// is itself still to be created.
+func (k *kind[T]) planPending(m Manifest) (*Change, error) {
+	spec, placeholders, err := k.specValues(m.Spec)
+	if err != nil {
+		return nil, err
+	}
+	return k.planCreate(m, spec, placeholders, nil)
+}
+
+// planCreate plans the creation of the resource of m, under parents that
+// may not exist yet.
+func (k *kind[T]) planCreate(m Manifest, spec map[string]any, placeholders map[string]bool, parentIDs []string) (*Change, error) {
+	cfg := k.cfg
+	if cfg.Create == nil {
+		return nil, fmt.Errorf("%s resources cannot be created", cfg.Name)
+	}
+	ch := &Change{Name: k.changeName(m, spec), Manifest: m, Kind: k, Action: Create, ParentIDs: parentIDs}
+	obj := new(T)
+	for _, f := range cfg.Fields {
+		v, ok := spec[f.Flag]
+		switch {
+		case !ok && f.Required && placeholders[f.Flag]:
+			return nil, &PlaceholderError{Kind: cfg.Name, Field: f.Flag}
+		case !ok && f.Required:
+			return nil, fmt.Errorf("spec.%s is required to create a %s", f.Flag, cfg.Name)
+		case !ok:
+			continue
+		}
+		setFieldValue(obj, f, v)
+		ch.Fields = append(ch.Fields, FieldChange{Field: f.Flag, New: v, Sensitive: isSecret(cfg.Fields, f, nil, spec)})
+	}
+	ch.object = obj
+	return ch, nil
+}
+
+// planDelete plans the deletion of the existing resource of m, if any.
+func (k *kind[T]) planDelete(m Manifest, spec map[string]any, parentIDs []string, existing *T) (*Change, error) {
+	ch := &Change{Name: k.changeName(m, spec), Manifest: m, Kind: k, Action: Unchanged, ParentIDs: parentIDs}
+	if existing == nil {
+		return ch, nil
+	}
+	if k.cfg.Delete == nil {
+		return nil, fmt.Errorf("%s resources cannot be deleted", k.cfg.Name)
+	}
+	ch.Action, ch.ID = Delete, itemID(existing)
+	return ch, nil
+}
+
+func (k *kind[T]) changeName(m Manifest, spec map[string]any) string {
+	if m.ID != "" {
+		return k.cfg.Name + "/" + m.ID
+	}
+	flag, _ := k.cfg.identity()
+	if flag == "" {
+		return k.cfg.Name
+	}
+	return fmt.Sprintf("%s/%v", k.cfg.Name, spec[flag])
+}
+
+// Execute implements Kind.
+func (k *kind[T]) Execute(ctx context.Context, c *terrakube.Client, ch *Change) (string, error) {
+	if ch.Action == Delete {
+		if err := k.cfg.Delete(ctx, c, ch.ParentIDs, ch.ID); err != nil {
+			return "", err
+		}
+		invalidateCache(k.cfg.Name)
+		return ch.ID, nil
+	}
+	obj, ok := ch.object.(*T)
+	if !ok {
+		return "", fmt.Errorf("%s: nothing to apply", ch.Name)
+	}
+	var result *T
+	var err error
+	switch ch.Action {
+	case Create:
+		result, err = k.cfg.Create(ctx, c, ch.ParentIDs, obj)
+	case Update:
+		result, err = k.cfg.Update(ctx, c, ch.ParentIDs, obj)
+	default:
+		return ch.ID, nil
+	}
+	if err != nil {
+		return "", err
+	}
+	invalidateCache(k.cfg.Name)
+	if id := itemID(result); id != "" {
+		ch.ID = id
+	}
+	return ch.ID, nil
+}
+
+// resolveManifestParents resolves the parents of a manifest, given by their
+// flag names or aliases.
+func (k *kind[T]) resolveManifestParents(ctx context.Context, c *terrakube.Client, values map[string]string) ([]string, error) {
+	ids, _, err := k.resolveBatchParents(ctx, c, values, nil)
+	return ids, err
+}
+
+// resolveBatchParents resolves the parents of a manifest like
+// resolveManifestParents, except for those created in the same batch, found
+// in lids by kind/name. Those are returned by lid, with an empty ID.
+func (k *kind[T]) resolveBatchParents(ctx context.Context, c *terrakube.Client, values, lids map[string]string) (ids, parentLIDs []string, err error) {
+	byName := make(map[string]string, len(values))
+	for key, val := range values {
+		flag := ""
+		for _, p := range k.cfg.Parents {
+			if key == p.Flag || key == p.IDFlag || slices.Contains(p.Aliases, key) {
+				flag = p.Flag
+			}
+		}
+		if flag == "" {
+			return nil, nil, fmt.Errorf("unknown parent %q for %s, expected one of %s", key, k.cfg.Name, parentFlags(k.cfg.Parents))
+		}
+		byName[flag] = val
+	}
+
+	ids = make([]string, 0, len(k.cfg.Parents))
+	parentLIDs = make([]string, len(k.cfg.Parents))
+	pending := ""
+	for i, p := range k.cfg.Parents {
+		val := byName[p.Flag]
+		if lid := lids[p.Flag+"/"+val]; val != "" && lid != "" {
+			ids = append(ids, "")
+			parentLIDs[i] = lid
+			if p.Relation == "" {
+				pending = p.Flag
+			}
+			continue
+		}
+		if pending != "" && val != "" && p.Relation == "" {
+			return nil, nil, fmt.Errorf("parents.%s: %s %q is not created in the batch, but parents.%s is", p.Flag, p.Flag, val, pending)
+		}
+		id, err := resolveScope(ctx, c, p, val, ids, "parents."+p.Flag)
+		if err != nil {
+			return nil, nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, parentLIDs, nil
+}
+
+func parentFlags(parents []ParentScope) string {
+	if len(parents) == 0 {
+		return "none"
+	}
+	flags := make([]string, len(parents))
+	for i, p := range parents {
+		flags[i] = p.Flag
+	}
+	return strings.Join(flags, ", ")
+}
+
+// find returns the existing resource a manifest describes, by ID or by its
+// identity field, or nil when there is none.
+func (k *kind[T]) find(ctx context.Context, c *terrakube.Client, parentIDs []string, m Manifest, spec map[string]any) (*T, error) {
+	cfg := k.cfg
+	if m.ID != "" {
+		if cfg.Get == nil {
+			return nil, fmt.Errorf("%s resources cannot be read by ID", cfg.Name)
+		}
+		return cfg.Get(ctx, c, parentIDs, m.ID)
+	}
+
+	flag, attr := cfg.identity()
+	if cfg.List == nil {
+		return nil, fmt.Errorf("%s resources cannot be listed, set id in the manifest", cfg.Name)
+	}
+	if flag == "" {
+		return k.findBySpec(ctx, c, parentIDs, spec)
+	}
+	value, ok := spec[flag]
+	if !ok {
+		return nil, fmt.Errorf("spec.%s is required to identify the %s", flag, cfg.Name)
+	}
+
+	items, err := cfg.List(ctx, c, parentIDs, &terrakube.ListOptions{Filter: attr + "==" + quoteRSQL(fmt.Sprint(value))})
+	if err != nil {
+		return nil, err
+	}
+	switch len(items) {
+	case 0:
+		return nil, nil
+	case 1:
+		return items[0], nil
+	default:
+		return nil, fmt.Errorf("multiple %ss match %s %q, set id in the manifest", cfg.Name, flag, fmt.Sprint(value))
+	}
+}
+
+// findBySpec returns the first resource whose fields all match spec, for
+// kinds without an identity field such as schedules, or nil when there is
+// none.
+func (k *kind[T]) findBySpec(ctx context.Context, c *terrakube.Client, parentIDs []string, spec map[string]any) (*T, error) {
+	var found *T
+	err := listPages(ctx, k.cfg, c, parentIDs, nil, paging{all: true}, func(items []*T) error {
+		for _, item := range items {
+			if found == nil && k.matches(item, spec) {
+				found = item
+			}
+		}
+		return nil
+	})
+	return found, err
+}
+
+func (k *kind[T]) matches(item *T, spec map[string]any) bool {
+	for _, f := range k.cfg.Fields {
+		if v, ok := spec[f.Flag]; ok && !reflect.DeepEqual(fieldValue(item, f), v) {
+			return false
+		}
+	}
+	return true
+}
+
+// specValues checks a manifest spec against the fields of the kind and
+// converts its values to the field types. Fields that may be sensitive set
+// to SecretPlaceholder are left out and returned as placeholders.
+func (k *kind[T]) specValues(spec map[string]any) (map[string]any, map[string]bool, error) {
+	values := make(map[string]any, len(spec))
+	placeholders := make(map[string]bool)
+	for key, raw := range spec {
+		i := slices.IndexFunc(k.cfg.Fields, func(f FieldDef) bool { return f.Flag == key })
+		if i < 0 {
+			return nil, nil, fmt.Errorf("unknown field spec.%s for %s", key, k.cfg.Name)
+		}
+		if f := k.cfg.Fields[i]; (f.Sensitive || f.SensitiveIf != "") && raw == SecretPlaceholder {
+			placeholders[key] = true
+			continue
+		}
+		v, err := convertSpecValue(k.cfg.Fields[i], raw)
+		if err != nil {
+			return nil, nil, fmt.Errorf("spec.%s: %w", key, err)
+		}
+		values[key] = v
+	}
+	return values, placeholders, nil
+}
+
+// resolveRefs replaces the names in Ref fields of spec with IDs. The
+// referenced resource is looked up under the leading parents it shares
+// with k, typically the organization.
+func (k *kind[T]) resolveRefs(ctx context.Context, c *terrakube.Client, parentIDs []string, spec map[string]any) error {
+	for _, f := range k.cfg.Fields {
+		v, ok := spec[f.Flag].(string)
+		if f.Ref == "" || !ok || v == "" || IsUUID(v) {
+			continue
+		}
+		ref, ok := kinds[f.Ref]
+		if !ok || len(ref.Parents()) > len(parentIDs) || slices.Contains(parentIDs[:len(ref.Parents())], "") {
+			return fmt.Errorf("spec.%s: cannot resolve %s %q by name", f.Flag, f.Ref, v)
+		}
+		id, err := ref.resolveName(ctx, c, parentIDs[:len(ref.Parents())], v)
+		if err != nil {
+			return fmt.Errorf("spec.%s: %w", f.Flag, err)
+		}
+		spec[f.Flag] = id
+	}
+	return nil
+}
+
+func (k *kind[T]) resolveName(ctx context.Context, c *terrakube.Client, parentIDs []string, name string) (string, error) {
+	resolver := k.cfg.selfResolver()
+	if resolver == nil {
+		return "", fmt.Errorf("%s resources cannot be referenced by name", k.cfg.Name)
+	}
+	return resolver(ctx, c, parentIDs, name)
+}
+
+// convertSpecValue converts a decoded YAML or JSON value to the Go type of a
+// field: string, bool, int or []string.
+func convertSpecValue(f FieldDef, raw any) (any, error) {
+	switch f.Type {
+	case Bool:
+		switch v := raw.(type) {
+		case bool:
+			return v, nil
+		case string:
+			b, err := strconv.ParseBool(v)
+			if err != nil {
+				return nil, fmt.Errorf("expected a boolean, got %q", v)
+			}
+			return b, nil
+		}
+		return nil, fmt.Errorf("expected a boolean, got %v", raw)
+	case Int:
+		switch v := raw.(type) {
+		case int:
+			return v, nil
+		case string:
+			n, err := strconv.Atoi(v)
+			if err != nil {
+				return nil, fmt.Errorf("expected an integer, got %q", v)
+			}
+			return n, nil
+		}
+		return nil, fmt.Errorf("expected an integer, got %v", raw)
+	case StringSlice:
+		switch v := raw.(type) {
+		case []any:
+			items := make([]string, len(v))
+			for i, item := range v {
+				items[i] = fmt.Sprint(item)
+			}
+			return items, nil
+		case string:
+			return strings.Split(v, ","), nil
+		}
+		return nil, fmt.Errorf("expected a list, got %v", raw)
+	default:
+		switch raw.(type) {
+		case map[string]any, []any, nil:
+			return nil, fmt.Errorf("expected a string, got %v", raw)
+		}
+		return fmt.Sprint(raw), nil
+	}
+}
+
+// setFieldValue sets a field of obj to a value converted by
+// convertSpecValue.
+func setFieldValue(obj any, f FieldDef, v any) {
+	field := reflect.ValueOf(obj).Elem().FieldByName(f.StructField)
+	if !field.IsValid() {
+		return
+	}
+	switch f.Type {
+	case String:
+		setStringField(field, v.(string))
+	case Bool:
+		setBoolField(field, v.(bool))
+	case Int:
+		setIntField(field, v.(int))
+	case StringSlice:
+		setStringSliceField(field, v.([]string))
+	}
+}
+
+// isSecret reports whether the value of f is sensitive: always for
+// Sensitive fields, and for fields with SensitiveIf when that field is true
+// in obj or in spec, either of which may be nil.
+func isSecret(fields []FieldDef, f FieldDef, obj any, spec map[string]any) bool {
+	if f.Sensitive {
+		return true
+	}
+	if f.SensitiveIf == "" {
+		return false
+	}
+	if b, _ := spec[f.SensitiveIf].(bool); b {
+		return true
+	}
+	i := slices.IndexFunc(fields, func(g FieldDef) bool { return g.Flag == f.SensitiveIf })
+	if i < 0 || obj == nil || reflect.ValueOf(obj).IsNil() {
+		return false
+	}
+	b, _ := fieldValue(obj, fields[i]).(bool)
+	return b
+}
+
+// fieldValue reads a field of obj as the type convertSpecValue returns. Nil
+// pointers read as the zero value, and relations as the ID they point to.
+func fieldValue(obj any, f FieldDef) any {
+	v := reflect.Indirect(reflect.ValueOf(obj))
+	field := v.FieldByName(f.StructField)
+	if !field.IsValid() {
+		return nil
+	}
+	if sf, _ := v.Type().FieldByName(f.StructField); relationName(sf) != "" {
+		return itemID(field.Interface())
+	}
+	if field.Kind() == reflect.Pointer {
+		if field.IsNil() {
+			field = reflect.Zero(field.Type().Elem())
+		} else {
+			field = field.Elem()
+		}
+	}
+	switch f.Type {
+	case Bool:
+		return field.Bool()
+	case Int:
+		return int(field.Int())
+	case StringSlice:
+		items := make([]string, field.Len())
+		for i := range items {
+			items[i] = field.Index(i).String()
+		}
+		return items
+	default:
+		return field.String()
+	}
+}
+
+// fieldSet reports whether a field of obj holds a value: nil pointers,
+// relations and slices are not set.
+func fieldSet(obj any, f FieldDef) bool {
+	field := reflect.Indirect(reflect.ValueOf(obj)).FieldByName(f.StructField)
+	switch field.Kind() {
+	case reflect.Invalid:
+		return false
+	case reflect.Pointer, reflect.Slice:
+		return !field.IsNil()
+	}
+	return true
+}
+
+// relationName returns the JSON:API relationship a struct field holds, or
+// "" when it is not a relation.
+func relationName(sf reflect.StructField) string {
+	name, ok := strings.CutPrefix(sf.Tag.Get("jsonapi"), "relation,")
+	if !ok {
+		return ""
+	}
+	name, _, _ = strings.Cut(name, ",")
+	return name
+}
@@ -0,0 +1,308 @@
+package resource
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	terrakube "github.com/terrakube-io/terrakube-go"
+	"github.com/spf13/cobra"
+)
+
+// fakeWidgets registers the widget kind backed by an in-memory list.
+func fakeWidgets(t *testing.T, existing ...*testResource) *[]*testResource {
+	t.Helper()
+	store := &existing
+	cfg := testConfig()
+	cfg.List = func(_ context.Context, _ *terrakube.Client, _ []string, opts *terrakube.ListOptions) ([]*testResource, error) {
+		var out []*testResource
+		for _, w := range *store {
+			if opts.Filter == "name=="+quoteRSQL(w.Name) {
+				out = append(out, w)
+			}
+		}
+		return out, nil
+	}
+	cfg.Create = func(_ context.Context, _ *terrakube.Client, _ []string, w *testResource) (*testResource, error) {
+		w.ID = "new-id"
+		*store = append(*store, w)
+		return w, nil
+	}
+	cfg.Update = func(_ context.Context, _ *terrakube.Client, _ []string, w *testResource) (*testResource, error) {
+		for _, e := range *store {
+			if e.ID == w.ID {
+				if w.Desc != nil {
+					e.Desc = w.Desc
+				}
+				e.Flag = w.Flag
+			}
+		}
+		return w, nil
+	}
+	Register(&cobra.Command{Use: "test"}, cfg)
+	return store
+}
+
+func widgetManifest(spec map[string]any) Manifest {
+	return Manifest{Kind: "widget", Parents: map[string]string{"organization": orgID}, Spec: spec, Source: "widget.yaml"}
+}
+
+func TestPlan_Actions(t *testing.T) {
+	desc := "old"
+	fakeWidgets(t, &testResource{ID: "w-1", Name: "existing", Desc: &desc, Flag: true})
+
+	tests := []struct {
+		name   string
+		spec   map[string]any
+		action Action
+		fields []string
+	}{
+		{"create", map[string]any{"name": "fresh", "flag": true}, Create, []string{"name", "flag"}},
+		{"unchanged", map[string]any{"name": "existing", "description": "old", "flag": true}, Unchanged, nil},
+		{"update", map[string]any{"name": "existing", "description": "new"}, Update, []string{"description"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ch, err := Plan(context.Background(), nil, widgetManifest(tt.spec))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if ch.Action != tt.action {
+				t.Errorf("expected %s, got %s", tt.action, ch.Action)
+			}
+			var fields []string
+			for _, f := range ch.Fields {
+				fields = append(fields, f.Field)
+			}
+			if strings.Join(fields, ",") != strings.Join(tt.fields, ",") {
+				t.Errorf("expected fields %v, got %v", tt.fields, fields)
+			}
+		})
+	}
+}
+
+func TestPlan_Errors(t *testing.T) {
+	fakeWidgets(t)
+
+	tests := []struct {
+		name string
+		m    Manifest
+		want string
+	}{
+		{"unknown kind", Manifest{Kind: "gizmo", Source: "g.yaml"}, `g.yaml: unknown kind "gizmo"`},
+		{"unknown field", widgetManifest(map[string]any{"name": "a", "colour": "red"}), "unknown field spec.colour for widget"},
+		{"wrong type", widgetManifest(map[string]any{"name": "a", "flag": "maybe"}), `spec.flag: expected a boolean, got "maybe"`},
+		{"missing identity", widgetManifest(map[string]any{"flag": true}), "spec.name is required to identify the widget"},
+		{"missing parent", Manifest{Kind: "widget", Spec: map[string]any{"name": "a"}, Source: "w.yaml"}, "parents.organization is required"},
+		{"unknown parent", Manifest{Kind: "widget", Parents: map[string]string{"team": "x"}, Source: "w.yaml"}, `unknown parent "team" for widget, expected one of organization`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Plan(context.Background(), nil, tt.m)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("expected error containing %q, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestApply(t *testing.T) {
+	desc := "old"
+	store := fakeWidgets(t, &testResource{ID: "w-1", Name: "existing", Desc: &desc})
+
+	var out bytes.Buffer
+	err := Apply(context.Background(), nil, []Manifest{
+		widgetManifest(map[string]any{"name": "existing", "description": "new"}),
+		widgetManifest(map[string]any{"name": "fresh"}),
+		widgetManifest(map[string]any{"name": "existing", "description": "new"}),
+	}, &out)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := "widget/existing updated\nwidget/fresh created\nwidget/existing unchanged\n"
+	if out.String() != want {
+		t.Errorf("expected output:\n%s\ngot:\n%s", want, out.String())
+	}
+	if len(*store) != 2 || *(*store)[0].Desc != "new" {
+		t.Errorf("unexpected store: %+v", *store)
+	}
+}
+
+func TestSortManifests(t *testing.T) {
+	fakeWidgets(t)
+	Register(&cobra.Command{Use: "test"}, Config[testResource]{Name: "root-widget"})
+
+	sorted := SortManifests([]Manifest{{Kind: "widget", ID: "1"}, {Kind: "root-widget"}, {Kind: "widget", ID: "2"}})
+	var order []string
+	for _, m := range sorted {
+		order = append(order, m.Kind+m.ID)
+	}
+	if got := strings.Join(order, ","); got != "root-widget,widget1,widget2" {
+		t.Errorf("expected parents first in a stable order, got %s", got)
+	}
+}
+
+// registerGizmos registers a gizmo kind with a required secret and a
+// reference to a widget, holding the given gizmos.
+func registerGizmos(existing ...*testResource) {
+	Register(&cobra.Command{Use: "test"}, Config[testResource]{
+		Name:    "gizmo",
+		Parents: testConfig().Parents,
+		Fields: []FieldDef{
+			{StructField: "Name", Flag: "name", Type: String, Required: true},
+			{StructField: "Desc", Flag: "widget-id", Type: String, Ref: "widget"},
+			{StructField: "Flag", Flag: "flag", Type: Bool},
+		},
+		List: func(context.Context, *terrakube.Client, []string, *terrakube.ListOptions) ([]*testResource, error) {
+			return existing, nil
+		},
+		Create: func(_ context.Context, _ *terrakube.Client, _ []string, g *testResource) (*testResource, error) { return g, nil },
+		Update: func(_ context.Context, _ *terrakube.Client, _ []string, g *testResource) (*testResource, error) { return g, nil },
+	})
+}
+
+func TestPlan_Ref(t *testing.T) {
+	fakeWidgets(t, &testResource{ID: "f0e1d2c3-b4a5-9687-7869-5a4b3c2d1e0f", Name: "target"})
+	registerGizmos()
+
+	m := Manifest{Kind: "gizmo", Parents: map[string]string{"organization": orgID}, Spec: map[string]any{"name": "g", "widget-id": "target"}}
+	ch, err := Plan(context.Background(), nil, m)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := ch.Fields[1].New; got != "f0e1d2c3-b4a5-9687-7869-5a4b3c2d1e0f" {
+		t.Errorf("expected widget-id to be resolved to the widget ID, got %v", got)
+	}
+
+	m.Spec["widget-id"] = "missing"
+	_, err = Plan(context.Background(), nil, m)
+	if err == nil || !strings.Contains(err.Error(), `spec.widget-id: no widget found with name "missing"`) {
+		t.Errorf("expected a not found error, got %v", err)
+	}
+}
+
+// registerVaults registers a vault kind with a required secret, holding a
+// vault named "kept".
+func registerVaults() {
+	desc := "s3cret"
+	Register(&cobra.Command{Use: "test"}, Config[testResource]{
+		Name:    "vault",
+		Parents: testConfig().Parents,
+		Fields: []FieldDef{
+			{StructField: "Name", Flag: "name", Type: String, Required: true},
+			{StructField: "Desc", Flag: "secret", Type: String, Required: true, Sensitive: true},
+		},
+		List: func(_ context.Context, _ *terrakube.Client, _ []string, opts *terrakube.ListOptions) ([]*testResource, error) {
+			if opts.Filter == "name==kept" {
+				return []*testResource{{ID: "v-1", Name: "kept", Desc: &desc}}, nil
+			}
+			return nil, nil
+		},
+		Create: func(_ context.Context, _ *terrakube.Client, _ []string, v *testResource) (*testResource, error) { return v, nil },
+	})
+}
+
+func TestPlan_SecretPlaceholder(t *testing.T) {
+	fakeWidgets(t)
+	registerVaults()
+
+	m := Manifest{Kind: "vault", Parents: map[string]string{"organization": orgID}, Spec: map[string]any{"name": "kept", "secret": SecretPlaceholder}}
+	ch, err := Plan(context.Background(), nil, m)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if ch.Action != Unchanged {
+		t.Errorf("expected the placeholder to leave the secret unchanged, got %s", ch.Action)
+	}
+
+	m.Spec["name"] = "fresh"
+	_, err = Plan(context.Background(), nil, m)
+	if err == nil || !strings.Contains(err.Error(), "spec.secret is a secret placeholder, set the value to create the vault") {
+		t.Errorf("expected a placeholder error, got %v", err)
+	}
+}
+
+// testSchedule is a resource without a name.
+type testSchedule struct {
+	ID      string `jsonapi:"primary,schedule"`
+	Enabled bool   `jsonapi:"attr,enabled"`
+}
+
+func TestPlan_MatchBySpec(t *testing.T) {
+	Register(&cobra.Command{Use: "test"}, Config[testSchedule]{
+		Name:    "schedule",
+		Parents: testConfig().Parents,
+		Fields:  []FieldDef{{StructField: "Enabled", Flag: "enabled", Type: Bool}},
+		List: func(context.Context, *terrakube.Client, []string, *terrakube.ListOptions) ([]*testSchedule, error) {
+			return []*testSchedule{{ID: "s-1"}, {ID: "s-2", Enabled: true}}, nil
+		},
+		Create: func(_ context.Context, _ *terrakube.Client, _ []string, s *testSchedule) (*testSchedule, error) { return s, nil },
+	})
+
+	m := Manifest{Kind: "schedule", Parents: map[string]string{"organization": orgID}, Spec: map[string]any{"enabled": true}}
+	ch, err := Plan(context.Background(), nil, m)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if ch.Action != Unchanged || ch.ID != "s-2" || ch.Name != "schedule" {
+		t.Errorf("expected the matching schedule to be unchanged, got %s %s %s", ch.Name, ch.Action, ch.ID)
+	}
+}
+
+func TestApplyEach(t *testing.T) {
+	widgets := fakeWidgets(t)
+	registerGadgets(t, widgets)
+	registerVaults()
+
+	var out bytes.Buffer
+	err := ApplyEach(context.Background(), nil, []Manifest{
+		{Kind: "gadget", Parents: map[string]string{"widget": "broken"}, Spec: map[string]any{"name": "g"}},
+		widgetManifest(map[string]any{"name": "fresh"}),
+		widgetManifest(map[string]any{"name": "broken", "flag": "maybe"}),
+		{Kind: "vault", Parents: map[string]string{"organization": orgID}, Spec: map[string]any{"name": "v", "secret": SecretPlaceholder}},
+	}, &out)
+	if err == nil || err.Error() != "1 of 4 resources failed" {
+		t.Errorf("expected one failure, got %v", err)
+	}
+
+	want := `widget/fresh created
+widget/broken failed: spec.flag: expected a boolean, got "maybe"
+vault/v skipped: spec.secret is a secret placeholder, set the value to create the vault
+gadget/g skipped: widget/broken was not applied
+`
+	if out.String() != want {
+		t.Errorf("expected output:\n%s\ngot:\n%s", want, out.String())
+	}
+}
+
+func TestIsSecret(t *testing.T) {
+	fields := []FieldDef{
+		{StructField: "Desc", Flag: "description", Type: String, SensitiveIf: "flag"},
+		{StructField: "Flag", Flag: "flag", Type: Bool},
+		{StructField: "Name", Flag: "name", Type: String, Sensitive: true},
+	}
+	tests := []struct {
+		name  string
+		field int
+		obj   *testResource
+		spec  map[string]any
+		want  bool
+	}{
+		{"sensitive field", 2, nil, nil, true},
+		{"plain object", 0, &testResource{Flag: false}, nil, false},
+		{"sensitive object", 0, &testResource{Flag: true}, nil, true},
+		{"made sensitive by spec", 0, &testResource{Flag: false}, map[string]any{"flag": true}, true},
+		{"created sensitive", 0, nil, map[string]any{"flag": true}, true},
+		{"created plain", 0, nil, map[string]any{"flag": false}, false},
+		{"other field", 1, &testResource{Flag: true}, nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isSecret(fields, fields[tt.field], tt.obj, tt.spec); got != tt.want {
+				t.Errorf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,396 @@
+package resource
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"reflect"
+	"slices"
+	"strings"
+
+	terrakube "github.com/terrakube-io/terrakube-go"
+)
+
+// AtomicBatch is a set of manifests compiled into one JSON:API atomic
+// operations request, so that their changes land together or not at all.
+type AtomicBatch struct {
+	// Changes are the planned changes of the manifests, in apply order.
+	Changes []*Change
+	Request terrakube.AtomicRequest
+
+	// ops holds the change of each operation of Request.
+	ops []*Change
+}
+
+// Operation returns the change compiled into the operation at index i of
+// the request.
+func (b *AtomicBatch) Operation(i int) (*Change, bool) {
+	if i < 0 || i >= len(b.ops) {
+		return nil, false
+	}
+	return b.ops[i], true
+}
+
+// atomicResource is the resource object of an add or update operation, or
+// the identifier in a relationship.
+type atomicResource struct {
+	Type          string                        `json:"type"`
+	ID            string                        `json:"id,omitempty"`
+	LID           string                        `json:"lid,omitempty"`
+	Attributes    map[string]any                `json:"attributes,omitempty"`
+	Relationships map[string]atomicRelationship `json:"relationships,omitempty"`
+}
+
+type atomicRelationship struct {
+	Data atomicResource `json:"data"`
+}
+
+// CompileAtomic plans every manifest, parents first, and compiles the
+// changes into an atomic request. Resources created in the batch get a
+// local ID (lid), by which the manifests under them refer to them.
+func CompileAtomic(ctx context.Context, c *terrakube.Client, manifests []Manifest) (*AtomicBatch, error) {
+	batch := &AtomicBatch{Request: terrakube.AtomicRequest{Operations: []terrakube.AtomicOperation{}}}
+	lids := make(map[string]string)
+	for _, m := range SortManifests(manifests) {
+		k, ok := LookupKind(m.Kind)
+		if !ok {
+			return nil, fmt.Errorf("%s: unknown kind %q", m.Source, m.Kind)
+		}
+		lid := fmt.Sprintf("lid-%d", len(batch.ops)+1)
+		ch, op, err := k.atomicOperation(ctx, c, m, lids, lid)
+		var notFound *NotFoundError
+		if errors.As(err, &notFound) && lids[notFound.Kind+"/"+notFound.Value] != "" {
+			err = fmt.Errorf("%w: it is created in the same batch, which an atomic request cannot refer to from a field, apply it first", err)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", m.Source, err)
+		}
+		batch.Changes = append(batch.Changes, ch)
+		if op == nil {
+			continue
+		}
+		if ch.Action == Create {
+			lids[ch.Name] = lid
+		}
+		batch.Request.Operations = append(batch.Request.Operations, *op)
+		batch.ops = append(batch.ops, ch)
+	}
+	return batch, nil
+}
+
+// SubmitAtomic submits the request of a batch and writes a line per
+// resource to w. Nothing is written when the request fails, as nothing was
+// changed.
+func SubmitAtomic(ctx context.Context, c *terrakube.Client, batch *AtomicBatch, w io.Writer) error {
+	if len(batch.ops) > 0 {
+		resp, err := c.Operations.Submit(ctx, &batch.Request)
+		if err != nil {
+			return err
+		}
+		for i, ch := range batch.ops {
+			if ch.Action == Create && i < len(resp.Results) {
+				var res atomicResource
+				if json.Unmarshal(resp.Results[i].Data, &res) == nil {
+					ch.ID = res.ID
+				}
+			}
+			invalidateCache(ch.Kind.Name())
+		}
+	}
+	for _, ch := range batch.Changes {
+		if _, err := fmt.Fprintf(w, "%s %s\n", ch.Name, status(ch.Action)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// atomicOperation plans m and compiles the change into an operation, nil
+// when nothing changes. Resources created in the batch are found in lids
+// by kind/name; lid is the local ID given to the resource if m creates it.
+func (k *kind[T]) atomicOperation(ctx context.Context, c *terrakube.Client, m Manifest, lids map[string]string, lid string) (*Change, *terrakube.AtomicOperation, error) {
+	spec, placeholders, err := k.specValues(m.Spec)
+	if err != nil {
+		return nil, nil, err
+	}
+	parentIDs, parentLIDs, err := k.resolveBatchParents(ctx, c, m.Parents, lids)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var ch *Change
+	switch {
+	case !slices.ContainsFunc(parentLIDs, func(l string) bool { return l != "" }):
+		ch, err = k.Plan(ctx, c, m)
+	case m.Delete:
+		ch, err = k.planDelete(m, spec, parentIDs, nil)
+	default:
+		if err := k.resolveRefs(ctx, c, parentIDs, spec); err != nil {
+			return nil, nil, err
+		}
+		ch, err = k.planCreate(m, spec, placeholders, parentIDs)
+	}
+	if err != nil || ch.Action == Unchanged {
+		return ch, nil, err
+	}
+	op, err := k.operation(ch, parentLIDs, lid)
+	if err != nil {
+		return nil, nil, err
+	}
+	return ch, op, nil
+}
+
+// operation compiles a planned change into an atomic operation. Parents
+// created in the same request are given in parentLIDs; lid is the local ID
+// of a created resource, if any.
+func (k *kind[T]) operation(ch *Change, parentLIDs []string, lid string) (*terrakube.AtomicOperation, error) {
+	href, err := k.href(ch.ParentIDs, parentLIDs)
+	if err != nil {
+		return nil, err
+	}
+	if ch.Action == Delete {
+		return &terrakube.AtomicOperation{Op: "remove", Href: href + "/" + ch.ID}, nil
+	}
+
+	res := atomicResource{Type: k.schema().Type, Attributes: make(map[string]any, len(ch.Fields))}
+	attrs := whereAttributes[T](k.cfg.Fields)
+	refs := k.relationFields()
+	fieldRels := make(map[string]atomicRelationship)
+	for _, f := range ch.Fields {
+		if rel, ok := refs[f.Field]; ok {
+			if id, _ := f.New.(string); id != "" {
+				fieldRels[rel.name] = atomicRelationship{Data: atomicResource{Type: rel.typ, ID: id}}
+			}
+			continue
+		}
+		attr, ok := attrs[f.Field]
+		if !ok {
+			attr = f.Field
+		}
+		res.Attributes[attr] = f.New
+	}
+	op := &terrakube.AtomicOperation{Op: "update", Href: href + "/" + ch.ID}
+	if ch.Action == Create {
+		res.LID = lid
+		if res.Relationships, err = k.atomicRelationships(ch.ParentIDs, parentLIDs); err != nil {
+			return nil, err
+		}
+		op = &terrakube.AtomicOperation{Op: "add", Href: href}
+	} else {
+		res.ID = ch.ID
+	}
+	for name, rel := range fieldRels {
+		if res.Relationships == nil {
+			res.Relationships = make(map[string]atomicRelationship)
+		}
+		res.Relationships[name] = rel
+	}
+	if op.Data, err = json.Marshal(res); err != nil {
+		return nil, err
+	}
+	return op, nil
+}
+
+// href is the API path of the collection of k under its parents, each
+// given by ID or, when created in the same batch, by lid.
+func (k *kind[T]) href(parentIDs, parentLIDs []string) (string, error) {
+	var b strings.Builder
+	for i, p := range k.cfg.Parents {
+		ref := parentIDs[i]
+		if parentLIDs[i] != "" {
+			ref = parentLIDs[i]
+		}
+		if p.Relation != "" || ref == "" {
+			continue
+		}
+		parent, ok := kinds[p.Flag]
+		if !ok {
+			return "", fmt.Errorf("%s resources cannot be changed atomically, %s is not a known kind", k.cfg.Name, p.Flag)
+		}
+		fmt.Fprintf(&b, "/%s/%s", parent.collection(), ref)
+	}
+	return b.String() + "/" + k.collection(), nil
+}
+
+// atomicRelationships returns the parents that are relationships of the
+// resource rather than part of its path, such as the workspace of a
+// collection reference.
+func (k *kind[T]) atomicRelationships(parentIDs, parentLIDs []string) (map[string]atomicRelationship, error) {
+	var rels map[string]atomicRelationship
+	for i, p := range k.cfg.Parents {
+		if p.Relation == "" || (parentIDs[i] == "" && parentLIDs[i] == "") {
+			continue
+		}
+		field, ok := reflect.TypeFor[T]().FieldByName(p.Relation)
+		parent, known := kinds[p.Flag]
+		if !ok || !known {
+			return nil, fmt.Errorf("%s resources cannot be changed atomically, no %s relationship", k.cfg.Name, p.Flag)
+		}
+		name := relationName(field)
+		if rels == nil {
+			rels = make(map[string]atomicRelationship)
+		}
+		rels[name] = atomicRelationship{Data: atomicResource{Type: parent.schema().Type, ID: parentIDs[i], LID: parentLIDs[i]}}
+	}
+	return rels, nil
+}
+
+// atomicRef is a Ref field held in a relation: the name of the
+// relationship and the JSON:API type of the resource it points to.
+type atomicRef struct {
+	name, typ string
+}
+
+// relationFields returns the Ref fields of k that are relations of T, such
+// as the VCS of a workspace, by flag.
+func (k *kind[T]) relationFields() map[string]atomicRef {
+	refs := make(map[string]atomicRef)
+	for _, f := range k.cfg.Fields {
+		field, _ := reflect.TypeFor[T]().FieldByName(f.StructField)
+		ref, known := kinds[f.Ref]
+		if name := relationName(field); name != "" && known {
+			refs[f.Flag] = atomicRef{name: name, typ: ref.schema().Type}
+		}
+	}
+	return refs
+}
+
+func (k *kind[T]) collection() string {
+	if k.cfg.Collection != "" {
+		return k.cfg.Collection
+	}
+	return k.schema().Type
+}
+
+func (k *kind[T]) schema() jsonapiSchema { return schemaOf[T]() }
+
+// atomicObject is a resource object, or an identifier, as read from an
+// atomic request. Relationship data is kept raw, being one identifier or a
+// list of them.
+type atomicObject struct {
+	Type          string         `json:"type"`
+	ID            string         `json:"id"`
+	LID           string         `json:"lid"`
+	Attributes    map[string]any `json:"attributes"`
+	Relationships map[string]struct {
+		Data json.RawMessage `json:"data"`
+	} `json:"relationships"`
+}
+
+// ValidateAtomic checks an atomic request against the registered kinds,
+// without sending it: the op of every operation, its ref or href, the types
+// and attributes of its data and the lids it refers to, which must be added
+// by an earlier operation. An ID in an href is taken as a lid when an
+// operation of the request adds a resource with that lid. The error lists
+// every problem with its JSON pointer.
+func ValidateAtomic(req *terrakube.AtomicRequest) error {
+	schemas := make(map[string]jsonapiSchema)
+	for _, k := range Kinds() {
+		s := k.schema()
+		schemas[s.Type] = s
+	}
+	declared := make(map[string]bool)
+	for _, op := range req.Operations {
+		if objects, many, err := atomicObjects(op.Data); err == nil && op.Op == "add" && !many && len(objects) == 1 {
+			declared[objects[0].LID] = true
+		}
+	}
+	delete(declared, "")
+	lids := make(map[string]bool)
+	var problems []string
+	for i, op := range req.Operations {
+		problem := func(path, format string, args ...any) {
+			problems = append(problems, fmt.Sprintf("/atomic:operations/%d%s: ", i, path)+fmt.Sprintf(format, args...))
+		}
+		checkLID := func(path, lid string) {
+			if lid != "" && !lids[lid] {
+				problem(path, "lid %q is not added by an earlier operation", lid)
+			}
+		}
+
+		if !slices.Contains([]string{"add", "update", "remove"}, op.Op) {
+			problem("/op", "expected add, update or remove, got %q", op.Op)
+		}
+		if op.Ref == nil && op.Href == "" {
+			problem("", "ref or href is required")
+		}
+		if op.Ref != nil {
+			if _, ok := schemas[op.Ref.Type]; !ok {
+				problem("/ref/type", "unknown type %q", op.Ref.Type)
+			}
+			checkLID("/ref/lid", op.Ref.LID)
+		}
+		// Hrefs alternate collections and IDs, and resources added in the
+		// request are referred to by lid in place of their ID.
+		segments := strings.Split(strings.Trim(op.Href, "/"), "/")
+		for j := 1; j < len(segments); j += 2 {
+			if declared[segments[j]] {
+				checkLID("/href", segments[j])
+			}
+		}
+
+		objects, many, err := atomicObjects(op.Data)
+		if err != nil {
+			problem("/data", "%v", err)
+			continue
+		}
+		if len(objects) == 0 && !many && (op.Op == "add" || op.Op == "update") {
+			problem("", "data is required to %s", op.Op)
+		}
+		for j, obj := range objects {
+			path := "/data"
+			if many {
+				path = fmt.Sprintf("/data/%d", j)
+			}
+			if s, ok := schemas[obj.Type]; !ok {
+				problem(path+"/type", "unknown type %q", obj.Type)
+			} else {
+				for _, attr := range slices.Sorted(maps.Keys(obj.Attributes)) {
+					if !s.Attrs[attr] {
+						problem(path+"/attributes/"+attr, "unknown attribute of %s, expected one of %s", obj.Type, s.attrNames())
+					}
+				}
+			}
+			for _, name := range slices.Sorted(maps.Keys(obj.Relationships)) {
+				ids, _, err := atomicObjects(obj.Relationships[name].Data)
+				if err != nil {
+					problem(path+"/relationships/"+name, "%v", err)
+				}
+				for _, id := range ids {
+					checkLID(path+"/relationships/"+name, id.LID)
+				}
+			}
+			if op.Op == "add" && !many && obj.LID != "" {
+				lids[obj.LID] = true
+			} else {
+				checkLID(path+"/lid", obj.LID)
+			}
+		}
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("invalid atomic request:\n  %s", strings.Join(problems, "\n  "))
+	}
+	return nil
+}
+
+// atomicObjects decodes the data of an operation or relationship: null, one
+// object or a list of them.
+func atomicObjects(data json.RawMessage) (objects []atomicObject, many bool, err error) {
+	data = bytes.TrimSpace(data)
+	switch {
+	case len(data) == 0 || bytes.Equal(data, []byte("null")):
+		return nil, false, nil
+	case data[0] == '[':
+		err = json.Unmarshal(data, &objects)
+		return objects, true, err
+	}
+	var obj atomicObject
+	if err := json.Unmarshal(data, &obj); err != nil {
+		return nil, false, err
+	}
+	return []atomicObject{obj}, false, nil
+}
@@ -0,0 +1,116 @@
+package resource
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	terrakube "github.com/terrakube-io/terrakube-go"
+	"github.com/spf13/cobra"
+)
+
+// registerAtomicKinds replaces the registered kinds with organizations,
+// widgets and gadgets under widgets.
+func registerAtomicKinds(t *testing.T, existing ...*testResource) {
+	t.Helper()
+	saved := kinds
+	kinds = map[string]Kind{}
+	t.Cleanup(func() { kinds = saved })
+
+	Register(&cobra.Command{Use: "test"}, Config[testResource]{Name: "organization", Collection: "organization"})
+	widgets := fakeWidgets(t, existing...)
+	registerGadgets(t, widgets)
+	registerGizmos()
+}
+
+func TestCompileAtomic(t *testing.T) {
+	desc := "old"
+	registerAtomicKinds(t, &testResource{ID: "w-1", Name: "existing", Desc: &desc}, &testResource{ID: "w-2", Name: "doomed"})
+
+	batch, err := CompileAtomic(context.Background(), nil, []Manifest{
+		{Kind: "widget", Parents: map[string]string{"organization": orgID}, Spec: map[string]any{"name": "gone"}, Delete: true},
+		{Kind: "gadget", Parents: map[string]string{"widget": "fresh"}, Spec: map[string]any{"name": "g"}},
+		widgetManifest(map[string]any{"name": "existing", "description": "new"}),
+		{Kind: "widget", Parents: map[string]string{"organization": orgID}, Spec: map[string]any{"name": "doomed"}, Delete: true},
+		widgetManifest(map[string]any{"name": "fresh"}),
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var names []string
+	for _, ch := range batch.Changes {
+		names = append(names, ch.Name+" "+string(ch.Action))
+	}
+	wantNames := "widget/existing update, widget/fresh create, gadget/g create, widget/gone unchanged, widget/doomed delete"
+	if got := strings.Join(names, ", "); got != wantNames {
+		t.Errorf("expected changes %s, got %s", wantNames, got)
+	}
+
+	want := []struct{ op, href, data string }{
+		{"update", "/organization/" + orgID + "/test/w-1", `{"type":"test","id":"w-1","attributes":{"desc":"new"}}`},
+		{"add", "/organization/" + orgID + "/test", `{"type":"test","lid":"lid-2","attributes":{"name":"fresh"}}`},
+		{"add", "/test/lid-2/test", `{"type":"test","lid":"lid-3","attributes":{"name":"g"}}`},
+		{"remove", "/organization/" + orgID + "/test/w-2", ""},
+	}
+	ops := batch.Request.Operations
+	if len(ops) != len(want) {
+		t.Fatalf("expected %d operations, got %d: %+v", len(want), len(ops), ops)
+	}
+	for i, w := range want {
+		if ops[i].Op != w.op || ops[i].Href != w.href || string(ops[i].Data) != w.data {
+			t.Errorf("operation %d: expected %s %s %s, got %s %s %s", i, w.op, w.href, w.data, ops[i].Op, ops[i].Href, ops[i].Data)
+		}
+	}
+	if ch, ok := batch.Operation(2); !ok || ch.Name != "gadget/g" {
+		t.Errorf("expected operation 2 to be gadget/g, got %v", ch)
+	}
+}
+
+func TestCompileAtomic_RefToNewResource(t *testing.T) {
+	registerAtomicKinds(t)
+
+	_, err := CompileAtomic(context.Background(), nil, []Manifest{
+		widgetManifest(map[string]any{"name": "fresh"}),
+		{Kind: "gizmo", Parents: map[string]string{"organization": orgID}, Spec: map[string]any{"name": "z", "widget-id": "fresh"}, Source: "z.yaml"},
+	})
+	if err == nil || !strings.Contains(err.Error(), "z.yaml: spec.widget-id") || !strings.Contains(err.Error(), "created in the same batch") {
+		t.Errorf("expected a same batch reference error, got %v", err)
+	}
+}
+
+func TestValidateAtomic_HrefLIDs(t *testing.T) {
+	registerAtomicKinds(t)
+	request := func(raw string) *terrakube.AtomicRequest {
+		t.Helper()
+		var req terrakube.AtomicRequest
+		if err := json.Unmarshal([]byte(raw), &req); err != nil {
+			t.Fatal(err)
+		}
+		return &req
+	}
+
+	// Server IDs need not be UUIDs: only lids added in the request are
+	// checked.
+	valid := request(`{"atomic:operations": [
+  {"op": "add", "href": "/organization/org-1/widget", "data": {"type": "test", "lid": "w", "attributes": {"name": "first"}}},
+  {"op": "update", "href": "/organization/org-1/widget/w", "data": {"type": "test", "lid": "w", "attributes": {"flag": true}}},
+  {"op": "remove", "href": "/organization/org-1/widget/widget-2"}
+]}`)
+	if err := ValidateAtomic(valid); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	dangling := request(`{"atomic:operations": [
+  {"op": "add", "href": "/organization/org-1/widget/w/gadget", "data": {"type": "test", "attributes": {"name": "g"}}},
+  {"op": "add", "href": "/organization/org-1/widget", "data": {"type": "test", "lid": "w", "attributes": {"name": "first"}}}
+]}`)
+	err := ValidateAtomic(dangling)
+	if err == nil || !strings.Contains(err.Error(), `/atomic:operations/0/href: lid "w" is not added by an earlier operation`) {
+		t.Errorf("expected a lid used before it is added, got %v", err)
+	}
+	if err != nil && strings.Contains(err.Error(), "org-1") {
+		t.Errorf("expected the server ID to be accepted, got %v", err)
+	}
+}
@@ -0,0 +1,186 @@
+package resource
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	terrakube "github.com/terrakube-io/terrakube-go"
+)
+
+// FieldOp classifies a field change: a value set, changed or cleared.
+type FieldOp string
+
+const (
+	FieldAdded   FieldOp = "add"
+	FieldChanged FieldOp = "change"
+	FieldDeleted FieldOp = "delete"
+)
+
+// maskedValue replaces the values of sensitive fields in diffs.
+const maskedValue = "(sensitive)"
+
+// Op classifies the change of the field by whether it is set before and
+// after: zero values such as false are values like any other.
+func (f FieldChange) Op() FieldOp {
+	switch {
+	case f.Old == nil:
+		return FieldAdded
+	case f.New == nil:
+		return FieldDeleted
+	default:
+		return FieldChanged
+	}
+}
+
+func isZero(v any) bool {
+	switch v := v.(type) {
+	case nil:
+		return true
+	case string:
+		return v == ""
+	case bool:
+		return !v
+	case int:
+		return v == 0
+	case []string:
+		return len(v) == 0
+	}
+	return false
+}
+
+// PlanAll plans every manifest, parents first, without changing anything.
+// Manifests under a parent that another manifest creates are planned as
+// creations.
+func PlanAll(ctx context.Context, c *terrakube.Client, manifests []Manifest) ([]*Change, error) {
+	created := make(map[string]bool)
+	var changes []*Change
+	for _, m := range SortManifests(manifests) {
+		ch, err := Plan(ctx, c, m)
+		var notFound *NotFoundError
+		if errors.As(err, &notFound) && created[notFound.Kind+"/"+notFound.Value] {
+			k, _ := LookupKind(m.Kind)
+			if ch, err = k.planPending(m); err != nil {
+				err = fmt.Errorf("%s: %w", m.Source, err)
+			}
+		}
+		if err != nil {
+			return nil, err
+		}
+		if ch.Action == Create {
+			created[ch.Name] = true
+		}
+		changes = append(changes, ch)
+	}
+	return changes, nil
+}
+
+// HasChanges reports whether applying the changes would modify anything.
+func HasChanges(changes []*Change) bool {
+	for _, ch := range changes {
+		if ch.Action != Unchanged {
+			return true
+		}
+	}
+	return false
+}
+
+// WriteDiff writes the changes as a unified diff of fields followed by a
+// summary. Sensitive values are masked.
+func WriteDiff(w io.Writer, changes []*Change) error {
+	var b strings.Builder
+	counts := map[Action]int{}
+	for _, ch := range changes {
+		counts[ch.Action]++
+		if ch.Action == Unchanged {
+			continue
+		}
+
+		from, to := ch.Name+" (server)", fmt.Sprintf("%s (%s)", ch.Name, ch.Manifest.Source)
+		switch ch.Action {
+		case Create:
+			from = "/dev/null"
+		case Delete:
+			to = "/dev/null"
+		}
+		fmt.Fprintf(&b, "--- %s\n+++ %s\n", from, to)
+		for _, f := range ch.Fields {
+			if f.Op() != FieldAdded {
+				fmt.Fprintf(&b, "-%s: %s\n", f.Field, diffValue(f.Old, f.Sensitive))
+			}
+			if f.Op() != FieldDeleted {
+				fmt.Fprintf(&b, "+%s: %s\n", f.Field, diffValue(f.New, f.Sensitive))
+			}
+		}
+	}
+
+	if !HasChanges(changes) {
+		b.WriteString("No changes.\n")
+	} else {
+		fmt.Fprintf(&b, "%d to create, %d to update, ", counts[Create], counts[Update])
+		if counts[Delete] > 0 {
+			fmt.Fprintf(&b, "%d to delete, ", counts[Delete])
+		}
+		fmt.Fprintf(&b, "%d unchanged.\n", counts[Unchanged])
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func diffValue(v any, sensitive bool) string {
+	if sensitive {
+		return maskedValue
+	}
+	if items, ok := v.([]string); ok {
+		return "[" + strings.Join(items, ", ") + "]"
+	}
+	return fmt.Sprint(v)
+}
+
+// DiffReport is the JSON and YAML form of a diff.
+type DiffReport struct {
+	Changed bool           `json:"changed" yaml:"changed"`
+	Changes []ChangeReport `json:"changes" yaml:"changes"`
+}
+
+// ChangeReport is one resource of a DiffReport.
+type ChangeReport struct {
+	Name   string        `json:"name" yaml:"name"`
+	Kind   string        `json:"kind" yaml:"kind"`
+	Action Action        `json:"action" yaml:"action"`
+	ID     string        `json:"id,omitempty" yaml:"id,omitempty"`
+	Source string        `json:"source" yaml:"source"`
+	Fields []FieldReport `json:"fields,omitempty" yaml:"fields,omitempty"`
+}
+
+// FieldReport is one field of a ChangeReport.
+type FieldReport struct {
+	Field string  `json:"field" yaml:"field"`
+	Op    FieldOp `json:"op" yaml:"op"`
+	Old   any     `json:"old,omitempty" yaml:"old,omitempty"`
+	New   any     `json:"new,omitempty" yaml:"new,omitempty"`
+}
+
+// NewDiffReport describes the changes, with sensitive values masked.
+func NewDiffReport(changes []*Change) DiffReport {
+	report := DiffReport{Changed: HasChanges(changes), Changes: []ChangeReport{}}
+	for _, ch := range changes {
+		cr := ChangeReport{Name: ch.Name, Kind: ch.Kind.Name(), Action: ch.Action, ID: ch.ID, Source: ch.Manifest.Source}
+		for _, f := range ch.Fields {
+			fr := FieldReport{Field: f.Field, Op: f.Op(), Old: f.Old, New: f.New}
+			if f.Sensitive {
+				if !isZero(fr.Old) {
+					fr.Old = maskedValue
+				}
+				if !isZero(fr.New) {
+					fr.New = maskedValue
+				}
+			}
+			cr.Fields = append(cr.Fields, fr)
+		}
+		report.Changes = append(report.Changes, cr)
+	}
+	return report
+}
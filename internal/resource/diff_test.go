@@ -0,0 +1,163 @@
+package resource
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	terrakube "github.com/terrakube-io/terrakube-go"
+	"github.com/spf13/cobra"
+)
+
+// registerGadgets registers a gadget kind living under widgets, with a
+// sensitive secret field.
+func registerGadgets(t *testing.T, widgets *[]*testResource) {
+	t.Helper()
+	Register(&cobra.Command{Use: "test"}, Config[testResource]{
+		Name: "gadget",
+		Parents: []ParentScope{{
+			Name: "widget",
+			Flag: "widget",
+			Resolver: NameResolver("widget", "widget", "name", func(_ context.Context, _ *terrakube.Client, _ []string, opts *terrakube.ListOptions) ([]*testResource, error) {
+				var out []*testResource
+				for _, w := range *widgets {
+					if opts.Filter == "name=="+quoteRSQL(w.Name) {
+						out = append(out, w)
+					}
+				}
+				return out, nil
+			}),
+		}},
+		Fields: []FieldDef{
+			{StructField: "Name", Flag: "name", Type: String, Required: true},
+			{StructField: "Desc", Flag: "secret", Type: String, Sensitive: true},
+		},
+		List:   func(context.Context, *terrakube.Client, []string, *terrakube.ListOptions) ([]*testResource, error) { return nil, nil },
+		Create: func(_ context.Context, _ *terrakube.Client, _ []string, g *testResource) (*testResource, error) { return g, nil },
+	})
+}
+
+func TestPlanAll_PendingParent(t *testing.T) {
+	widgets := fakeWidgets(t)
+	registerGadgets(t, widgets)
+
+	changes, err := PlanAll(context.Background(), nil, []Manifest{
+		{Kind: "gadget", Parents: map[string]string{"widget": "fresh"}, Spec: map[string]any{"name": "g", "secret": "s3cr3t"}, Source: "g.yaml"},
+		widgetManifest(map[string]any{"name": "fresh"}),
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(changes) != 2 || changes[0].Name != "widget/fresh" || changes[1].Name != "gadget/g" || changes[1].Action != Create {
+		t.Fatalf("unexpected changes: %+v", changes)
+	}
+	if len(*widgets) != 0 {
+		t.Error("expected PlanAll not to create anything")
+	}
+
+	_, err = PlanAll(context.Background(), nil, []Manifest{
+		{Kind: "gadget", Parents: map[string]string{"widget": "missing"}, Spec: map[string]any{"name": "g"}, Source: "g.yaml"},
+	})
+	if err == nil || !strings.Contains(err.Error(), `g.yaml: no widget found with name "missing"`) {
+		t.Errorf("expected a missing parent error, got %v", err)
+	}
+}
+
+func TestWriteDiff(t *testing.T) {
+	desc := "old"
+	widgets := fakeWidgets(t, &testResource{ID: "w-1", Name: "existing", Desc: &desc, Flag: true}, &testResource{ID: "w-2", Name: "same"})
+	registerGadgets(t, widgets)
+
+	changes, err := PlanAll(context.Background(), nil, []Manifest{
+		widgetManifest(map[string]any{"name": "existing", "description": "new", "flag": false}),
+		widgetManifest(map[string]any{"name": "same"}),
+		{Kind: "gadget", Parents: map[string]string{"widget": "same"}, Spec: map[string]any{"name": "g", "secret": "s3cr3t"}, Source: "g.yaml"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var out bytes.Buffer
+	if err := WriteDiff(&out, changes); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := `--- widget/existing (server)
++++ widget/existing (widget.yaml)
+-description: old
++description: new
+-flag: true
++flag: false
+--- /dev/null
++++ gadget/g (g.yaml)
++name: g
++secret: (sensitive)
+1 to create, 1 to update, 1 unchanged.
+`
+	if out.String() != want {
+		t.Errorf("expected diff:\n%s\ngot:\n%s", want, out.String())
+	}
+	if strings.Contains(out.String(), "s3cr3t") {
+		t.Error("expected the secret to be masked")
+	}
+
+	report := NewDiffReport(changes)
+	if !report.Changed || len(report.Changes) != 3 {
+		t.Fatalf("unexpected report: %+v", report)
+	}
+	fields := report.Changes[0].Fields
+	if fields[0].Op != FieldChanged || fields[1].Op != FieldChanged {
+		t.Errorf("unexpected field ops: %+v", fields)
+	}
+	if secret := report.Changes[2].Fields[1]; secret.New != "(sensitive)" || secret.Op != FieldAdded {
+		t.Errorf("expected the secret to be masked, got %+v", secret)
+	}
+}
+
+func TestWriteDiff_BoolFlips(t *testing.T) {
+	fakeWidgets(t, &testResource{ID: "w-1", Name: "off"}, &testResource{ID: "w-2", Name: "on", Flag: true})
+	changes, err := PlanAll(context.Background(), nil, []Manifest{
+		widgetManifest(map[string]any{"name": "off", "flag": true}),
+		widgetManifest(map[string]any{"name": "on", "flag": false}),
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var out bytes.Buffer
+	if err := WriteDiff(&out, changes); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := `--- widget/off (server)
++++ widget/off (widget.yaml)
+-flag: false
++flag: true
+--- widget/on (server)
++++ widget/on (widget.yaml)
+-flag: true
++flag: false
+0 to create, 2 to update, 0 unchanged.
+`
+	if out.String() != want {
+		t.Errorf("expected diff:\n%s\ngot:\n%s", want, out.String())
+	}
+	for _, ch := range changes {
+		if op := ch.Fields[0].Op(); op != FieldChanged {
+			t.Errorf("%s: expected a change, got %s", ch.Name, op)
+		}
+	}
+}
+
+func TestWriteDiff_NoChanges(t *testing.T) {
+	fakeWidgets(t, &testResource{ID: "w-1", Name: "same"})
+	changes, err := PlanAll(context.Background(), nil, []Manifest{widgetManifest(map[string]any{"name": "same"})})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var out bytes.Buffer
+	_ = WriteDiff(&out, changes)
+	if out.String() != "No changes.\n" || HasChanges(changes) {
+		t.Errorf("expected no changes, got %q", out.String())
+	}
+}
@@ -0,0 +1,232 @@
+package resource
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"reflect"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+
+	"terrakube/internal/output"
+)
+
+// editHeader starts the file opened by edit.
+const editHeader = `# Edit the %s below and save to update it. Fields left out are not changed.
+# An empty file cancels the edit.
+`
+
+func newEditCmd[T any](cfg Config[T]) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "edit",
+		Short: fmt.Sprintf("edit a %s resource in $EDITOR", cfg.Name),
+		Long: fmt.Sprintf(`Open a %[1]s as YAML in $VISUAL or $EDITOR (default vi), keyed by the flags
+of the update command, and update the fields changed in the editor. The
+editor is opened again on invalid values. Nothing is updated if the %[1]s
+changed on the server in the meantime.`, cfg.Name),
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			target, positional, err := targetArg(cmd, args, cfg.Name)
+			if err != nil {
+				return err
+			}
+			client, err := cfg.NewClient()
+			if err != nil {
+				return err
+			}
+			ctx := cfg.GetContext()
+
+			parentIDs, err := resolveParents(ctx, client, cmd, cfg.Parents)
+			if err != nil {
+				return err
+			}
+			id, err := resolveTarget(ctx, cfg, client, parentIDs, target, positional)
+			if err != nil {
+				return err
+			}
+			original, err := cfg.Get(ctx, client, parentIDs, id)
+			if err != nil {
+				return err
+			}
+
+			k := &kind[T]{cfg: cfg}
+			session, err := newEditSession(cfg.Name, k.editDocument(original))
+			if err != nil {
+				return err
+			}
+			values, err := session.edit(func(edited []byte) (map[string]any, error) {
+				var spec map[string]any
+				if err := yaml.Unmarshal(edited, &spec); err != nil {
+					return nil, err
+				}
+				values, _, err := k.specValues(spec)
+				return values, err
+			})
+			if err != nil {
+				return err
+			}
+
+			resource := new(T)
+			setStructField(resource, "ID", id)
+			changed := false
+			for _, f := range cfg.Fields {
+				if v, ok := values[f.Flag]; ok && !reflect.DeepEqual(fieldValue(original, f), v) {
+					setFieldValue(resource, f, v)
+					changed = true
+				}
+			}
+			if !changed {
+				session.remove()
+				_, err := fmt.Fprintln(os.Stdout, "Edit cancelled, no changes made.")
+				return err
+			}
+
+			current, err := cfg.Get(ctx, client, parentIDs, id)
+			if err != nil {
+				return err
+			}
+			if !k.sameFields(original, current) {
+				return fmt.Errorf("%s %s changed on the server while it was edited, nothing was updated; the edited copy is in %s", cfg.Name, id, session.path)
+			}
+			result, err := cfg.Update(ctx, client, parentIDs, resource)
+			if err != nil {
+				return fmt.Errorf("%w (the edited copy is in %s)", err, session.path)
+			}
+			session.remove()
+			invalidateCache(cfg.Name)
+
+			return output.Render(os.Stdout, result, cfg.GetOutput())
+		},
+	}
+
+	addParentFlags(cmd, cfg.Parents)
+	addTargetFlags(cmd, cfg.Name)
+	return cmd
+}
+
+// editDocument returns the fields of obj as a YAML document keyed by flag
+// names, in field order. Sensitive values are replaced by SecretPlaceholder,
+// which leaves them unchanged.
+func (k *kind[T]) editDocument(obj *T) []byte {
+	doc := &yaml.Node{Kind: yaml.MappingNode}
+	for _, f := range k.cfg.Fields {
+		if f.ManifestOnly {
+			continue
+		}
+		v := fieldValue(obj, f)
+		if isSecret(k.cfg.Fields, f, obj, nil) && !isZero(v) {
+			v = SecretPlaceholder
+		}
+		var value yaml.Node
+		_ = value.Encode(v)
+		doc.Content = append(doc.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: f.Flag}, &value)
+	}
+	b, _ := yaml.Marshal(doc)
+	return append([]byte(fmt.Sprintf(editHeader, k.cfg.Name)), b...)
+}
+
+// sameFields reports whether a and b have the same value in every field.
+func (k *kind[T]) sameFields(a, b *T) bool {
+	for _, f := range k.cfg.Fields {
+		if !reflect.DeepEqual(fieldValue(a, f), fieldValue(b, f)) {
+			return false
+		}
+	}
+	return true
+}
+
+// editSession is a temporary file edited in the user's editor.
+type editSession struct {
+	path string
+}
+
+func newEditSession(name string, content []byte) (*editSession, error) {
+	f, err := os.CreateTemp("", "terrakube-"+name+"-*.yaml")
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+	if _, err := f.Write(content); err != nil {
+		return nil, err
+	}
+	return &editSession{path: f.Name()}, nil
+}
+
+// edit opens the file in the editor until parse accepts it, with the parse
+// error at the top of the file. It returns nil values when the file is
+// emptied, and an error when it is saved again unchanged after an error.
+func (s *editSession) edit(parse func([]byte) (map[string]any, error)) (map[string]any, error) {
+	var last []byte
+	for {
+		if err := runEditor(s.path); err != nil {
+			s.remove()
+			return nil, err
+		}
+		edited, err := os.ReadFile(s.path)
+		if err != nil {
+			return nil, err
+		}
+		if len(bytes.TrimSpace(stripComments(edited))) == 0 {
+			s.remove()
+			return nil, nil
+		}
+		values, err := parse(edited)
+		if err == nil {
+			return values, nil
+		}
+		if bytes.Equal(edited, last) {
+			return nil, fmt.Errorf("%w (the edited copy is in %s)", err, s.path)
+		}
+
+		last = append([]byte("# error: "+strings.ReplaceAll(err.Error(), "\n", "\n# error: ")+"\n"), stripErrors(edited)...)
+		if err := os.WriteFile(s.path, last, 0o600); err != nil {
+			return nil, err
+		}
+	}
+}
+
+func (s *editSession) remove() {
+	_ = os.Remove(s.path)
+}
+
+// runEditor opens path in $VISUAL or $EDITOR, which may hold arguments,
+// falling back to vi.
+func runEditor(path string) error {
+	args := strings.Fields(os.Getenv("VISUAL"))
+	if len(args) == 0 {
+		args = strings.Fields(os.Getenv("EDITOR"))
+	}
+	if len(args) == 0 {
+		args = []string{"vi"}
+	}
+	cmd := exec.Command(args[0], append(args[1:], path)...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("running editor %q: %w", strings.Join(args, " "), err)
+	}
+	return nil
+}
+
+// stripComments drops the comment lines of an edited file.
+func stripComments(b []byte) []byte {
+	var out []byte
+	for _, line := range bytes.SplitAfter(b, []byte("\n")) {
+		if !bytes.HasPrefix(bytes.TrimSpace(line), []byte("#")) {
+			out = append(out, line...)
+		}
+	}
+	return out
+}
+
+// stripErrors drops the error lines added to an edited file by a previous
+// attempt.
+func stripErrors(b []byte) []byte {
+	lines := bytes.SplitAfter(b, []byte("\n"))
+	for len(lines) > 0 && bytes.HasPrefix(lines[0], []byte("# error: ")) {
+		lines = lines[1:]
+	}
+	return bytes.Join(lines, nil)
+}
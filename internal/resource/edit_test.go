@@ -0,0 +1,139 @@
+package resource
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	terrakube "github.com/terrakube-io/terrakube-go"
+	"github.com/spf13/cobra"
+)
+
+// editWidgets registers a widget kind on a new root command, whose Get
+// returns the widgets in turn, the last one from then on, and whose Update
+// records the resource it is given.
+func editWidgets(t *testing.T, versions ...*testResource) (*cobra.Command, **testResource) {
+	t.Helper()
+	var updated *testResource
+	gets := 0
+	cfg := testConfig()
+	cfg.Get = func(context.Context, *terrakube.Client, []string, string) (*testResource, error) {
+		w := *versions[min(gets, len(versions)-1)]
+		gets++
+		return &w, nil
+	}
+	cfg.Update = func(_ context.Context, _ *terrakube.Client, _ []string, w *testResource) (*testResource, error) {
+		updated = w
+		return w, nil
+	}
+	root := &cobra.Command{Use: "test"}
+	Register(root, cfg)
+	return root, &updated
+}
+
+// setEditor makes a shell script the editor, with the edited file as $1,
+// and keeps the edited files in a test directory.
+func setEditor(t *testing.T, script string) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "editor.sh")
+	if err := os.WriteFile(path, []byte("#!/bin/sh\n"+script+"\n"), 0o700); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("VISUAL", "")
+	t.Setenv("EDITOR", path)
+	t.Setenv("TMPDIR", t.TempDir())
+}
+
+func runEdit(root *cobra.Command) error {
+	root.SetArgs([]string{"widget", "edit", "--organization", orgID, "--id", "w-1"})
+	return root.Execute()
+}
+
+func TestEditCmd(t *testing.T) {
+	desc := "old"
+	root, updated := editWidgets(t, &testResource{ID: "w-1", Name: "first", Desc: &desc, Flag: true})
+	setEditor(t, `grep -q '^name: first$' "$1" || exit 1
+sed -i.bak 's/^description: old$/description: new/' "$1"`)
+
+	if err := runEdit(root); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	got := *updated
+	if got == nil || got.ID != "w-1" || got.Desc == nil || *got.Desc != "new" || got.Name != "" {
+		t.Errorf("expected only the description to be sent, got %+v", got)
+	}
+}
+
+func TestEditCmd_ReopensOnError(t *testing.T) {
+	root, updated := editWidgets(t, &testResource{ID: "w-1", Name: "first"})
+	count := filepath.Join(t.TempDir(), "opened")
+	setEditor(t, `if [ -f `+count+` ]; then
+  grep -q '^# error: spec.flag: expected a boolean' "$1" && sed -i.bak 's/^flag: .*/flag: true/' "$1"
+else
+  touch `+count+`
+  sed -i.bak 's/^flag: .*/flag: maybe/' "$1"
+fi`)
+
+	if err := runEdit(root); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := *updated; got == nil || !got.Flag {
+		t.Errorf("expected the fixed flag to be sent, got %+v", got)
+	}
+}
+
+func TestEditCmd_NoChanges(t *testing.T) {
+	root, updated := editWidgets(t, &testResource{ID: "w-1", Name: "first"})
+	setEditor(t, "true")
+
+	if err := runEdit(root); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if *updated != nil {
+		t.Errorf("expected no update, got %+v", *updated)
+	}
+}
+
+func TestEditCmd_ChangedOnServer(t *testing.T) {
+	root, updated := editWidgets(t, &testResource{ID: "w-1", Name: "first"}, &testResource{ID: "w-1", Name: "renamed"})
+	setEditor(t, `sed -i.bak 's/^flag: .*/flag: true/' "$1"`)
+
+	err := runEdit(root)
+	if err == nil || !strings.Contains(err.Error(), "widget w-1 changed on the server while it was edited") {
+		t.Errorf("expected a conflict error, got %v", err)
+	}
+	if *updated != nil {
+		t.Errorf("expected no update, got %+v", *updated)
+	}
+}
+
+func TestRunEditor_BlankVisual(t *testing.T) {
+	setEditor(t, `echo edited > "$1"`)
+	t.Setenv("VISUAL", "  ")
+	path := filepath.Join(t.TempDir(), "widget.yaml")
+
+	if err := runEditor(path); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if b, _ := os.ReadFile(path); string(b) != "edited\n" {
+		t.Errorf("expected $EDITOR to be run, got %q", b)
+	}
+}
+
+func TestEditDocument_SensitiveIf(t *testing.T) {
+	cfg := testConfig()
+	cfg.Fields[1].SensitiveIf = "flag"
+	k := &kind[testResource]{cfg: cfg}
+	desc := "us-east-1"
+
+	doc := string(k.editDocument(&testResource{Name: "region", Desc: &desc}))
+	if !strings.Contains(doc, "description: us-east-1\n") {
+		t.Errorf("expected the value of a non-sensitive widget in plain text, got:\n%s", doc)
+	}
+	doc = string(k.editDocument(&testResource{Name: "region", Desc: &desc, Flag: true}))
+	if !strings.Contains(doc, "description: "+SecretPlaceholder+"\n") || strings.Contains(doc, desc) {
+		t.Errorf("expected the value of a sensitive widget to be hidden, got:\n%s", doc)
+	}
+}
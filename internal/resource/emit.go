@@ -0,0 +1,117 @@
+package resource
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+
+	terrakube "github.com/terrakube-io/terrakube-go"
+	"github.com/spf13/cobra"
+)
+
+// OpsFile, when set, makes the create, update and delete commands append
+// the atomic operation they would perform to the file instead of calling
+// the API, building a request for "operations -f". No client is created, so
+// parents and targets must be given by ID.
+var OpsFile string
+
+// EmitAnnotation marks the commands that honor OpsFile. Any other command
+// would call the API, so the CLI refuses to run it with --emit-operation.
+const EmitAnnotation = "terrakube.io/emit-operation"
+
+// commandScope returns the client of a command and the IDs of its parents.
+// In emit mode there is no client and parents given by name are an error.
+func commandScope[T any](cfg Config[T], cmd *cobra.Command) (*terrakube.Client, []string, error) {
+	if OpsFile == "" {
+		client, err := cfg.NewClient()
+		if err != nil {
+			return nil, nil, err
+		}
+		parentIDs, err := resolveParents(cfg.GetContext(), client, cmd, cfg.Parents)
+		return client, parentIDs, err
+	}
+
+	parentIDs := make([]string, 0, len(cfg.Parents))
+	for _, p := range cfg.Parents {
+		val, _ := cmd.Flags().GetString(p.Flag)
+		if val != "" && !p.RawID && !IsUUID(val) {
+			return nil, nil, fmt.Errorf("--%s: %q is not an ID, --emit-operation does not call the API to resolve names", p.Flag, val)
+		}
+		id, err := resolveScope(cfg.GetContext(), nil, p, val, parentIDs, "--"+p.Flag)
+		if err != nil {
+			return nil, nil, err
+		}
+		parentIDs = append(parentIDs, id)
+	}
+	return nil, parentIDs, nil
+}
+
+// commandTarget resolves the target of a command like resolveTarget. In
+// emit mode a name is an error.
+func commandTarget[T any](cfg Config[T], client *terrakube.Client, parentIDs []string, target string, positional bool) (string, error) {
+	if OpsFile == "" {
+		return resolveTarget(cfg.GetContext(), cfg, client, parentIDs, target, positional)
+	}
+	if positional && !IsUUID(target) && cfg.selfResolver() != nil {
+		return "", fmt.Errorf("%q is not an ID, --emit-operation does not call the API to resolve names", target)
+	}
+	return target, nil
+}
+
+// emitChange appends the operation of a change made by a command to
+// OpsFile and reports it on w.
+func emitChange[T any](cfg Config[T], ch *Change, w io.Writer) error {
+	k := &kind[T]{cfg: cfg}
+	op, err := k.operation(ch, make([]string, len(cfg.Parents)), "")
+	if err != nil {
+		return err
+	}
+	n, err := appendOperation(OpsFile, *op)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "%s %s appended to %s (%d operations)\n", op.Op, cfg.Name, OpsFile, n)
+	return err
+}
+
+// flagChanges returns the fields set by flags on cmd, with their values in
+// obj.
+func flagChanges(cmd *cobra.Command, fields []FieldDef, obj any) []FieldChange {
+	var changes []FieldChange
+	for _, f := range fields {
+		if flag := cmd.Flags().Lookup(f.Flag); flag != nil && flag.Changed {
+			changes = append(changes, FieldChange{Field: f.Flag, New: fieldValue(obj, f), Sensitive: isSecret(fields, f, obj, nil)})
+		}
+	}
+	return changes
+}
+
+// appendOperation adds op to the atomic request in the file at path,
+// creating it if needed, and returns the number of operations it holds.
+func appendOperation(path string, op terrakube.AtomicOperation) (int, error) {
+	var req terrakube.AtomicRequest
+	data, err := os.ReadFile(path)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+	case err != nil:
+		return 0, err
+	case len(bytes.TrimSpace(data)) > 0:
+		if err := json.Unmarshal(data, &req); err != nil {
+			return 0, fmt.Errorf("reading %s: %w", path, err)
+		}
+	}
+
+	req.Operations = append(req.Operations, op)
+	data, err = json.MarshalIndent(req, "", "  ")
+	if err != nil {
+		return 0, err
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0o600); err != nil {
+		return 0, err
+	}
+	return len(req.Operations), nil
+}
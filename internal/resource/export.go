@@ -0,0 +1,429 @@
+package resource
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+
+	terrakube "github.com/terrakube-io/terrakube-go"
+	"gopkg.in/yaml.v3"
+)
+
+// SecretPlaceholder replaces the values of sensitive fields in exported
+// manifests. Apply leaves fields set to it unchanged.
+const SecretPlaceholder = "<secret>"
+
+// PlaceholderError is returned when planning the creation of a resource
+// whose manifest holds SecretPlaceholder in a required field.
+type PlaceholderError struct {
+	Kind  string
+	Field string
+}
+
+func (e *PlaceholderError) Error() string {
+	return fmt.Sprintf("spec.%s is a secret placeholder, set the value to create the %s", e.Field, e.Kind)
+}
+
+// exportItem is a resource read for export, with its fields still holding
+// IDs.
+type exportItem struct {
+	id       string
+	identity string
+	spec     map[string]any
+
+	// relations are the IDs of the parents held in a Relation field, by
+	// parent flag.
+	relations map[string]string
+}
+
+// exported is an exportItem with the kind it belongs to and the IDs of the
+// parents it was listed under.
+type exported struct {
+	kind      Kind
+	item      exportItem
+	parentIDs []string
+}
+
+// Export reads an organization, given by name or ID, and every resource
+// under it that can be exported, and returns them as manifests, parents
+// first. Parents and Ref fields name the resources they point to, and
+// sensitive fields hold SecretPlaceholder. No IDs are written, as they mean
+// nothing on another server: resources without an identity field, such as
+// schedules, are found by their fields when applied.
+func Export(ctx context.Context, c *terrakube.Client, organization string) ([]Manifest, error) {
+	org, err := exportOrganization(ctx, c, organization)
+	if err != nil {
+		return nil, err
+	}
+	items := []exported{org}
+	if err := exportChildren(ctx, c, []string{"organization"}, []string{org.item.id}, &items); err != nil {
+		return nil, err
+	}
+	return exportManifests(dedupeExported(items)), nil
+}
+
+// ExportResource is Export for one resource of an organization, such as a
+// workspace, given by name or ID. The manifests hold the resource, those
+// under it and the organization resources its Ref fields point to, such as
+// templates, but not the organization itself.
+func ExportResource(ctx context.Context, c *terrakube.Client, organization, kindName, name string) ([]Manifest, error) {
+	k, ok := kinds[kindName]
+	if !ok {
+		return nil, fmt.Errorf("unknown kind %q", kindName)
+	}
+	org, err := exportOrganization(ctx, c, organization)
+	if err != nil {
+		return nil, err
+	}
+	orgChain, orgIDs := []string{"organization"}, []string{org.item.id}
+	parentIDs, ok := listedUnder(k, orgChain, orgIDs)
+	if !ok {
+		return nil, fmt.Errorf("%s resources are not listed under an organization", k.Name())
+	}
+
+	id := name
+	if !IsUUID(id) {
+		if id, err = k.resolveName(ctx, c, parentIDs, name); err != nil {
+			return nil, err
+		}
+	}
+	list, err := k.exportItems(ctx, c, parentIDs)
+	if err != nil {
+		return nil, err
+	}
+	i := slices.IndexFunc(list, func(item exportItem) bool { return item.id == id })
+	if i < 0 {
+		return nil, fmt.Errorf("%s %q not found", k.Name(), name)
+	}
+
+	items := []exported{{kind: k, item: list[i], parentIDs: parentIDs}}
+	err = exportChildren(ctx, c, append(orgChain, k.Name()), append(orgIDs, id), &items)
+	if err != nil {
+		return nil, err
+	}
+	refs, err := exportRefs(ctx, c, orgChain, orgIDs, items)
+	if err != nil {
+		return nil, err
+	}
+	manifests := exportManifests(dedupeExported(append([]exported{org}, append(refs, items...)...)))
+	return manifests[1:], nil
+}
+
+// exportOrganization reads the organization with the given name or ID.
+func exportOrganization(ctx context.Context, c *terrakube.Client, organization string) (exported, error) {
+	orgKind, ok := kinds["organization"]
+	if !ok {
+		return exported{}, fmt.Errorf("organization resources are not registered")
+	}
+	orgID := organization
+	if !IsUUID(orgID) {
+		id, err := orgKind.resolveName(ctx, c, nil, organization)
+		if err != nil {
+			return exported{}, err
+		}
+		orgID = id
+	}
+	orgs, err := orgKind.exportItems(ctx, c, nil)
+	if err != nil {
+		return exported{}, err
+	}
+	i := slices.IndexFunc(orgs, func(item exportItem) bool { return item.id == orgID })
+	if i < 0 {
+		return exported{}, fmt.Errorf("organization %q not found", organization)
+	}
+	return exported{kind: orgKind, item: orgs[i]}, nil
+}
+
+// exportRefs reads the resources listed under the parents of chain that the
+// Ref fields of items point to.
+func exportRefs(ctx context.Context, c *terrakube.Client, chain, ids []string, items []exported) ([]exported, error) {
+	wanted := make(map[string]map[string]bool)
+	for _, e := range items {
+		for _, f := range e.kind.fieldDefs() {
+			if id, ok := e.item.spec[f.Flag].(string); ok && f.Ref != "" {
+				if wanted[f.Ref] == nil {
+					wanted[f.Ref] = make(map[string]bool)
+				}
+				wanted[f.Ref][id] = true
+			}
+		}
+	}
+
+	var refs []exported
+	for _, k := range Kinds() {
+		if len(wanted[k.Name()]) == 0 {
+			continue
+		}
+		parentIDs, ok := listedUnder(k, chain, ids)
+		if !ok {
+			continue
+		}
+		list, err := k.exportItems(ctx, c, parentIDs)
+		if err != nil {
+			return nil, fmt.Errorf("export %s: %w", k.Name(), err)
+		}
+		for _, item := range list {
+			if wanted[k.Name()][item.id] {
+				refs = append(refs, exported{kind: k, item: item, parentIDs: parentIDs})
+			}
+		}
+	}
+	return refs, nil
+}
+
+// exportChildren appends the resources listed under the parents of chain,
+// with the given IDs, then those under each of them.
+func exportChildren(ctx context.Context, c *terrakube.Client, chain, ids []string, items *[]exported) error {
+	for _, k := range Kinds() {
+		if k.noExport() {
+			continue
+		}
+		parentIDs, ok := listedUnder(k, chain, ids)
+		if !ok {
+			continue
+		}
+		list, err := k.exportItems(ctx, c, parentIDs)
+		if err != nil {
+			return fmt.Errorf("export %s: %w", k.Name(), err)
+		}
+		for _, item := range list {
+			*items = append(*items, exported{kind: k, item: item, parentIDs: parentIDs})
+			if !hasChildren(k) {
+				continue
+			}
+			err := exportChildren(ctx, c, append(slices.Clip(chain), k.Name()), append(slices.Clip(ids), item.id), items)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// listedUnder reports whether k is listed under the parents of chain, and
+// returns the parent IDs to list it with. The parents of k that are not in
+// its list path must follow those that are. Trailing optional parents may
+// be missing from chain and are passed as "".
+func listedUnder(k Kind, chain, ids []string) ([]string, bool) {
+	var path []ParentScope
+	for _, p := range k.Parents() {
+		if p.Relation == "" {
+			path = append(path, p)
+		}
+	}
+	if len(path) < len(chain) {
+		return nil, false
+	}
+	for i, p := range path {
+		if i < len(chain) && p.Flag != chain[i] || i >= len(chain) && !p.Optional {
+			return nil, false
+		}
+	}
+	parentIDs := make([]string, len(k.Parents()))
+	copy(parentIDs, ids)
+	return parentIDs, true
+}
+
+// hasChildren reports whether a registered kind has k as a parent.
+func hasChildren(k Kind) bool {
+	for name, child := range kinds {
+		if child.Name() != name {
+			continue
+		}
+		if slices.ContainsFunc(child.Parents(), func(p ParentScope) bool { return p.Flag == k.Name() }) {
+			return true
+		}
+	}
+	return false
+}
+
+// dedupeExported drops resources read more than once, such as notification
+// configurations listed by organization and by workspace, keeping the last
+// read in the place of the first.
+func dedupeExported(items []exported) []exported {
+	seen := make(map[string]int, len(items))
+	var out []exported
+	for _, e := range items {
+		key := e.kind.Name() + "/" + e.item.id
+		if i, ok := seen[key]; ok {
+			out[i] = e
+			continue
+		}
+		seen[key] = len(out)
+		out = append(out, e)
+	}
+	return out
+}
+
+// exportManifests turns exported resources into manifests, replacing the
+// IDs of parents and Ref fields with identities when the resources they
+// point to were exported too.
+func exportManifests(items []exported) []Manifest {
+	names := make(map[string]map[string]string)
+	for _, e := range items {
+		if e.item.identity == "" {
+			continue
+		}
+		if names[e.kind.Name()] == nil {
+			names[e.kind.Name()] = make(map[string]string)
+		}
+		names[e.kind.Name()][e.item.id] = e.item.identity
+	}
+	name := func(kind, id string) string {
+		if n, ok := names[kind][id]; ok {
+			return n
+		}
+		return id
+	}
+
+	manifests := make([]Manifest, 0, len(items))
+	for _, e := range items {
+		m := Manifest{Kind: e.kind.Name(), Spec: e.item.spec}
+		for i, p := range e.kind.Parents() {
+			id := e.item.relations[p.Flag]
+			if p.Relation == "" && i < len(e.parentIDs) {
+				id = e.parentIDs[i]
+			}
+			if id == "" {
+				continue
+			}
+			if m.Parents == nil {
+				m.Parents = make(map[string]string)
+			}
+			m.Parents[p.Flag] = name(p.Flag, id)
+		}
+		for _, f := range e.kind.fieldDefs() {
+			if id, ok := m.Spec[f.Flag].(string); ok && f.Ref != "" && id != "" && id != SecretPlaceholder {
+				m.Spec[f.Flag] = name(f.Ref, id)
+			}
+		}
+		manifests = append(manifests, m)
+	}
+	return manifests
+}
+
+// exportItems implements Kind. Every field is exported, zero values
+// included, so that applying the export resets fields that drifted.
+// Sensitive values are exported as SecretPlaceholder, and left out when
+// empty unless required.
+func (k *kind[T]) exportItems(ctx context.Context, c *terrakube.Client, parentIDs []string) ([]exportItem, error) {
+	cfg := k.cfg
+	if cfg.List == nil {
+		return nil, nil
+	}
+	flag := k.identityFlag()
+	var items []exportItem
+	err := listPages(ctx, cfg, c, parentIDs, nil, paging{all: true}, func(page []*T) error {
+		for _, obj := range page {
+			item := exportItem{id: itemID(obj), spec: make(map[string]any)}
+			for _, f := range cfg.Fields {
+				v := fieldValue(obj, f)
+				switch {
+				case !isSecret(cfg.Fields, f, obj, nil):
+					item.spec[f.Flag] = v
+				case f.Required || !isZero(v):
+					item.spec[f.Flag] = SecretPlaceholder
+				}
+			}
+			if v, ok := item.spec[flag]; ok {
+				item.identity = fmt.Sprint(v)
+			}
+			for _, p := range cfg.Parents {
+				if p.Relation != "" {
+					if id := relationID(obj, p.Relation); id != "" {
+						if item.relations == nil {
+							item.relations = make(map[string]string)
+						}
+						item.relations[p.Flag] = id
+					}
+				}
+			}
+			items = append(items, item)
+		}
+		return nil
+	})
+	return items, err
+}
+
+func (k *kind[T]) noExport() bool        { return k.cfg.NoExport }
+func (k *kind[T]) fieldDefs() []FieldDef { return k.cfg.Fields }
+
+func (k *kind[T]) identityFlag() string {
+	flag, _ := k.cfg.identity()
+	return flag
+}
+
+// relationID returns the ID of the resource a relation field of obj points
+// to, or "" when it is not set.
+func relationID(obj any, field string) string {
+	v := reflect.Indirect(reflect.ValueOf(obj)).FieldByName(field)
+	if !v.IsValid() || v.Kind() == reflect.Pointer && v.IsNil() {
+		return ""
+	}
+	return itemID(v.Interface())
+}
+
+// WriteManifests writes each manifest to its own YAML file in dir, creating
+// dir if needed, and returns the paths written. Files are named after the
+// kind, the parents below the organization and the identity of the resource.
+func WriteManifests(dir string, manifests []Manifest) ([]string, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	used := make(map[string]bool, len(manifests))
+	paths := make([]string, 0, len(manifests))
+	for _, m := range manifests {
+		base := manifestFileName(m)
+		name := base + ".yaml"
+		for n := 2; used[name]; n++ {
+			name = fmt.Sprintf("%s-%d.yaml", base, n)
+		}
+		used[name] = true
+
+		b, err := yaml.Marshal(m)
+		if err != nil {
+			return nil, fmt.Errorf("%s/%s: %w", m.Kind, name, err)
+		}
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, b, 0o600); err != nil {
+			return nil, err
+		}
+		paths = append(paths, path)
+	}
+	return paths, nil
+}
+
+// manifestFileName is the file name of m without extension, such as
+// "variable--production-vpc--AWS_REGION".
+func manifestFileName(m Manifest) string {
+	parts := []string{m.Kind}
+	if k, ok := kinds[m.Kind]; ok {
+		for _, p := range k.Parents() {
+			if v := m.Parents[p.Flag]; v != "" && p.Flag != "organization" {
+				parts = append(parts, v)
+			}
+		}
+	}
+	ident := m.ID
+	if k, ok := kinds[m.Kind]; ok && ident == "" {
+		if flag := k.identityFlag(); flag != "" {
+			ident = fmt.Sprint(m.Spec[flag])
+		}
+	}
+	if ident != "" {
+		parts = append(parts, ident)
+	}
+	for i, p := range parts {
+		parts[i] = strings.Trim(strings.Map(func(r rune) rune {
+			if r == '.' || r == '_' || r == '-' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' {
+				return r
+			}
+			return '-'
+		}, p), "-.")
+	}
+	return strings.Join(parts, "--")
+}
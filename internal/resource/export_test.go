@@ -0,0 +1,151 @@
+package resource
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	terrakube "github.com/terrakube-io/terrakube-go"
+	"github.com/spf13/cobra"
+)
+
+// registerExportKinds replaces the registered kinds with an organization
+// holding a widget, with a sensitive description, and a gadget under the
+// widget that refers to another widget.
+func registerExportKinds(t *testing.T) {
+	t.Helper()
+	saved := kinds
+	kinds = map[string]Kind{}
+	t.Cleanup(func() { kinds = saved })
+
+	list := func(items ...*testResource) func(context.Context, *terrakube.Client, []string, *terrakube.ListOptions) ([]*testResource, error) {
+		return func(context.Context, *terrakube.Client, []string, *terrakube.ListOptions) ([]*testResource, error) {
+			return items, nil
+		}
+	}
+	secret, ref := "s3cret", "w-2"
+	org := parentScope("organization")
+
+	Register(&cobra.Command{Use: "test"}, Config[testResource]{
+		Name:   "organization",
+		Fields: []FieldDef{{StructField: "Name", Flag: "name", Type: String}},
+		List:   list(&testResource{ID: "other", Name: "other"}, &testResource{ID: orgID, Name: "acme"}),
+	})
+	Register(&cobra.Command{Use: "test"}, Config[testResource]{
+		Name:    "widget",
+		Parents: []ParentScope{org},
+		Fields: []FieldDef{
+			{StructField: "Name", Flag: "name", Type: String},
+			{StructField: "Desc", Flag: "description", Type: String, SensitiveIf: "flag"},
+			{StructField: "Flag", Flag: "flag", Type: Bool},
+		},
+		List: list(&testResource{ID: "w-1", Name: "first", Desc: &secret, Flag: true}, &testResource{ID: "w-2", Name: "second"}),
+	})
+	Register(&cobra.Command{Use: "test"}, Config[testResource]{
+		Name:    "gadget",
+		Parents: []ParentScope{org, parentScope("widget")},
+		Fields: []FieldDef{
+			{StructField: "Name", Flag: "name", Type: String},
+			{StructField: "Desc", Flag: "widget-id", Type: String, Ref: "widget"},
+		},
+		List: func(_ context.Context, _ *terrakube.Client, pIDs []string, _ *terrakube.ListOptions) ([]*testResource, error) {
+			if pIDs[1] != "w-1" {
+				return nil, nil
+			}
+			return []*testResource{{ID: "g-1", Name: "gear", Desc: &ref}}, nil
+		},
+	})
+	Register(&cobra.Command{Use: "test"}, Config[testResource]{
+		Name:     "run",
+		Parents:  []ParentScope{org},
+		NoExport: true,
+		List: func(context.Context, *terrakube.Client, []string, *terrakube.ListOptions) ([]*testResource, error) {
+			t.Error("expected runs not to be listed")
+			return nil, nil
+		},
+	})
+}
+
+// parentScope is a ParentScope named after its flag.
+func parentScope(flag string) ParentScope {
+	return ParentScope{Name: flag, Flag: flag}
+}
+
+func TestExport(t *testing.T) {
+	registerExportKinds(t)
+
+	manifests, err := Export(context.Background(), nil, orgID)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []Manifest{
+		{Kind: "organization", Spec: map[string]any{"name": "acme"}},
+		{Kind: "widget", Parents: map[string]string{"organization": "acme"},
+			Spec: map[string]any{"name": "first", "description": SecretPlaceholder, "flag": true}},
+		{Kind: "gadget", Parents: map[string]string{"organization": "acme", "widget": "first"},
+			Spec: map[string]any{"name": "gear", "widget-id": "second"}},
+		{Kind: "widget", Parents: map[string]string{"organization": "acme"},
+			Spec: map[string]any{"name": "second", "description": "", "flag": false}},
+	}
+	if !reflect.DeepEqual(manifests, want) {
+		t.Errorf("expected manifests:\n%+v\ngot:\n%+v", want, manifests)
+	}
+}
+
+func TestExport_UnknownOrganization(t *testing.T) {
+	registerExportKinds(t)
+
+	_, err := Export(context.Background(), nil, "f0e1d2c3-b4a5-9687-7869-5a4b3c2d1e0f")
+	if err == nil || err.Error() != `organization "f0e1d2c3-b4a5-9687-7869-5a4b3c2d1e0f" not found` {
+		t.Errorf("expected a not found error, got %v", err)
+	}
+}
+
+func TestWriteManifests(t *testing.T) {
+	registerExportKinds(t)
+	manifests, err := Export(context.Background(), nil, orgID)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	manifests = append(manifests, Manifest{Kind: "widget", Parents: map[string]string{"organization": "acme"}, Spec: map[string]any{"name": "first"}})
+
+	dir := filepath.Join(t.TempDir(), "out")
+	paths, err := WriteManifests(dir, manifests)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var names []string
+	for _, p := range paths {
+		names = append(names, filepath.Base(p))
+	}
+	wantNames := []string{"organization--acme.yaml", "widget--first.yaml", "gadget--first--gear.yaml", "widget--second.yaml", "widget--first-2.yaml"}
+	if !reflect.DeepEqual(names, wantNames) {
+		t.Errorf("expected files %v, got %v", wantNames, names)
+	}
+
+	b, err := os.ReadFile(filepath.Join(dir, "widget--first.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `kind: widget
+parents:
+    organization: acme
+spec:
+    description: <secret>
+    flag: true
+    name: first
+`
+	if string(b) != want {
+		t.Errorf("expected manifest:\n%s\ngot:\n%s", want, b)
+	}
+
+	read, err := ReadManifests([]string{dir})
+	if err != nil {
+		t.Fatalf("reading the manifests back: %v", err)
+	}
+	if len(read) != len(manifests) {
+		t.Errorf("expected %d manifests read back, got %d", len(manifests), len(read))
+	}
+}
@@ -0,0 +1,142 @@
+package resource
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Manifest declares one resource: its kind, the parents it lives under, by
+// name or ID, and a spec keyed by the flag names of the kind's fields.
+//
+//	kind: workspace
+//	parents:
+//	  organization: acme
+//	spec:
+//	  name: production-vpc
+//	  branch: main
+type Manifest struct {
+	Kind    string            `json:"kind" yaml:"kind"`
+	ID      string            `json:"id,omitempty" yaml:"id,omitempty"`
+	Parents map[string]string `json:"parents,omitempty" yaml:"parents,omitempty"`
+	Spec    map[string]any    `json:"spec" yaml:"spec"`
+
+	// Delete removes the resource instead of creating or updating it.
+	Delete bool `json:"delete,omitempty" yaml:"delete,omitempty"`
+
+	// Source locates the manifest in its file, for messages.
+	Source string `json:"-" yaml:"-"`
+}
+
+// Name returns the kind of m and the value of its identity field, such as
+// "variable/AWS_REGION", or its ID when set.
+func (m Manifest) Name() string {
+	if m.ID != "" {
+		return m.Kind + "/" + m.ID
+	}
+	if k, ok := kinds[m.Kind]; ok {
+		if flag := k.identityFlag(); flag != "" {
+			return fmt.Sprintf("%s/%v", m.Kind, m.Spec[flag])
+		}
+	}
+	return m.Kind
+}
+
+// manifestExts are the extensions of the files read from directories.
+var manifestExts = []string{".yaml", ".yml", ".json"}
+
+// ReadManifests reads the manifests in the given files and directories, "-"
+// being stdin. Directories are read one level deep, in name order.
+func ReadManifests(paths []string) ([]Manifest, error) {
+	var manifests []Manifest
+	for _, path := range paths {
+		files, err := manifestFiles(path)
+		if err != nil {
+			return nil, err
+		}
+		for _, file := range files {
+			ms, err := readManifestFile(file)
+			if err != nil {
+				return nil, err
+			}
+			manifests = append(manifests, ms...)
+		}
+	}
+	return manifests, nil
+}
+
+func manifestFiles(path string) ([]string, error) {
+	if path == "-" {
+		return []string{path}, nil
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return []string{path}, nil
+	}
+
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return nil, err
+	}
+	var files []string
+	for _, e := range entries {
+		if !e.IsDir() && slices.Contains(manifestExts, strings.ToLower(filepath.Ext(e.Name()))) {
+			files = append(files, filepath.Join(path, e.Name()))
+		}
+	}
+	return files, nil
+}
+
+func readManifestFile(path string) ([]Manifest, error) {
+	if path == "-" {
+		return DecodeManifests(os.Stdin, "stdin")
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+	return DecodeManifests(f, path)
+}
+
+// DecodeManifests reads the YAML documents, or a JSON object, in r. source
+// names r in messages.
+func DecodeManifests(r io.Reader, source string) ([]Manifest, error) {
+	dec := yaml.NewDecoder(r)
+	dec.KnownFields(true)
+
+	var manifests []Manifest
+	for doc := 1; ; doc++ {
+		var m Manifest
+		err := dec.Decode(&m)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		where := fmt.Sprintf("%s (document %d)", source, doc)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", where, err)
+		}
+		if m.Kind == "" && m.Spec == nil && m.Parents == nil && m.ID == "" {
+			continue
+		}
+		if m.Kind == "" {
+			return nil, fmt.Errorf("%s: missing kind", where)
+		}
+		m.Source = where
+		manifests = append(manifests, m)
+	}
+
+	if len(manifests) == 1 {
+		manifests[0].Source = source
+	}
+	return manifests, nil
+}
@@ -0,0 +1,88 @@
+package resource
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDecodeManifests(t *testing.T) {
+	in := `
+kind: organization
+spec:
+  name: acme
+---
+kind: workspace
+parents:
+  organization: acme
+spec:
+  name: vpc
+  execution-mode: remote
+`
+	ms, err := DecodeManifests(strings.NewReader(in), "all.yaml")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(ms) != 2 {
+		t.Fatalf("expected 2 manifests, got %d", len(ms))
+	}
+	if ms[1].Kind != "workspace" || ms[1].Parents["organization"] != "acme" || ms[1].Spec["execution-mode"] != "remote" {
+		t.Errorf("unexpected manifest: %+v", ms[1])
+	}
+	if ms[1].Source != "all.yaml (document 2)" {
+		t.Errorf("unexpected source %q", ms[1].Source)
+	}
+}
+
+func TestDecodeManifests_JSON(t *testing.T) {
+	ms, err := DecodeManifests(strings.NewReader(`{"kind": "team", "parents": {"organization": "acme"}, "spec": {"name": "ops"}}`), "team.json")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(ms) != 1 || ms[0].Spec["name"] != "ops" || ms[0].Source != "team.json" {
+		t.Errorf("unexpected manifests: %+v", ms)
+	}
+}
+
+func TestDecodeManifests_Errors(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"missing kind", "spec:\n  name: x\n", "all.yaml (document 1): missing kind"},
+		{"unknown key", "kind: team\nmetadata:\n  name: x\n", "field metadata not found"},
+		{"invalid yaml", "kind: [\n", "all.yaml (document 1)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := DecodeManifests(strings.NewReader(tt.in), "all.yaml")
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("expected error containing %q, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestReadManifests_Directory(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"b.yml":     "kind: team\nspec:\n  name: b\n",
+		"a.json":    `{"kind": "team", "spec": {"name": "a"}}`,
+		"notes.txt": "not a manifest",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ms, err := ReadManifests([]string{dir})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(ms) != 2 || ms[0].Spec["name"] != "a" || ms[1].Spec["name"] != "b" {
+		t.Errorf("expected a.json then b.yml, got %+v", ms)
+	}
+}
@@ -0,0 +1,141 @@
+package resource
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"reflect"
+	"strconv"
+
+	terrakube "github.com/terrakube-io/terrakube-go"
+	"github.com/spf13/cobra"
+
+	"terrakube/internal/transport"
+)
+
+// DefaultPageSize is the page size used by --all and --limit when
+// --page-size is not given.
+const DefaultPageSize = 100
+
+// paging holds the --all, --page-size and --limit flags of a list command.
+type paging struct {
+	all   bool
+	size  int
+	limit int
+}
+
+func addPagingFlags(cmd *cobra.Command) {
+	cmd.Flags().Bool("all", false, "Fetch every page of results")
+	cmd.Flags().Int("page-size", 0, fmt.Sprintf("Results per page (default: server default, or %d with --all and --limit)", DefaultPageSize))
+	cmd.Flags().Int("limit", 0, "Stop after this many results, following pages as needed")
+}
+
+func pagingFromFlags(cmd *cobra.Command) (paging, error) {
+	var p paging
+	p.all, _ = cmd.Flags().GetBool("all")
+	p.size, _ = cmd.Flags().GetInt("page-size")
+	p.limit, _ = cmd.Flags().GetInt("limit")
+	if p.size < 0 {
+		return p, fmt.Errorf("--page-size must not be negative")
+	}
+	if p.limit < 0 {
+		return p, fmt.Errorf("--limit must not be negative")
+	}
+	return p, nil
+}
+
+// listPages calls list for each page selected by p and passes the results
+// to emit as they arrive. Without paging flags list is called once, with the
+// server's default page.
+//
+// Pages are followed by the links.next of each response. When the server
+// sends no pagination links, a page shorter or longer than requested is
+// taken to be the last.
+func listPages[T any](ctx context.Context, cfg Config[T], client *terrakube.Client, parentIDs []string,
+	opts *terrakube.ListOptions, p paging, emit func([]*T) error) error {
+	if !p.all && p.size == 0 && p.limit == 0 {
+		items, err := cfg.List(ctx, client, parentIDs, opts)
+		if err != nil {
+			return err
+		}
+		return emit(items)
+	}
+
+	size := p.size
+	if size == 0 {
+		size = DefaultPageSize
+		if p.limit > 0 && p.limit < size {
+			size = p.limit
+		}
+	}
+	follow := p.all || p.limit > 0
+
+	remaining := p.limit
+	var previousFirst string
+	query := pageQuery(1, size)
+	for page := 1; ; page++ {
+		var links transport.PageLinks
+		pageCtx := transport.WithPageLinks(transport.WithQuery(ctx, query), &links)
+		items, err := cfg.List(pageCtx, client, parentIDs, opts)
+		if err != nil {
+			return err
+		}
+
+		// A server that ignores paging returns the same results for every
+		// page; stop instead of looping forever.
+		if page > 1 && len(items) > 0 && itemID(items[0]) == previousFirst {
+			return nil
+		}
+		fetched := len(items)
+		if fetched > 0 {
+			previousFirst = itemID(items[0])
+		}
+
+		if p.limit > 0 && len(items) > remaining {
+			items = items[:remaining]
+		}
+		if err := emit(items); err != nil {
+			return err
+		}
+		remaining -= len(items)
+
+		if !follow || (p.limit > 0 && remaining == 0) {
+			return nil
+		}
+		switch {
+		case links.Present && links.Next == "":
+			return nil
+		case links.Present:
+			next, err := url.Parse(links.Next)
+			if err != nil {
+				return fmt.Errorf("invalid next page link %q: %w", links.Next, err)
+			}
+			query = next.Query()
+		case fetched != size:
+			return nil
+		default:
+			query = pageQuery(page+1, size)
+		}
+	}
+}
+
+// pageQuery returns the query parameters selecting a page.
+func pageQuery(number, size int) url.Values {
+	return url.Values{
+		"page[number]": {strconv.Itoa(number)},
+		"page[size]":   {strconv.Itoa(size)},
+	}
+}
+
+// itemID returns the ID field of a resource, or "" when it has none.
+func itemID(item any) string {
+	v := reflect.Indirect(reflect.ValueOf(item))
+	if v.Kind() != reflect.Struct {
+		return ""
+	}
+	id := v.FieldByName("ID")
+	if !id.IsValid() || id.Kind() != reflect.String {
+		return ""
+	}
+	return id.String()
+}
@@ -0,0 +1,145 @@
+package resource
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"testing"
+
+	terrakube "github.com/terrakube-io/terrakube-go"
+
+	"terrakube/internal/transport"
+)
+
+// pagedConfig serves total widgets, honoring page[number] and page[size]
+// like the API, and counts the calls.
+func pagedConfig(total int, calls *int) Config[testResource] {
+	cfg := testConfig()
+	cfg.List = func(ctx context.Context, _ *terrakube.Client, _ []string, _ *terrakube.ListOptions) ([]*testResource, error) {
+		*calls++
+		q := transport.QueryFromContext(ctx)
+		number, _ := strconv.Atoi(q.Get("page[number]"))
+		size, _ := strconv.Atoi(q.Get("page[size]"))
+		if size == 0 {
+			number, size = 1, 3 // server default page
+		}
+		var items []*testResource
+		for i := (number - 1) * size; i < number*size && i < total; i++ {
+			items = append(items, &testResource{ID: fmt.Sprintf("w-%d", i)})
+		}
+		return items, nil
+	}
+	return cfg
+}
+
+func collectPages(t *testing.T, cfg Config[testResource], p paging) ([]*testResource, int) {
+	t.Helper()
+	var all []*testResource
+	pages := 0
+	err := listPages(context.Background(), cfg, nil, nil, nil, p, func(items []*testResource) error {
+		pages++
+		all = append(all, items...)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return all, pages
+}
+
+func TestListPages(t *testing.T) {
+	tests := []struct {
+		name      string
+		total     int
+		p         paging
+		wantItems int
+		wantCalls int
+	}{
+		{"server default page", 10, paging{}, 3, 1},
+		{"single page of page-size", 10, paging{size: 4}, 4, 1},
+		{"all pages", 10, paging{all: true, size: 4}, 10, 3},
+		{"all with exact last page", 8, paging{all: true, size: 4}, 8, 3},
+		{"all with default page size", 250, paging{all: true}, 250, 3},
+		{"limit stops early", 10, paging{size: 4, limit: 6}, 6, 2},
+		{"limit without page-size", 10, paging{limit: 5}, 5, 1},
+		{"all and limit", 10, paging{all: true, size: 2, limit: 3}, 3, 2},
+		{"limit beyond total", 5, paging{limit: 50}, 5, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			items, _ := collectPages(t, pagedConfig(tt.total, &calls), tt.p)
+			if len(items) != tt.wantItems {
+				t.Errorf("expected %d items, got %d", tt.wantItems, len(items))
+			}
+			if calls != tt.wantCalls {
+				t.Errorf("expected %d calls, got %d", tt.wantCalls, calls)
+			}
+		})
+	}
+}
+
+func TestListPages_ServerIgnoresPaging(t *testing.T) {
+	cfg := testConfig()
+	calls := 0
+	cfg.List = func(_ context.Context, _ *terrakube.Client, _ []string, _ *terrakube.ListOptions) ([]*testResource, error) {
+		calls++
+		return []*testResource{{ID: "a"}, {ID: "b"}}, nil
+	}
+
+	items, _ := collectPages(t, cfg, paging{all: true, size: 2})
+	if len(items) != 2 || calls != 2 {
+		t.Errorf("expected to stop on a repeated page, got %d items in %d calls", len(items), calls)
+	}
+}
+
+// linkedConfig serves total widgets in pages of at most maxSize, whatever
+// the page size requested, with JSON:API next links.
+func linkedConfig(total, maxSize int, calls *int) Config[testResource] {
+	cfg := testConfig()
+	cfg.List = func(ctx context.Context, _ *terrakube.Client, _ []string, _ *terrakube.ListOptions) ([]*testResource, error) {
+		*calls++
+		q := transport.QueryFromContext(ctx)
+		number, _ := strconv.Atoi(q.Get("page[number]"))
+		size, _ := strconv.Atoi(q.Get("page[size]"))
+		size = min(size, maxSize)
+		var items []*testResource
+		for i := (number - 1) * size; i < number*size && i < total; i++ {
+			items = append(items, &testResource{ID: fmt.Sprintf("w-%d", i)})
+		}
+
+		links := transport.PageLinksFromContext(ctx)
+		*links = transport.PageLinks{Present: true}
+		if number*size < total {
+			links.Next = fmt.Sprintf("https://terrakube.example.com/api/v1/widget?page[number]=%d&page[size]=%d", number+1, size)
+		}
+		return items, nil
+	}
+	return cfg
+}
+
+func TestListPages_FollowsNextLinks(t *testing.T) {
+	tests := []struct {
+		name      string
+		total     int
+		p         paging
+		wantItems int
+		wantCalls int
+	}{
+		{"server caps the page size", 10, paging{all: true, size: 4}, 10, 5},
+		{"full last page without next", 8, paging{all: true, size: 2}, 8, 4},
+		{"limit across capped pages", 10, paging{limit: 5}, 5, 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			items, _ := collectPages(t, linkedConfig(tt.total, 2, &calls), tt.p)
+			if len(items) != tt.wantItems {
+				t.Errorf("expected %d items, got %d", tt.wantItems, len(items))
+			}
+			if calls != tt.wantCalls {
+				t.Errorf("expected %d calls, got %d", tt.wantCalls, calls)
+			}
+		})
+	}
+}
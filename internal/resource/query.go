@@ -0,0 +1,102 @@
+package resource
+
+import (
+	"fmt"
+	"net/url"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// jsonapiSchema describes the JSON:API type of a resource struct.
+type jsonapiSchema struct {
+	Type      string
+	Attrs     map[string]bool
+	Relations map[string]bool
+}
+
+// schemaOf reads the jsonapi tags of T.
+func schemaOf[T any]() jsonapiSchema {
+	s := jsonapiSchema{Attrs: map[string]bool{}, Relations: map[string]bool{}}
+	t := reflect.TypeOf((*T)(nil)).Elem()
+	for i := 0; i < t.NumField(); i++ {
+		parts := strings.Split(t.Field(i).Tag.Get("jsonapi"), ",")
+		if len(parts) < 2 {
+			continue
+		}
+		switch parts[0] {
+		case "primary":
+			s.Type = parts[1]
+		case "attr":
+			s.Attrs[parts[1]] = true
+		case "relation":
+			s.Relations[parts[1]] = true
+		}
+	}
+	return s
+}
+
+func (s jsonapiSchema) attrNames() string {
+	names := make([]string, 0, len(s.Attrs))
+	for name := range s.Attrs {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return strings.Join(names, ", ")
+}
+
+func addSortFlag(cmd *cobra.Command) {
+	cmd.Flags().String("sort", "", "Comma-separated attributes to sort by, prefix with - for descending (e.g. -createdDate,name)")
+}
+
+func addFieldsFlag(cmd *cobra.Command) {
+	cmd.Flags().String("fields", "", "Comma-separated attributes to return and show besides the ID (e.g. name,description)")
+}
+
+// queryFromFlags validates --sort and --fields against the jsonapi tags of
+// T and returns the matching JSON:API query parameters and selected fields.
+// Commands without one of the flags ignore it.
+func queryFromFlags[T any](cmd *cobra.Command, name string) (url.Values, []string, error) {
+	schema := schemaOf[T]()
+	q := url.Values{}
+
+	if sort, _ := cmd.Flags().GetString("sort"); sort != "" {
+		for _, key := range strings.Split(sort, ",") {
+			attr := strings.TrimPrefix(strings.TrimPrefix(strings.TrimSpace(key), "-"), "+")
+			if !schema.validSortKey(attr) {
+				return nil, nil, fmt.Errorf("invalid --sort %q: %s has no attribute %q, expected one of: %s",
+					sort, name, attr, schema.attrNames())
+			}
+		}
+		q.Set("sort", strings.ReplaceAll(sort, " ", ""))
+	}
+
+	var fields []string
+	if list, _ := cmd.Flags().GetString("fields"); list != "" {
+		for _, f := range strings.Split(list, ",") {
+			fields = append(fields, strings.TrimSpace(f))
+		}
+	}
+	for _, f := range fields {
+		if !schema.Attrs[f] && !schema.Relations[f] {
+			return nil, nil, fmt.Errorf("invalid --fields: %s has no attribute %q, expected one of: %s",
+				name, f, schema.attrNames())
+		}
+	}
+	if len(fields) > 0 && schema.Type != "" {
+		q.Set("fields["+schema.Type+"]", strings.Join(fields, ","))
+	}
+	return q, fields, nil
+}
+
+// validSortKey accepts "id", an attribute, or a path through a relation
+// such as organization.name, whose remainder the server checks.
+func (s jsonapiSchema) validSortKey(key string) bool {
+	if key == "id" || s.Attrs[key] {
+		return true
+	}
+	relation, _, ok := strings.Cut(key, ".")
+	return ok && s.Relations[relation]
+}
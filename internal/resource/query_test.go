@@ -0,0 +1,84 @@
+package resource
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+type sortableResource struct {
+	ID          string            `jsonapi:"primary,widget"`
+	Name        string            `jsonapi:"attr,name"`
+	CreatedDate string            `jsonapi:"attr,createdDate"`
+	Owner       *sortableResource `jsonapi:"relation,owner"`
+}
+
+func queryCmd(t *testing.T, args ...string) *cobra.Command {
+	t.Helper()
+	cmd := &cobra.Command{Use: "list"}
+	addSortFlag(cmd)
+	addFieldsFlag(cmd)
+	if err := cmd.ParseFlags(args); err != nil {
+		t.Fatalf("parsing flags: %v", err)
+	}
+	return cmd
+}
+
+func TestSchemaOf(t *testing.T) {
+	s := schemaOf[sortableResource]()
+	if s.Type != "widget" || !s.Attrs["name"] || !s.Attrs["createdDate"] || !s.Relations["owner"] {
+		t.Errorf("unexpected schema %+v", s)
+	}
+}
+
+func TestQueryFromFlags(t *testing.T) {
+	cmd := queryCmd(t, "--sort", "-createdDate,name", "--fields", "name,owner")
+	q, fields, err := queryFromFlags[sortableResource](cmd, "widget")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if q.Get("sort") != "-createdDate,name" {
+		t.Errorf("unexpected sort %q", q.Get("sort"))
+	}
+	if q.Get("fields[widget]") != "name,owner" {
+		t.Errorf("unexpected fields %q", q.Get("fields[widget]"))
+	}
+	if strings.Join(fields, ",") != "name,owner" {
+		t.Errorf("unexpected selected fields %v", fields)
+	}
+}
+
+func TestQueryFromFlags_RelationPathAndID(t *testing.T) {
+	cmd := queryCmd(t, "--sort", "owner.name,-id")
+	if _, _, err := queryFromFlags[sortableResource](cmd, "widget"); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestQueryFromFlags_Invalid(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		want string
+	}{
+		{"unknown sort attribute", []string{"--sort", "-createdDte"}, `widget has no attribute "createdDte", expected one of: createdDate, name`},
+		{"unknown relation path", []string{"--sort", "team.name"}, `no attribute "team.name"`},
+		{"unknown field", []string{"--fields", "name,nmae"}, `invalid --fields: widget has no attribute "nmae"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := queryFromFlags[sortableResource](queryCmd(t, tt.args...), "widget")
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("expected error containing %q, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestQueryFromFlags_NoFlags(t *testing.T) {
+	q, fields, err := queryFromFlags[sortableResource](&cobra.Command{Use: "get"}, "widget")
+	if err != nil || len(q) != 0 || fields != nil {
+		t.Errorf("expected an empty query, got %v %v %v", q, fields, err)
+	}
+}
@@ -0,0 +1,93 @@
+package resource
+
+import (
+	"context"
+	"slices"
+	"strings"
+
+	terrakube "github.com/terrakube-io/terrakube-go"
+)
+
+// Kind is a registered resource type with its Go type erased, so that
+// manifests can drive any of them.
+type Kind interface {
+	Name() string
+	Parents() []ParentScope
+
+	// Plan works out what applying m would change on the server.
+	Plan(ctx context.Context, c *terrakube.Client, m Manifest) (*Change, error)
+
+	// Execute creates, updates or deletes the resource of a planned change and
+	// returns its ID.
+	Execute(ctx context.Context, c *terrakube.Client, ch *Change) (string, error)
+
+	planPending(m Manifest) (*Change, error)
+	atomicOperation(ctx context.Context, c *terrakube.Client, m Manifest, lids map[string]string, lid string) (*Change, *terrakube.AtomicOperation, error)
+	collection() string
+	schema() jsonapiSchema
+	resolveName(ctx context.Context, c *terrakube.Client, parentIDs []string, name string) (string, error)
+	exportItems(ctx context.Context, c *terrakube.Client, parentIDs []string) ([]exportItem, error)
+	noExport() bool
+	fieldDefs() []FieldDef
+	identityFlag() string
+}
+
+// kinds holds every resource passed to Register, by name and alias.
+var kinds = map[string]Kind{}
+
+func registerKind[T any](cfg Config[T]) {
+	k := &kind[T]{cfg: cfg}
+	kinds[cfg.Name] = k
+	for _, a := range cfg.Aliases {
+		kinds[a] = k
+	}
+}
+
+// LookupKind returns the registered resource with the given name or alias.
+func LookupKind(name string) (Kind, bool) {
+	k, ok := kinds[name]
+	return k, ok
+}
+
+// Kinds returns the registered resources, parents before their children.
+func Kinds() []Kind {
+	var list []Kind
+	for name, k := range kinds {
+		if k.Name() == name {
+			list = append(list, k)
+		}
+	}
+	slices.SortFunc(list, func(a, b Kind) int {
+		if d := kindDepth(a) - kindDepth(b); d != 0 {
+			return d
+		}
+		return strings.Compare(a.Name(), b.Name())
+	})
+	return list
+}
+
+// kindDepth is the number of levels of parents above k: 0 for
+// organizations, 1 for workspaces, 2 for variables.
+func kindDepth(k Kind) int {
+	return depthOf(k, len(kinds))
+}
+
+func depthOf(k Kind, limit int) int {
+	depth := 0
+	for _, p := range k.Parents() {
+		d := 1
+		if parent, ok := kinds[p.Flag]; ok && parent != k && limit > 0 {
+			d = depthOf(parent, limit-1) + 1
+		}
+		depth = max(depth, d)
+	}
+	return depth
+}
+
+// kind adapts a Config to the Kind interface.
+type kind[T any] struct {
+	cfg Config[T]
+}
+
+func (k *kind[T]) Name() string           { return k.cfg.Name }
+func (k *kind[T]) Parents() []ParentScope { return k.cfg.Parents }
@@ -3,6 +3,8 @@ package resource
 import (
 	"context"
 	"fmt"
+	"strings"
+	"sync"
 
 	"github.com/google/uuid"
 	terrakube "github.com/terrakube-io/terrakube-go"
@@ -28,11 +30,17 @@ func resolveParents(ctx context.Context, client *terrakube.Client, cmd *cobra.Co
 
 func resolveParent(ctx context.Context, client *terrakube.Client, cmd *cobra.Command, p ParentScope, resolvedIDs []string) (string, error) {
 	val, _ := cmd.Flags().GetString(p.Flag)
+	return resolveScope(ctx, client, p, val, resolvedIDs, "--"+p.Flag)
+}
+
+// resolveScope resolves the value of a parent, given by the flag or manifest
+// key named by source.
+func resolveScope(ctx context.Context, client *terrakube.Client, p ParentScope, val string, resolvedIDs []string, source string) (string, error) {
 	if val == "" {
 		if p.Optional {
 			return "", nil
 		}
-		return "", fmt.Errorf("--%s is required", p.Flag)
+		return "", fmt.Errorf("%s is required", source)
 	}
 
 	if p.RawID || IsUUID(val) {
@@ -40,12 +48,102 @@ func resolveParent(ctx context.Context, client *terrakube.Client, cmd *cobra.Com
 	}
 
 	if p.Resolver == nil {
-		return "", fmt.Errorf("--%s: %q is not a valid UUID and name resolution is not configured for %s", p.Flag, val, p.Name)
+		return "", fmt.Errorf("%s: %q is not a valid UUID and name resolution is not configured for %s", source, val, p.Name)
 	}
 
 	return p.Resolver(ctx, client, resolvedIDs, val)
 }
 
+// ResolutionCache keeps name to ID resolutions between runs. Put and
+// Invalidate failures only cost a lookup, so they are not reported.
+type ResolutionCache interface {
+	Get(typ, scope, name string) (string, bool)
+	Put(typ, scope, name, id string)
+	Invalidate(typ string)
+}
+
+// Cache, when set, is consulted by NameResolver before listing, and the
+// entries of a type are dropped when create, update or delete change it.
+var Cache ResolutionCache
+
+// ResolverFunc resolves a name to an ID, given the IDs of the parents
+// resolved before it.
+type ResolverFunc func(ctx context.Context, c *terrakube.Client, resolvedParentIDs []string, name string) (string, error)
+
+// NotFoundError is returned by a NameResolver when no resource matches.
+type NotFoundError struct {
+	Kind  string
+	Attr  string
+	Value string
+}
+
+func (e *NotFoundError) Error() string {
+	return fmt.Sprintf("no %s found with %s %q", e.Kind, e.Attr, e.Value)
+}
+
+// ListFunc lists resources under the given parents.
+type ListFunc[T any] func(ctx context.Context, c *terrakube.Client, parentIDs []string, opts *terrakube.ListOptions) ([]*T, error)
+
+// NameResolver returns a resolver that looks up a noun by the attr attribute
+// with list. flag is the flag suggested when several resources match, none
+// for positional arguments. Results are memoized per client, so a command
+// resolving the same name twice only lists once, and kept in Cache if set.
+func NameResolver[T any](noun, flag, attr string, list ListFunc[T]) ResolverFunc {
+	type key struct {
+		client  *terrakube.Client
+		parents string
+		value   string
+	}
+	var mu sync.Mutex
+	memo := make(map[key]string)
+
+	return func(ctx context.Context, c *terrakube.Client, resolvedParentIDs []string, value string) (string, error) {
+		k := key{c, strings.Join(resolvedParentIDs, "/"), value}
+		mu.Lock()
+		id, ok := memo[k]
+		mu.Unlock()
+		if ok {
+			return id, nil
+		}
+		if Cache != nil {
+			if id, ok := Cache.Get(noun, k.parents, value); ok {
+				return id, nil
+			}
+		}
+
+		items, err := list(ctx, c, resolvedParentIDs, &terrakube.ListOptions{Filter: attr + "==" + quoteRSQL(value)})
+		if err != nil {
+			return "", err
+		}
+		if len(items) == 0 {
+			return "", &NotFoundError{Kind: noun, Attr: attr, Value: value}
+		}
+		if len(items) > 1 {
+			hint := "use the ID instead"
+			if flag != "" {
+				hint = fmt.Sprintf("use --%s with the ID", flag)
+			}
+			return "", fmt.Errorf("multiple %ss match %s %q, %s", noun, attr, value, hint)
+		}
+
+		id = itemID(items[0])
+		mu.Lock()
+		memo[k] = id
+		mu.Unlock()
+		if Cache != nil {
+			Cache.Put(noun, k.parents, value, id)
+		}
+		return id, nil
+	}
+}
+
+// invalidateCache drops the cached resolutions of a type after a change.
+func invalidateCache(typ string) {
+	if Cache != nil {
+		Cache.Invalidate(typ)
+	}
+}
+
 // IsUUID returns true if s is a valid UUID.
 func IsUUID(s string) bool {
 	_, err := uuid.Parse(s)
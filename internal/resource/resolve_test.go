@@ -227,3 +227,43 @@ func TestResolveParents_RawIDSkipsResolver(t *testing.T) {
 		t.Error("resolver should not be called for RawID parent")
 	}
 }
+
+func TestNameResolver(t *testing.T) {
+	var calls []string
+	resolve := NameResolver("widget", "widget", "path", func(_ context.Context, _ *terrakube.Client, parentIDs []string, opts *terrakube.ListOptions) ([]*testResource, error) {
+		calls = append(calls, fmt.Sprint(parentIDs, " ", opts.Filter))
+		switch opts.Filter {
+		case "path==/one":
+			return []*testResource{{ID: "w-1"}}, nil
+		case "path==/dup":
+			return []*testResource{{ID: "w-1"}, {ID: "w-2"}}, nil
+		}
+		return nil, nil
+	})
+
+	for range 2 {
+		id, err := resolve(context.Background(), nil, []string{"org-1"}, "/one")
+		if err != nil || id != "w-1" {
+			t.Fatalf("expected w-1, got %q, %v", id, err)
+		}
+	}
+	if len(calls) != 1 || calls[0] != "[org-1] path==/one" {
+		t.Errorf("expected a single memoized lookup, got %v", calls)
+	}
+
+	if _, err := resolve(context.Background(), nil, []string{"org-2"}, "/one"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(calls) != 2 {
+		t.Errorf("expected other parents to be looked up again, got %v", calls)
+	}
+
+	_, err := resolve(context.Background(), nil, nil, "/none")
+	if err == nil || err.Error() != `no widget found with path "/none"` {
+		t.Errorf("unexpected error: %v", err)
+	}
+	_, err = resolve(context.Background(), nil, nil, "/dup")
+	if err == nil || err.Error() != `multiple widgets match path "/dup", use --widget with the ID` {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
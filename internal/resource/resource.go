@@ -5,12 +5,14 @@ import (
 	"fmt"
 	"os"
 	"reflect"
+	"strings"
 
 	terrakube "github.com/terrakube-io/terrakube-go"
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
 
 	"terrakube/internal/output"
+	"terrakube/internal/transport"
 )
 
 // FieldType represents the type of a CLI flag.
@@ -32,7 +34,8 @@ type ParentScope struct {
 	IDFlag    string   // hidden backwards-compat alias, e.g. "organization-id"
 	RawID     bool     // parent IDs are not UUIDs (e.g. numeric job IDs); use the flag value as the ID directly, no name resolution
 	Optional  bool     // parent is optional (e.g. workspace on notification-configuration)
-	Resolver  func(ctx context.Context, c *terrakube.Client, resolvedParentIDs []string, name string) (string, error)
+	Relation  string   // struct field holding the parent when it is not part of the list path (e.g. "Workspace" on collection-reference)
+	Resolver  ResolverFunc
 }
 
 // FieldDef maps a CLI flag to a struct field.
@@ -43,11 +46,29 @@ type FieldDef struct {
 	Type        FieldType
 	Required    bool
 	Description string
+
+	// Sensitive fields, such as secrets and private keys, are masked in
+	// diffs and exported as SecretPlaceholder.
+	Sensitive bool
+
+	// SensitiveIf is the flag of a Bool field that makes this field
+	// sensitive on the resources where it is true, such as "sensitive" for
+	// the value of a variable.
+	SensitiveIf string
+
+	// Ref is the kind whose ID the field holds, such as "tag". Export writes
+	// the identity of the referenced resource and apply resolves it back.
+	// The struct field may be a relation, such as the VCS of a workspace.
+	Ref string
+
+	// ManifestOnly fields are read and written by manifests, export and
+	// copy, but have no flag on the create and update commands.
+	ManifestOnly bool
 }
 
 // Runtime provides access to CLI infrastructure.
 type Runtime struct {
-	NewClient  func() *terrakube.Client
+	NewClient  func() (*terrakube.Client, error)
 	GetContext func() context.Context
 	GetOutput  func() string
 }
@@ -60,6 +81,24 @@ type Config[T any] struct {
 	Parents []ParentScope
 	Fields  []FieldDef
 
+	// Identity is the flag of the field that identifies a resource among its
+	// siblings, such as "key" for variables. It defaults to "name".
+	Identity string
+
+	// Collection is the relationship that holds the resource under its
+	// parent in API paths, such as "globalvar" for organization variables.
+	// It defaults to the JSON:API type of T.
+	Collection string
+
+	// NoExport leaves the resource out of export, for records such as jobs
+	// that are not configuration.
+	NoExport bool
+
+	// Resolver resolves a name given as the positional argument of get,
+	// update and delete to an ID. When nil, resources are looked up with List
+	// by their Identity field.
+	Resolver ResolverFunc
+
 	List   func(ctx context.Context, c *terrakube.Client, parentIDs []string, opts *terrakube.ListOptions) ([]*T, error)
 	Get    func(ctx context.Context, c *terrakube.Client, parentIDs []string, id string) (*T, error)
 	Create func(ctx context.Context, c *terrakube.Client, parentIDs []string, resource *T) (*T, error)
@@ -67,30 +106,40 @@ type Config[T any] struct {
 	Delete func(ctx context.Context, c *terrakube.Client, parentIDs []string, id string) error
 }
 
-// Register creates Cobra commands for the resource and adds them to root.
+// Register creates Cobra commands for the resource and adds them to root. It
+// also registers the resource as a Kind for manifests.
 func Register[T any](root *cobra.Command, cfg Config[T]) *cobra.Command {
 	parentCmd := &cobra.Command{
-		Use:     cfg.Name + " list|get|create|update|delete [FLAGS]",
 		Short:   fmt.Sprintf("manage %s resources", cfg.Name),
 		Aliases: cfg.Aliases,
 	}
-	root.AddCommand(parentCmd)
+	registerKind(cfg)
 
+	var verbs []string
+	add := func(cmd *cobra.Command) {
+		parentCmd.AddCommand(cmd)
+		verbs = append(verbs, cmd.Name())
+	}
 	if cfg.List != nil {
-		parentCmd.AddCommand(newListCmd(cfg))
+		add(newListCmd(cfg))
 	}
 	if cfg.Get != nil {
-		parentCmd.AddCommand(newGetCmd(cfg))
+		add(newGetCmd(cfg))
 	}
 	if cfg.Create != nil {
-		parentCmd.AddCommand(newCreateCmd(cfg))
+		add(newCreateCmd(cfg))
 	}
 	if cfg.Update != nil {
-		parentCmd.AddCommand(newUpdateCmd(cfg))
+		add(newUpdateCmd(cfg))
 	}
 	if cfg.Delete != nil {
-		parentCmd.AddCommand(newDeleteCmd(cfg))
+		add(newDeleteCmd(cfg))
+	}
+	if cfg.Get != nil && cfg.Update != nil {
+		add(newEditCmd(cfg))
 	}
+	parentCmd.Use = cfg.Name + " " + strings.Join(verbs, "|") + " [FLAGS]"
+	root.AddCommand(parentCmd)
 	return parentCmd
 }
 
@@ -100,7 +149,18 @@ func newListCmd[T any](cfg Config[T]) *cobra.Command {
 		Short:        fmt.Sprintf("list %s resources", cfg.Name),
 		SilenceUsage: true,
 		RunE: func(cmd *cobra.Command, _ []string) error {
-			client := cfg.NewClient()
+			query, fields, err := queryFromFlags[T](cmd, cfg.Name)
+			if err != nil {
+				return err
+			}
+			filter, err := filterFromFlags[T](cmd, cfg.Name, cfg.Fields)
+			if err != nil {
+				return err
+			}
+			client, err := cfg.NewClient()
+			if err != nil {
+				return err
+			}
 			ctx := cfg.GetContext()
 
 			parentIDs, err := resolveParents(ctx, client, cmd, cfg.Parents)
@@ -109,22 +169,31 @@ func newListCmd[T any](cfg Config[T]) *cobra.Command {
 			}
 
 			var opts *terrakube.ListOptions
-			filter, _ := cmd.Flags().GetString("filter")
 			if filter != "" {
 				opts = &terrakube.ListOptions{Filter: filter}
 			}
 
-			result, err := cfg.List(ctx, client, parentIDs, opts)
+			p, err := pagingFromFlags(cmd)
 			if err != nil {
 				return err
 			}
-
-			return output.Render(os.Stdout, result, cfg.GetOutput())
+			stream := output.NewStream(os.Stdout, cfg.GetOutput(), fields)
+			err = listPages(transport.WithQuery(ctx, query), cfg, client, parentIDs, opts, p, func(items []*T) error {
+				return stream.Add(items)
+			})
+			if err != nil {
+				return err
+			}
+			return stream.Close()
 		},
 	}
 
 	addParentFlags(cmd, cfg.Parents)
 	cmd.Flags().String("filter", "", "RSQL filter expression")
+	addWhereFlag(cmd)
+	addSortFlag(cmd)
+	addFieldsFlag(cmd)
+	addPagingFlags(cmd)
 	return cmd
 }
 
@@ -133,8 +202,19 @@ func newGetCmd[T any](cfg Config[T]) *cobra.Command {
 		Use:          "get",
 		Short:        fmt.Sprintf("get a %s resource", cfg.Name),
 		SilenceUsage: true,
-		RunE: func(cmd *cobra.Command, _ []string) error {
-			client := cfg.NewClient()
+		RunE: func(cmd *cobra.Command, args []string) error {
+			target, positional, err := targetArg(cmd, args, cfg.Name)
+			if err != nil {
+				return err
+			}
+			query, fields, err := queryFromFlags[T](cmd, cfg.Name)
+			if err != nil {
+				return err
+			}
+			client, err := cfg.NewClient()
+			if err != nil {
+				return err
+			}
 			ctx := cfg.GetContext()
 
 			parentIDs, err := resolveParents(ctx, client, cmd, cfg.Parents)
@@ -142,19 +222,22 @@ func newGetCmd[T any](cfg Config[T]) *cobra.Command {
 				return err
 			}
 
-			id, _ := cmd.Flags().GetString("id")
-			result, err := cfg.Get(ctx, client, parentIDs, id)
+			id, err := resolveTarget(ctx, cfg, client, parentIDs, target, positional)
+			if err != nil {
+				return err
+			}
+			result, err := cfg.Get(transport.WithQuery(ctx, query), client, parentIDs, id)
 			if err != nil {
 				return err
 			}
 
-			return output.Render(os.Stdout, result, cfg.GetOutput())
+			return output.RenderFields(os.Stdout, result, cfg.GetOutput(), fields)
 		},
 	}
 
 	addParentFlags(cmd, cfg.Parents)
-	cmd.Flags().String("id", "", fmt.Sprintf("%s ID", cfg.Name))
-	_ = cmd.MarkFlagRequired("id")
+	addTargetFlags(cmd, cfg.Name)
+	addFieldsFlag(cmd)
 	return cmd
 }
 
@@ -163,24 +246,28 @@ func newCreateCmd[T any](cfg Config[T]) *cobra.Command {
 		Use:          "create",
 		Short:        fmt.Sprintf("create a %s resource", cfg.Name),
 		SilenceUsage: true,
+		Annotations:  map[string]string{EmitAnnotation: "true"},
 		RunE: func(cmd *cobra.Command, _ []string) error {
-			client := cfg.NewClient()
-			ctx := cfg.GetContext()
-
-			parentIDs, err := resolveParents(ctx, client, cmd, cfg.Parents)
+			client, parentIDs, err := commandScope(cfg, cmd)
 			if err != nil {
 				return err
 			}
+			ctx := cfg.GetContext()
 
 			resource := new(T)
 			if err := populateFields(cmd, cfg.Fields, resource); err != nil {
 				return err
 			}
+			if OpsFile != "" {
+				ch := &Change{Action: Create, ParentIDs: parentIDs, Fields: flagChanges(cmd, cfg.Fields, resource)}
+				return emitChange(cfg, ch, os.Stdout)
+			}
 
 			result, err := cfg.Create(ctx, client, parentIDs, resource)
 			if err != nil {
 				return err
 			}
+			invalidateCache(cfg.Name)
 
 			return output.Render(os.Stdout, result, cfg.GetOutput())
 		},
@@ -196,34 +283,44 @@ func newUpdateCmd[T any](cfg Config[T]) *cobra.Command {
 		Use:          "update",
 		Short:        fmt.Sprintf("update a %s resource", cfg.Name),
 		SilenceUsage: true,
-		RunE: func(cmd *cobra.Command, _ []string) error {
-			client := cfg.NewClient()
+		Annotations:  map[string]string{EmitAnnotation: "true"},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			target, positional, err := targetArg(cmd, args, cfg.Name)
+			if err != nil {
+				return err
+			}
+			client, parentIDs, err := commandScope(cfg, cmd)
+			if err != nil {
+				return err
+			}
 			ctx := cfg.GetContext()
 
-			parentIDs, err := resolveParents(ctx, client, cmd, cfg.Parents)
+			id, err := commandTarget(cfg, client, parentIDs, target, positional)
 			if err != nil {
 				return err
 			}
-
-			id, _ := cmd.Flags().GetString("id")
 			resource := new(T)
 			setStructField(resource, "ID", id)
 			if err := populateChangedFields(cmd, cfg.Fields, resource); err != nil {
 				return err
 			}
+			if OpsFile != "" {
+				ch := &Change{Action: Update, ID: id, ParentIDs: parentIDs, Fields: flagChanges(cmd, cfg.Fields, resource)}
+				return emitChange(cfg, ch, os.Stdout)
+			}
 
 			result, err := cfg.Update(ctx, client, parentIDs, resource)
 			if err != nil {
 				return err
 			}
+			invalidateCache(cfg.Name)
 
 			return output.Render(os.Stdout, result, cfg.GetOutput())
 		},
 	}
 
 	addParentFlags(cmd, cfg.Parents)
-	cmd.Flags().String("id", "", fmt.Sprintf("%s ID", cfg.Name))
-	_ = cmd.MarkFlagRequired("id")
+	addTargetFlags(cmd, cfg.Name)
 	addFieldFlags(cmd, cfg.Fields, false)
 	return cmd
 }
@@ -233,19 +330,29 @@ func newDeleteCmd[T any](cfg Config[T]) *cobra.Command {
 		Use:          "delete",
 		Short:        fmt.Sprintf("delete a %s resource", cfg.Name),
 		SilenceUsage: true,
-		RunE: func(cmd *cobra.Command, _ []string) error {
-			client := cfg.NewClient()
+		Annotations:  map[string]string{EmitAnnotation: "true"},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			target, positional, err := targetArg(cmd, args, cfg.Name)
+			if err != nil {
+				return err
+			}
+			client, parentIDs, err := commandScope(cfg, cmd)
+			if err != nil {
+				return err
+			}
 			ctx := cfg.GetContext()
 
-			parentIDs, err := resolveParents(ctx, client, cmd, cfg.Parents)
+			id, err := commandTarget(cfg, client, parentIDs, target, positional)
 			if err != nil {
 				return err
 			}
-
-			id, _ := cmd.Flags().GetString("id")
+			if OpsFile != "" {
+				return emitChange(cfg, &Change{Action: Delete, ID: id, ParentIDs: parentIDs}, os.Stdout)
+			}
 			if err := cfg.Delete(ctx, client, parentIDs, id); err != nil {
 				return err
 			}
+			invalidateCache(cfg.Name)
 
 			_, err = fmt.Fprintf(os.Stdout, "%s deleted\n", cfg.Name)
 			return err
@@ -253,8 +360,7 @@ func newDeleteCmd[T any](cfg Config[T]) *cobra.Command {
 	}
 
 	addParentFlags(cmd, cfg.Parents)
-	cmd.Flags().String("id", "", fmt.Sprintf("%s ID", cfg.Name))
-	_ = cmd.MarkFlagRequired("id")
+	addTargetFlags(cmd, cfg.Name)
 	return cmd
 }
 
@@ -286,6 +392,9 @@ func addParentFlags(cmd *cobra.Command, parents []ParentScope) {
 
 func addFieldFlags(cmd *cobra.Command, fields []FieldDef, forCreate bool) {
 	for _, f := range fields {
+		if f.ManifestOnly {
+			continue
+		}
 		desc := f.Description
 		if desc == "" {
 			desc = f.Flag
@@ -310,6 +419,9 @@ func addFieldFlags(cmd *cobra.Command, fields []FieldDef, forCreate bool) {
 
 func populateFields(cmd *cobra.Command, fields []FieldDef, obj any) error {
 	for _, f := range fields {
+		if f.ManifestOnly {
+			continue
+		}
 		if err := setFieldFromFlag(cmd, f, obj); err != nil {
 			return err
 		}
@@ -360,7 +472,12 @@ func setStringField(field reflect.Value, val string) {
 			return
 		}
 		ptr := reflect.New(field.Type().Elem())
-		ptr.Elem().SetString(val)
+		if ptr.Elem().Kind() == reflect.Struct {
+			// A relation, set by the ID of the resource it points to.
+			ptr.Elem().FieldByName("ID").SetString(val)
+		} else {
+			ptr.Elem().SetString(val)
+		}
 		field.Set(ptr)
 	} else {
 		field.SetString(val)
@@ -17,7 +17,7 @@ type testResource struct {
 
 func testRuntime() Runtime {
 	return Runtime{
-		NewClient:  func() *terrakube.Client { return nil },
+		NewClient:  func() (*terrakube.Client, error) { return nil, nil },
 		GetContext:  func() context.Context { return context.Background() },
 		GetOutput:  func() string { return "json" },
 	}
@@ -55,7 +55,7 @@ func TestRegister_CommandTree(t *testing.T) {
 	if err != nil {
 		t.Fatalf("expected widget command, got error: %v", err)
 	}
-	if widgetCmd.Use != "widget list|get|create|update|delete [FLAGS]" {
+	if widgetCmd.Use != "widget list|get|create|update|delete|edit [FLAGS]" {
 		t.Errorf("unexpected Use: %s", widgetCmd.Use)
 	}
 
@@ -65,7 +65,7 @@ func TestRegister_CommandTree(t *testing.T) {
 		names[sub.Name()] = true
 	}
 
-	for _, expected := range []string{"list", "get", "create", "update", "delete"} {
+	for _, expected := range []string{"list", "get", "create", "update", "delete", "edit"} {
 		if !names[expected] {
 			t.Errorf("expected subcommand %q, not found", expected)
 		}
@@ -94,6 +94,9 @@ func TestRegister_OnlyListAndDelete(t *testing.T) {
 	Register(root, cfg)
 
 	partialCmd, _, _ := root.Find([]string{"partial"})
+	if partialCmd.Use != "partial list|delete [FLAGS]" {
+		t.Errorf("unexpected Use: %s", partialCmd.Use)
+	}
 	subcmds := partialCmd.Commands()
 	names := make(map[string]bool)
 	for _, sub := range subcmds {
@@ -115,6 +118,9 @@ func TestRegister_OnlyListAndDelete(t *testing.T) {
 	if names["update"] {
 		t.Error("did not expect update subcommand")
 	}
+	if names["edit"] {
+		t.Error("did not expect edit subcommand")
+	}
 }
 
 func TestRegister_ParentFlags(t *testing.T) {
@@ -205,6 +211,20 @@ func TestRegister_UpdateHasIDAndFields(t *testing.T) {
 	}
 }
 
+func TestRegister_ManifestOnlyHasNoFlag(t *testing.T) {
+	root := &cobra.Command{Use: "test"}
+	cfg := testConfig()
+	cfg.Fields = append(cfg.Fields, FieldDef{StructField: "Desc", Flag: "note", Type: String, ManifestOnly: true})
+	Register(root, cfg)
+
+	for _, name := range []string{"create", "update"} {
+		cmd, _, _ := root.Find([]string{"widget", name})
+		if cmd.Flags().Lookup("note") != nil {
+			t.Errorf("expected no --note flag on %s", name)
+		}
+	}
+}
+
 func TestPopulateFields(t *testing.T) {
 	cmd := &cobra.Command{Use: "test"}
 	cmd.Flags().StringP("name", "n", "", "")
@@ -0,0 +1,75 @@
+package resource
+
+import (
+	"context"
+	"fmt"
+
+	terrakube "github.com/terrakube-io/terrakube-go"
+	"github.com/spf13/cobra"
+)
+
+// addTargetFlags sets up a command acting on one resource, given as a
+// positional name or ID argument or with --id.
+func addTargetFlags(cmd *cobra.Command, name string) {
+	cmd.Use += " [NAME|ID]"
+	cmd.Args = cobra.MaximumNArgs(1)
+	cmd.Flags().String("id", "", fmt.Sprintf("%s ID", name))
+}
+
+// targetArg returns the resource a command acts on and whether it was given
+// as the positional argument, which may be a name.
+func targetArg(cmd *cobra.Command, args []string, name string) (string, bool, error) {
+	id, _ := cmd.Flags().GetString("id")
+	switch {
+	case len(args) == 1 && id != "" && args[0] != id:
+		return "", false, fmt.Errorf("the %s argument %q and --id %q do not match, use only one", name, args[0], id)
+	case len(args) == 1:
+		return args[0], true, nil
+	case id != "":
+		return id, false, nil
+	default:
+		return "", false, fmt.Errorf("a %s name or ID argument, or --id, is required", name)
+	}
+}
+
+// resolveTarget turns the target of a command into an ID. --id values and
+// UUIDs are used as is; names are resolved with the Config's resolver.
+func resolveTarget[T any](ctx context.Context, cfg Config[T], client *terrakube.Client, parentIDs []string, target string, positional bool) (string, error) {
+	if !positional || IsUUID(target) {
+		return target, nil
+	}
+	resolver := cfg.selfResolver()
+	if resolver == nil {
+		// Resources without names, such as jobs, have non-UUID IDs.
+		return target, nil
+	}
+	return resolver(ctx, client, parentIDs, target)
+}
+
+// selfResolver returns the Resolver of the Config, or a lookup by the
+// identifying attribute when T has one and can be listed.
+func (cfg Config[T]) selfResolver() ResolverFunc {
+	if cfg.Resolver != nil {
+		return cfg.Resolver
+	}
+	_, attr := cfg.identity()
+	if cfg.List == nil || attr == "" {
+		return nil
+	}
+	return NameResolver(cfg.Name, "", attr, cfg.List)
+}
+
+// identity returns the flag of the field that identifies a resource among
+// its siblings, Identity or "name", and its jsonapi attribute. Both are empty
+// when T has no such attribute.
+func (cfg Config[T]) identity() (flag, attr string) {
+	flag = cfg.Identity
+	if flag == "" {
+		flag = "name"
+	}
+	attr, ok := whereAttributes[T](cfg.Fields)[flag]
+	if !ok {
+		return "", ""
+	}
+	return flag, attr
+}
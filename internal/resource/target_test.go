@@ -0,0 +1,130 @@
+package resource
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	terrakube "github.com/terrakube-io/terrakube-go"
+	"github.com/spf13/cobra"
+)
+
+const orgID = "a1b2c3d4-e5f6-7890-abcd-ef1234567890"
+
+// runTarget executes "widget get" with args and returns the ID passed to Get.
+func runTarget(t *testing.T, cfg Config[testResource], args ...string) (string, error) {
+	t.Helper()
+	var got string
+	cfg.Get = func(_ context.Context, _ *terrakube.Client, _ []string, id string) (*testResource, error) {
+		got = id
+		return &testResource{ID: id}, nil
+	}
+	root := &cobra.Command{Use: "test"}
+	Register(root, cfg)
+	root.SetArgs(append([]string{"widget", "get", "--organization-id", orgID}, args...))
+	root.SilenceErrors = true
+	err := root.Execute()
+	return got, err
+}
+
+func listing(filters *[]string, items ...*testResource) func(context.Context, *terrakube.Client, []string, *terrakube.ListOptions) ([]*testResource, error) {
+	return func(_ context.Context, _ *terrakube.Client, _ []string, opts *terrakube.ListOptions) ([]*testResource, error) {
+		*filters = append(*filters, opts.Filter)
+		return items, nil
+	}
+}
+
+func TestTarget_ResolvesName(t *testing.T) {
+	cfg := testConfig()
+	var filters []string
+	cfg.List = listing(&filters, &testResource{ID: "w-1", Name: "my widget"})
+
+	got, err := runTarget(t, cfg, "my widget")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got != "w-1" {
+		t.Errorf("expected ID w-1, got %q", got)
+	}
+	if len(filters) != 1 || filters[0] != `name=="my widget"` {
+		t.Errorf("unexpected list filters: %v", filters)
+	}
+}
+
+func TestTarget_UUIDAndIDFlagSkipLookup(t *testing.T) {
+	const uuid = "d4e5f6a7-b8c9-0123-defa-234567890123"
+	for _, args := range [][]string{{uuid}, {"--id", "w-1"}, {uuid, "--id", uuid}} {
+		cfg := testConfig()
+		var filters []string
+		cfg.List = listing(&filters)
+
+		got, err := runTarget(t, cfg, args...)
+		if err != nil {
+			t.Fatalf("%v: unexpected error: %v", args, err)
+		}
+		if want := args[len(args)-1]; got != want {
+			t.Errorf("%v: expected ID %q, got %q", args, want, got)
+		}
+		if len(filters) != 0 {
+			t.Errorf("%v: expected no lookup, got %v", args, filters)
+		}
+	}
+}
+
+func TestTarget_Errors(t *testing.T) {
+	tests := []struct {
+		name  string
+		items []*testResource
+		args  []string
+		want  string
+	}{
+		{"missing", nil, nil, "a widget name or ID argument, or --id, is required"},
+		{"conflict", nil, []string{"a", "--id", "b"}, "do not match"},
+		{"not found", nil, []string{"nope"}, `no widget found with name "nope"`},
+		{"ambiguous", []*testResource{{ID: "w-1"}, {ID: "w-2"}}, []string{"dup"}, `multiple widgets match name "dup"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := testConfig()
+			var filters []string
+			cfg.List = listing(&filters, tt.items...)
+
+			_, err := runTarget(t, cfg, tt.args...)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("expected error containing %q, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestTarget_CustomResolver(t *testing.T) {
+	cfg := testConfig()
+	cfg.Resolver = func(_ context.Context, _ *terrakube.Client, parentIDs []string, name string) (string, error) {
+		return parentIDs[0] + "/" + name, nil
+	}
+
+	got, err := runTarget(t, cfg, "thing")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got != orgID+"/thing" {
+		t.Errorf("expected the custom resolver to be used, got %q", got)
+	}
+}
+
+func TestTarget_WithoutNameUsesValue(t *testing.T) {
+	type job struct {
+		ID     string `jsonapi:"primary,job"`
+		Status string `jsonapi:"attr,status"`
+	}
+	if _, attr := (Config[job]{}).identity(); attr != "" {
+		t.Fatalf("expected no identifying attribute, got %q", attr)
+	}
+	if flag, attr := testConfig().identity(); flag != "name" || attr != "name" {
+		t.Fatalf("expected the name attribute, got %q, %q", flag, attr)
+	}
+	got, err := resolveTarget(context.Background(), Config[job]{}, nil, nil, "42", true)
+	if err != nil || got != "42" {
+		t.Errorf("expected 42, got %q, %v", got, err)
+	}
+}
@@ -0,0 +1,173 @@
+package resource
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// whereOp is a --where operator and the RSQL operator it compiles to.
+type whereOp struct{ op, rsql string }
+
+// whereOperators are tried in order, longest first so that "!=" is not read
+// as "=".
+var whereOperators = []whereOp{
+	{"!=in:", "=out="},
+	{"=in:", "=in="},
+	{"!=", "!="},
+	{"~=", "=="},
+	{">=", "=ge="},
+	{"<=", "=le="},
+	{"=", "=="},
+	{">", "=gt="},
+	{"<", "=lt="},
+}
+
+func addWhereFlag(cmd *cobra.Command) {
+	cmd.Flags().StringArray("where", nil, "Filter on a field, repeatable: flag=value, flag!=value, flag~=glob*, flag=in:a,b (\\, for a comma in a value), flag>value (combined with --filter)")
+}
+
+// filterFromFlags combines --filter with the --where conditions compiled to
+// RSQL for the resource type T.
+func filterFromFlags[T any](cmd *cobra.Command, name string, fields []FieldDef) (string, error) {
+	filter, _ := cmd.Flags().GetString("filter")
+	exprs, _ := cmd.Flags().GetStringArray("where")
+	if len(exprs) == 0 {
+		return filter, nil
+	}
+
+	where, err := compileWhere[T](exprs, name, fields)
+	if err != nil {
+		return "", err
+	}
+	if filter == "" {
+		return where, nil
+	}
+	return "(" + filter + ");" + where, nil
+}
+
+// compileWhere translates --where conditions to an RSQL conjunction. Field
+// names are CLI flag names, or JSON:API attribute names.
+func compileWhere[T any](exprs []string, name string, fields []FieldDef) (string, error) {
+	attrs := whereAttributes[T](fields)
+	conds := make([]string, 0, len(exprs))
+	for _, expr := range exprs {
+		field, op, value, err := parseWhere(expr)
+		if err != nil {
+			return "", err
+		}
+		attr, ok := attrs[field]
+		if !ok {
+			return "", fmt.Errorf("invalid --where %q: %s has no field %q, expected one of: %s",
+				expr, name, field, whereFieldNames(fields, attrs))
+		}
+
+		switch op.rsql {
+		case "=in=", "=out=":
+			values := splitList(value)
+			for i, v := range values {
+				values[i] = quoteRSQL(v)
+			}
+			conds = append(conds, attr+op.rsql+"("+strings.Join(values, ",")+")")
+		default:
+			if op.op == "=" || op.op == "!=" {
+				if strings.Contains(value, "*") {
+					return "", fmt.Errorf("invalid --where %q: use ~= to match with * wildcards", expr)
+				}
+			}
+			conds = append(conds, attr+op.rsql+quoteRSQL(value))
+		}
+	}
+	return strings.Join(conds, ";"), nil
+}
+
+// parseWhere splits "flag<op>value".
+func parseWhere(expr string) (string, whereOp, string, error) {
+	end := strings.IndexFunc(expr, func(r rune) bool {
+		return !(r == '-' || r == '_' || r == '.' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9')
+	})
+	if end <= 0 {
+		return "", whereOp{}, "", fmt.Errorf("invalid --where %q: expected flag=value, flag!=value, flag~=glob or flag=in:a,b", expr)
+	}
+	rest := expr[end:]
+	for _, op := range whereOperators {
+		if strings.HasPrefix(rest, op.op) {
+			return expr[:end], op, rest[len(op.op):], nil
+		}
+	}
+	return "", whereOp{}, "", fmt.Errorf("invalid --where %q: unknown operator in %q", expr, rest)
+}
+
+// splitList splits an in: list on commas. A comma or backslash escaped with
+// a backslash is part of the value; any other backslash is kept as it is.
+func splitList(s string) []string {
+	var values []string
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		switch {
+		case s[i] == '\\' && i+1 < len(s) && (s[i+1] == ',' || s[i+1] == '\\'):
+			i++
+			b.WriteByte(s[i])
+		case s[i] == ',':
+			values = append(values, b.String())
+			b.Reset()
+		default:
+			b.WriteByte(s[i])
+		}
+	}
+	return append(values, b.String())
+}
+
+// whereAttributes maps the flag names of fields, the JSON:API attribute
+// names of T and "id" to attribute names.
+func whereAttributes[T any](fields []FieldDef) map[string]string {
+	attrs := map[string]string{"id": "id"}
+	t := reflect.TypeOf((*T)(nil)).Elem()
+	for i := 0; i < t.NumField(); i++ {
+		parts := strings.Split(t.Field(i).Tag.Get("jsonapi"), ",")
+		if len(parts) >= 2 && parts[0] == "attr" {
+			attrs[parts[1]] = parts[1]
+		}
+	}
+	for _, f := range fields {
+		sf, ok := t.FieldByName(f.StructField)
+		if !ok {
+			continue
+		}
+		parts := strings.Split(sf.Tag.Get("jsonapi"), ",")
+		if len(parts) >= 2 && parts[0] == "attr" {
+			attrs[f.Flag] = parts[1]
+		}
+	}
+	return attrs
+}
+
+// whereFieldNames lists the flag names, falling back to attribute names
+// when the resource has no field flags.
+func whereFieldNames(fields []FieldDef, attrs map[string]string) string {
+	var names []string
+	for _, f := range fields {
+		if _, ok := attrs[f.Flag]; ok {
+			names = append(names, f.Flag)
+		}
+	}
+	if len(names) == 0 {
+		for name := range attrs {
+			names = append(names, name)
+		}
+	}
+	slices.Sort(names)
+	return strings.Join(names, ", ")
+}
+
+// quoteRSQL quotes an RSQL argument when it has reserved characters or is
+// empty, escaping backslashes and double quotes.
+func quoteRSQL(v string) string {
+	if v != "" && !strings.ContainsAny(v, " \t\"'();,=!~<>\\") {
+		return v
+	}
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(v) + `"`
+}
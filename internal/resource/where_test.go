@@ -0,0 +1,83 @@
+package resource
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+type whereResource struct {
+	ID      string `jsonapi:"primary,workspace"`
+	Name    string `jsonapi:"attr,name"`
+	IacType string `jsonapi:"attr,iacType"`
+	Locked  bool   `jsonapi:"attr,locked"`
+}
+
+var whereFields = []FieldDef{
+	{StructField: "Name", Flag: "name", Type: String},
+	{StructField: "IacType", Flag: "iac-type", Type: String},
+}
+
+func TestCompileWhere(t *testing.T) {
+	tests := []struct {
+		exprs []string
+		want  string
+	}{
+		{[]string{"name=prod"}, "name==prod"},
+		{[]string{"iac-type!=tofu"}, "iacType!=tofu"},
+		{[]string{"iacType=terraform"}, "iacType==terraform"},
+		{[]string{"name~=prod-*"}, "name==prod-*"},
+		{[]string{"name=in:a,b c"}, `name=in=(a,"b c")`},
+		{[]string{"name!=in:a,b"}, "name=out=(a,b)"},
+		{[]string{`name=in:a\,b,c\\,d\e`}, `name=in=("a,b","c\\","d\\e")`},
+		{[]string{"locked=true", "name>=m"}, "locked==true;name=ge=m"},
+		{[]string{`name=it's "x"`}, `name=="it's \"x\""`},
+		{[]string{"name="}, `name==""`},
+		{[]string{"id=abc"}, "id==abc"},
+	}
+	for _, tt := range tests {
+		got, err := compileWhere[whereResource](tt.exprs, "workspace", whereFields)
+		if err != nil {
+			t.Errorf("compileWhere(%q): unexpected error: %v", tt.exprs, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("compileWhere(%q) = %q, want %q", tt.exprs, got, tt.want)
+		}
+	}
+}
+
+func TestCompileWhere_Invalid(t *testing.T) {
+	tests := []struct {
+		expr string
+		want string
+	}{
+		{"nmae=prod", `workspace has no field "nmae", expected one of: iac-type, name`},
+		{"name", "expected flag=value"},
+		{"name:prod", "unknown operator"},
+		{"=prod", "expected flag=value"},
+		{"name=prod*", "use ~= to match with * wildcards"},
+	}
+	for _, tt := range tests {
+		_, err := compileWhere[whereResource]([]string{tt.expr}, "workspace", whereFields)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("compileWhere(%q): expected error containing %q, got %v", tt.expr, tt.want, err)
+		}
+	}
+}
+
+func TestFilterFromFlags(t *testing.T) {
+	cmd := &cobra.Command{Use: "list"}
+	cmd.Flags().String("filter", "", "")
+	addWhereFlag(cmd)
+	_ = cmd.ParseFlags([]string{"--filter", "name==a,name==b", "--where", "iac-type=terraform"})
+
+	got, err := filterFromFlags[whereResource](cmd, "workspace", whereFields)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got != "(name==a,name==b);iacType==terraform" {
+		t.Errorf("unexpected filter %q", got)
+	}
+}
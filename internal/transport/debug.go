@@ -0,0 +1,221 @@
+package transport
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultMaxBody is the number of body bytes Debug prints before truncating.
+const DefaultMaxBody = 4096
+
+const redacted = "[REDACTED]"
+
+// sensitiveHeaders are printed as redacted, keeping only the auth scheme.
+var sensitiveHeaders = map[string]bool{
+	"Authorization":       true,
+	"Proxy-Authorization": true,
+	"Cookie":              true,
+	"Set-Cookie":          true,
+}
+
+// sensitiveFields are top-level JSON fields redacted from bodies, such as the
+// tokens returned by the OIDC token endpoint.
+var sensitiveFields = []string{"access_token", "refresh_token", "id_token", "device_code", "client_secret"}
+
+// Debug writes a trace of every request and response to Out: method, URL,
+// status and duration, followed by headers and JSON bodies unless Summary is
+// set. Credentials are redacted and bodies are truncated to MaxBody bytes.
+type Debug struct {
+	Next http.RoundTripper
+	Out  io.Writer
+
+	// Summary only prints the request line, status and duration.
+	Summary bool
+
+	// MaxBody is the number of body bytes printed, DefaultMaxBody when zero.
+	MaxBody int
+
+	mu sync.Mutex
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *Debug) RoundTrip(req *http.Request) (*http.Response, error) {
+	var b strings.Builder
+	if !t.Summary {
+		fmt.Fprintf(&b, "--> %s %s\n", req.Method, req.URL.Redacted())
+		writeHeaders(&b, req.Header)
+		body, err := t.requestBody(req)
+		if err != nil {
+			return nil, err
+		}
+		t.writeBody(&b, req.Header, body)
+		t.print(b.String())
+		b.Reset()
+	}
+
+	start := time.Now()
+	resp, err := t.next().RoundTrip(req)
+	elapsed := time.Since(start).Round(time.Millisecond)
+	if err != nil {
+		fmt.Fprintf(&b, "<-- %s %s: %v (%s)\n", req.Method, req.URL.Redacted(), err, elapsed)
+		t.print(b.String())
+		return nil, err
+	}
+
+	fmt.Fprintf(&b, "<-- %s %s %s (%s)\n", resp.Status, req.Method, req.URL.Redacted(), elapsed)
+	if !t.Summary {
+		writeHeaders(&b, resp.Header)
+		body, err := t.responseBody(resp)
+		if err != nil {
+			return nil, err
+		}
+		t.writeBody(&b, resp.Header, body)
+	}
+	t.print(b.String())
+	return resp, nil
+}
+
+func (t *Debug) next() http.RoundTripper {
+	if t.Next != nil {
+		return t.Next
+	}
+	return http.DefaultTransport
+}
+
+func (t *Debug) maxBody() int {
+	if t.MaxBody > 0 {
+		return t.MaxBody
+	}
+	return DefaultMaxBody
+}
+
+func (t *Debug) print(s string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	_, _ = io.WriteString(t.Out, s)
+}
+
+// requestBody returns the request body without consuming it.
+func (t *Debug) requestBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, nil
+	}
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		defer func() { _ = body.Close() }()
+		return io.ReadAll(body)
+	}
+	data, err := io.ReadAll(req.Body)
+	_ = req.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	req.Body = io.NopCloser(bytes.NewReader(data))
+	return data, nil
+}
+
+// responseBody reads the response body and puts it back for the caller. The
+// whole body is read so that secrets are redacted before truncating.
+func (t *Debug) responseBody(resp *http.Response) ([]byte, error) {
+	if resp.Body == nil || resp.Body == http.NoBody {
+		return nil, nil
+	}
+	data, err := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(data))
+	return data, nil
+}
+
+func (t *Debug) writeBody(b *strings.Builder, h http.Header, body []byte) {
+	if len(body) == 0 {
+		return
+	}
+	if !isJSON(h.Get("Content-Type")) {
+		fmt.Fprintf(b, "    [%s body omitted]\n", h.Get("Content-Type"))
+		return
+	}
+
+	body = redactJSON(body)
+	truncated := len(body) > t.maxBody()
+	if truncated {
+		body = body[:t.maxBody()]
+	}
+	b.WriteString("    ")
+	b.Write(bytes.TrimRight(body, "\n"))
+	if truncated {
+		b.WriteString("... (truncated)")
+	}
+	b.WriteString("\n")
+}
+
+func writeHeaders(b *strings.Builder, h http.Header) {
+	names := make([]string, 0, len(h))
+	for name := range h {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	for _, name := range names {
+		for _, v := range h[name] {
+			if sensitiveHeaders[name] {
+				v = redactCredential(v)
+			}
+			fmt.Fprintf(b, "    %s: %s\n", name, v)
+		}
+	}
+}
+
+// redactCredential hides a credential, keeping the scheme of an
+// Authorization value such as "Bearer".
+func redactCredential(v string) string {
+	if scheme, _, ok := strings.Cut(v, " "); ok {
+		return scheme + " " + redacted
+	}
+	return redacted
+}
+
+// redactJSON hides sensitiveFields of a JSON object. Other bodies are
+// returned unchanged.
+func redactJSON(body []byte) []byte {
+	var obj map[string]json.RawMessage
+	if json.Unmarshal(body, &obj) != nil {
+		return body
+	}
+	changed := false
+	for _, field := range sensitiveFields {
+		if _, ok := obj[field]; ok {
+			obj[field] = json.RawMessage(`"` + redacted + `"`)
+			changed = true
+		}
+	}
+	if !changed {
+		return body
+	}
+	out, err := json.Marshal(obj)
+	if err != nil {
+		return body
+	}
+	return out
+}
+
+// isJSON reports whether a content type is JSON, including JSON:API.
+func isJSON(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	return mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
+}
@@ -0,0 +1,141 @@
+package transport
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func debugServer(t *testing.T, contentType, body string) *httptest.Server {
+	t.Helper()
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", contentType)
+		_, _ = io.WriteString(w, body)
+	}))
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+func TestDebug_TracesRequestAndResponse(t *testing.T) {
+	ts := debugServer(t, "application/vnd.api+json", `{"data":[]}`)
+	var out strings.Builder
+	client := &http.Client{Transport: &Debug{Out: &out}}
+
+	req, _ := http.NewRequest(http.MethodPost, ts.URL+"/api/v1/organization", strings.NewReader(`{"data":{"type":"organization"}}`))
+	req.Header.Set("Authorization", "Bearer secret-token")
+	req.Header.Set("Content-Type", "application/vnd.api+json")
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+
+	if string(body) != `{"data":[]}` {
+		t.Errorf("expected the caller to still read the body, got %q", body)
+	}
+	trace := out.String()
+	for _, want := range []string{
+		"--> POST " + ts.URL + "/api/v1/organization",
+		"Authorization: Bearer [REDACTED]",
+		`{"data":{"type":"organization"}}`,
+		"<-- 200 OK POST " + ts.URL + "/api/v1/organization (",
+		`{"data":[]}`,
+	} {
+		if !strings.Contains(trace, want) {
+			t.Errorf("expected trace to contain %q, got:\n%s", want, trace)
+		}
+	}
+	if strings.Contains(trace, "secret-token") {
+		t.Errorf("expected token to be redacted, got:\n%s", trace)
+	}
+}
+
+func TestDebug_TruncatesBody(t *testing.T) {
+	ts := debugServer(t, "application/json", `{"data":"`+strings.Repeat("x", 100)+`"}`)
+	var out strings.Builder
+	client := &http.Client{Transport: &Debug{Out: &out, MaxBody: 20}}
+
+	resp, err := client.Get(ts.URL)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+
+	if len(body) != 111 {
+		t.Errorf("expected the whole body for the caller, got %d bytes", len(body))
+	}
+	if !strings.Contains(out.String(), `{"data":"xxxxxxxxxxx... (truncated)`) {
+		t.Errorf("expected truncated body, got:\n%s", out.String())
+	}
+}
+
+func TestDebug_RedactsTokenFields(t *testing.T) {
+	ts := debugServer(t, "application/json", `{"access_token":"at","refresh_token":"rt","expires_in":300}`)
+	var out strings.Builder
+	client := &http.Client{Transport: &Debug{Out: &out}}
+
+	resp, err := client.Get(ts.URL)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	_ = resp.Body.Close()
+
+	trace := out.String()
+	if strings.Contains(trace, `"at"`) || strings.Contains(trace, `"rt"`) {
+		t.Errorf("expected tokens to be redacted, got:\n%s", trace)
+	}
+	if !strings.Contains(trace, `"expires_in":300`) {
+		t.Errorf("expected other fields to be kept, got:\n%s", trace)
+	}
+}
+
+func TestDebug_OmitsNonJSONBody(t *testing.T) {
+	ts := debugServer(t, "text/html", "<html>secret</html>")
+	var out strings.Builder
+	client := &http.Client{Transport: &Debug{Out: &out}}
+
+	resp, err := client.Get(ts.URL)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	_ = resp.Body.Close()
+
+	if strings.Contains(out.String(), "secret") || !strings.Contains(out.String(), "[text/html body omitted]") {
+		t.Errorf("expected HTML body to be omitted, got:\n%s", out.String())
+	}
+}
+
+func TestDebug_Summary(t *testing.T) {
+	ts := debugServer(t, "application/json", `{}`)
+	var out strings.Builder
+	client := &http.Client{Transport: &Debug{Out: &out, Summary: true}}
+
+	req, _ := http.NewRequest(http.MethodGet, ts.URL, nil)
+	req.Header.Set("Authorization", "Bearer secret-token")
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	_ = resp.Body.Close()
+
+	lines := strings.Split(strings.TrimRight(out.String(), "\n"), "\n")
+	if len(lines) != 1 || !strings.HasPrefix(lines[0], "<-- 200 OK GET "+ts.URL) {
+		t.Errorf("expected a single summary line, got:\n%s", out.String())
+	}
+}
+
+func TestDebug_TransportError(t *testing.T) {
+	var out strings.Builder
+	client := &http.Client{Transport: &Debug{Out: &out, Summary: true}}
+
+	if _, err := client.Get("http://127.0.0.1:1/"); err == nil {
+		t.Fatal("expected connection error, got nil")
+	}
+	if !strings.Contains(out.String(), "<-- GET http://127.0.0.1:1/: ") {
+		t.Errorf("expected the error to be traced, got:\n%s", out.String())
+	}
+}
@@ -0,0 +1,67 @@
+package transport
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"sync"
+)
+
+// Failure is a response with an error status.
+type Failure struct {
+	Method     string
+	URL        string
+	StatusCode int
+	Body       []byte
+}
+
+// Failures remembers the last API response when it failed, so that an error
+// returned by the client can be explained with the server's error document.
+type Failures struct {
+	mu   sync.Mutex
+	last *Failure
+}
+
+// Last returns the last response if it had an error status, or nil.
+func (f *Failures) Last() *Failure {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.last
+}
+
+// Reset forgets the last failure.
+func (f *Failures) Reset() {
+	f.set(nil)
+}
+
+func (f *Failures) set(failure *Failure) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.last = failure
+}
+
+// Transport returns a RoundTripper that records failed responses of next.
+func (f *Failures) Transport(next http.RoundTripper) http.RoundTripper {
+	return roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		resp, err := next.RoundTrip(req)
+		if err != nil || resp.StatusCode < 400 {
+			f.set(nil)
+			return resp, err
+		}
+
+		body, err := io.ReadAll(resp.Body)
+		_ = resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		resp.Body = io.NopCloser(bytes.NewReader(body))
+		f.set(&Failure{Method: req.Method, URL: req.URL.Redacted(), StatusCode: resp.StatusCode, Body: body})
+		return resp, nil
+	})
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (fn roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return fn(req)
+}
@@ -0,0 +1,53 @@
+package transport
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestFailures(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing" {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = io.WriteString(w, `{"errors":[{"detail":"not found"}]}`)
+			return
+		}
+		_, _ = io.WriteString(w, "ok")
+	}))
+	defer ts.Close()
+
+	var failures Failures
+	client := &http.Client{Transport: failures.Transport(http.DefaultTransport)}
+
+	resp, err := client.Get(ts.URL + "/missing")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if string(body) != `{"errors":[{"detail":"not found"}]}` {
+		t.Errorf("expected the caller to still read the body, got %q", body)
+	}
+
+	last := failures.Last()
+	if last == nil {
+		t.Fatal("expected the failure to be recorded")
+	}
+	if last.Method != http.MethodGet || last.URL != ts.URL+"/missing" || last.StatusCode != http.StatusNotFound {
+		t.Errorf("unexpected failure %+v", last)
+	}
+	if string(last.Body) != string(body) {
+		t.Errorf("expected the body to be recorded, got %q", last.Body)
+	}
+
+	resp, err = client.Get(ts.URL + "/ok")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	_ = resp.Body.Close()
+	if failures.Last() != nil {
+		t.Error("expected a successful response to clear the failure")
+	}
+}
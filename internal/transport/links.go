@@ -0,0 +1,79 @@
+package transport
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+)
+
+// PageLinks are the pagination links of a JSON:API response.
+type PageLinks struct {
+	// Present reports whether the response had pagination links at all.
+	// Without them the last page cannot be told from Next.
+	Present bool
+
+	// Next is the URL of the next page, "" on the last page.
+	Next string
+}
+
+type pageLinksKey struct{}
+
+// WithPageLinks returns a context whose API responses record their
+// pagination links in links. It is used to follow links.next, which the
+// client library does not expose.
+func WithPageLinks(ctx context.Context, links *PageLinks) context.Context {
+	return context.WithValue(ctx, pageLinksKey{}, links)
+}
+
+// PageLinksFromContext returns the links set by WithPageLinks, or nil.
+func PageLinksFromContext(ctx context.Context) *PageLinks {
+	links, _ := ctx.Value(pageLinksKey{}).(*PageLinks)
+	return links
+}
+
+// recordPageLinks stores the pagination links of a successful GET response
+// in the links carried by the request context, if any.
+func recordPageLinks(req *http.Request, resp *http.Response) error {
+	links := PageLinksFromContext(req.Context())
+	if links == nil || req.Method != http.MethodGet || resp.StatusCode >= 300 {
+		return nil
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if err != nil {
+		return err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	var doc struct {
+		Links map[string]json.RawMessage `json:"links"`
+	}
+	*links = PageLinks{}
+	if json.Unmarshal(body, &doc) != nil {
+		return nil
+	}
+	for _, name := range []string{"first", "last", "prev", "next"} {
+		if _, ok := doc.Links[name]; ok {
+			links.Present = true
+		}
+	}
+	links.Next = linkHref(doc.Links["next"])
+	return nil
+}
+
+// linkHref returns the URL of a JSON:API link, given as a string or as a
+// link object, "" when it is null or missing.
+func linkHref(raw json.RawMessage) string {
+	var href string
+	if json.Unmarshal(raw, &href) == nil {
+		return href
+	}
+	var obj struct {
+		Href string `json:"href"`
+	}
+	_ = json.Unmarshal(raw, &obj)
+	return obj.Href
+}
@@ -0,0 +1,66 @@
+package transport
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+)
+
+type queryKey struct{}
+
+// WithQuery returns a context whose API requests carry the query parameters
+// in q, such as page[size], in addition to those of the request URL. It is
+// used for JSON:API parameters the client library has no option for.
+func WithQuery(ctx context.Context, q url.Values) context.Context {
+	merged := url.Values{}
+	for k, v := range QueryFromContext(ctx) {
+		merged[k] = v
+	}
+	for k, v := range q {
+		merged[k] = v
+	}
+	return context.WithValue(ctx, queryKey{}, merged)
+}
+
+// QueryFromContext returns the query parameters added by WithQuery.
+func QueryFromContext(ctx context.Context) url.Values {
+	q, _ := ctx.Value(queryKey{}).(url.Values)
+	return q
+}
+
+// Query adds the query parameters carried by the request context to GET
+// requests, and records the pagination links of their responses for
+// WithPageLinks. Parameters already in the URL are kept.
+type Query struct {
+	Next http.RoundTripper
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *Query) RoundTrip(req *http.Request) (*http.Response, error) {
+	if extra := QueryFromContext(req.Context()); len(extra) > 0 && req.Method == http.MethodGet {
+		q := req.URL.Query()
+		for k, v := range extra {
+			if _, ok := q[k]; !ok {
+				q[k] = v
+			}
+		}
+		req = req.Clone(req.Context())
+		req.URL.RawQuery = q.Encode()
+	}
+
+	resp, err := t.next().RoundTrip(req)
+	if err != nil {
+		return resp, err
+	}
+	if err := recordPageLinks(req, resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+func (t *Query) next() http.RoundTripper {
+	if t.Next != nil {
+		return t.Next
+	}
+	return http.DefaultTransport
+}
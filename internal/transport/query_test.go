@@ -0,0 +1,97 @@
+package transport
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func TestQuery(t *testing.T) {
+	var got url.Values
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.URL.Query()
+	}))
+	defer ts.Close()
+	client := &http.Client{Transport: &Query{}}
+
+	ctx := WithQuery(context.Background(), url.Values{"page[size]": {"10"}, "filter": {"ignored"}})
+	ctx = WithQuery(ctx, url.Values{"page[number]": {"2"}})
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, ts.URL+"?filter=name==a", nil)
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	_ = resp.Body.Close()
+
+	if got.Get("page[size]") != "10" || got.Get("page[number]") != "2" {
+		t.Errorf("expected paging parameters, got %v", got)
+	}
+	if got.Get("filter") != "name==a" {
+		t.Errorf("expected the URL's filter to win, got %q", got.Get("filter"))
+	}
+	if req.URL.RawQuery != "filter=name==a" {
+		t.Errorf("expected the original request to be left alone, got %q", req.URL.RawQuery)
+	}
+}
+
+func TestQuery_OnlyGet(t *testing.T) {
+	var raw string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		raw = r.URL.RawQuery
+	}))
+	defer ts.Close()
+	client := &http.Client{Transport: &Query{}}
+
+	ctx := WithQuery(context.Background(), url.Values{"page[size]": {"10"}})
+	req, _ := http.NewRequestWithContext(ctx, http.MethodPost, ts.URL, nil)
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	_ = resp.Body.Close()
+	if raw != "" {
+		t.Errorf("expected no query on POST, got %q", raw)
+	}
+}
+
+func TestQuery_RecordsPageLinks(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want PageLinks
+	}{
+		{"next link", `{"data":[],"links":{"self":"/w?page[number]=1","next":"/w?page[number]=2"}}`, PageLinks{Present: true, Next: "/w?page[number]=2"}},
+		{"next link object", `{"data":[],"links":{"next":{"href":"/w?page[number]=2"}}}`, PageLinks{Present: true, Next: "/w?page[number]=2"}},
+		{"last page", `{"data":[],"links":{"first":"/w?page[number]=1","next":null}}`, PageLinks{Present: true}},
+		{"no pagination links", `{"data":[],"links":{"self":"/w"}}`, PageLinks{}},
+		{"no links", `{"data":[]}`, PageLinks{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				_, _ = w.Write([]byte(tt.body))
+			}))
+			defer ts.Close()
+			client := &http.Client{Transport: &Query{}}
+
+			links := PageLinks{Present: true, Next: "stale"}
+			req, _ := http.NewRequestWithContext(WithPageLinks(context.Background(), &links), http.MethodGet, ts.URL, nil)
+			resp, err := client.Do(req)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			body, _ := io.ReadAll(resp.Body)
+			_ = resp.Body.Close()
+
+			if links != tt.want {
+				t.Errorf("expected %+v, got %+v", tt.want, links)
+			}
+			if string(body) != tt.body {
+				t.Errorf("expected the body to be passed on, got %q", body)
+			}
+		})
+	}
+}
@@ -0,0 +1,205 @@
+package transport
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// flakyServer fails the first failures requests with status.
+func flakyServer(t *testing.T, failures int32, status int, header http.Header) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var calls atomic.Int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := calls.Add(1)
+		if n <= failures {
+			for k, v := range header {
+				w.Header()[k] = v
+			}
+			w.WriteHeader(status)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		_, _ = fmt.Fprintf(w, "ok %s", body)
+	}))
+	t.Cleanup(ts.Close)
+	return ts, &calls
+}
+
+func newTestRetry(maxRetries int, delays *[]time.Duration) *Retry {
+	return &Retry{
+		MaxRetries: maxRetries,
+		BaseDelay:  100 * time.Millisecond,
+		MaxDelay:   time.Second,
+		sleep: func(_ context.Context, d time.Duration) error {
+			*delays = append(*delays, d)
+			return nil
+		},
+	}
+}
+
+func TestRetry_TransientStatus(t *testing.T) {
+	for _, status := range []int{429, 502, 503, 504} {
+		t.Run(http.StatusText(status), func(t *testing.T) {
+			ts, calls := flakyServer(t, 2, status, nil)
+			var delays []time.Duration
+			client := &http.Client{Transport: newTestRetry(3, &delays)}
+
+			resp, err := client.Get(ts.URL)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			_ = resp.Body.Close()
+			if resp.StatusCode != http.StatusOK {
+				t.Errorf("expected 200 after retries, got %d", resp.StatusCode)
+			}
+			if calls.Load() != 3 {
+				t.Errorf("expected 3 attempts, got %d", calls.Load())
+			}
+			if len(delays) != 2 {
+				t.Fatalf("expected 2 waits, got %v", delays)
+			}
+			if delays[0] < 50*time.Millisecond || delays[0] > 100*time.Millisecond {
+				t.Errorf("first delay %s outside [50ms, 100ms]", delays[0])
+			}
+			if delays[1] < 100*time.Millisecond || delays[1] > 200*time.Millisecond {
+				t.Errorf("second delay %s outside [100ms, 200ms]", delays[1])
+			}
+		})
+	}
+}
+
+func TestRetry_GivesUp(t *testing.T) {
+	ts, calls := flakyServer(t, 10, http.StatusServiceUnavailable, nil)
+	var delays []time.Duration
+	client := &http.Client{Transport: newTestRetry(2, &delays)}
+
+	resp, err := client.Get(ts.URL)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("expected the last 503 to be returned, got %d", resp.StatusCode)
+	}
+	if calls.Load() != 3 {
+		t.Errorf("expected 3 attempts, got %d", calls.Load())
+	}
+}
+
+func TestRetry_HonorsRetryAfter(t *testing.T) {
+	ts, _ := flakyServer(t, 1, http.StatusTooManyRequests, http.Header{"Retry-After": {"7"}})
+	var delays []time.Duration
+	retry := newTestRetry(3, &delays)
+	retry.MaxDelay = 10 * time.Second
+	client := &http.Client{Transport: retry}
+
+	resp, err := client.Get(ts.URL)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	_ = resp.Body.Close()
+	if len(delays) != 1 || delays[0] != 7*time.Second {
+		t.Errorf("expected a 7s wait from Retry-After, got %v", delays)
+	}
+}
+
+func TestRetry_RetryAfterLongerThanMaxDelay(t *testing.T) {
+	ts, calls := flakyServer(t, 1, http.StatusTooManyRequests, http.Header{"Retry-After": {"3600"}})
+	var delays []time.Duration
+	client := &http.Client{Transport: newTestRetry(3, &delays)}
+
+	resp, err := client.Get(ts.URL)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusTooManyRequests {
+		t.Errorf("expected the 429 to be returned, got %d", resp.StatusCode)
+	}
+	if calls.Load() != 1 || len(delays) != 0 {
+		t.Errorf("expected no retry, got %d attempts and waits %v", calls.Load(), delays)
+	}
+}
+
+func TestRetry_NonIdempotent(t *testing.T) {
+	ts, calls := flakyServer(t, 1, http.StatusServiceUnavailable, nil)
+	var delays []time.Duration
+	rt := newTestRetry(3, &delays)
+	client := &http.Client{Transport: rt}
+
+	resp, err := client.Post(ts.URL, "text/plain", strings.NewReader("payload"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusServiceUnavailable || calls.Load() != 1 {
+		t.Errorf("expected POST not to be retried, got status %d after %d calls", resp.StatusCode, calls.Load())
+	}
+
+	rt.AllMethods = true
+	resp, err = client.Post(ts.URL, "text/plain", strings.NewReader("payload"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if string(body) != "ok payload" {
+		t.Errorf("expected the body to be replayed, got %q", body)
+	}
+}
+
+func TestRetry_ConnectionReset(t *testing.T) {
+	var calls atomic.Int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			conn, _, err := w.(http.Hijacker).Hijack()
+			if err == nil {
+				_ = conn.Close()
+			}
+			return
+		}
+		_, _ = io.WriteString(w, "ok")
+	}))
+	defer ts.Close()
+
+	var delays []time.Duration
+	var logged []string
+	rt := newTestRetry(3, &delays)
+	rt.Logf = func(format string, args ...any) { logged = append(logged, fmt.Sprintf(format, args...)) }
+	client := &http.Client{Transport: rt}
+
+	resp, err := client.Get(ts.URL)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	_ = resp.Body.Close()
+	if calls.Load() != 2 {
+		t.Errorf("expected 2 attempts, got %d", calls.Load())
+	}
+	if len(logged) != 1 || !strings.Contains(logged[0], "retry 1/3") {
+		t.Errorf("expected one logged retry, got %v", logged)
+	}
+}
+
+func TestRetry_StopsWhenContextDone(t *testing.T) {
+	ts, calls := flakyServer(t, 10, http.StatusServiceUnavailable, nil)
+	rt := &Retry{MaxRetries: 5, BaseDelay: time.Hour, MaxDelay: time.Hour}
+	client := &http.Client{Transport: rt}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, ts.URL, nil)
+	if _, err := client.Do(req); err == nil {
+		t.Fatal("expected error when the context expires during backoff")
+	}
+	if calls.Load() != 1 {
+		t.Errorf("expected 1 attempt, got %d", calls.Load())
+	}
+}
@@ -0,0 +1,160 @@
+package transport
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeServerCA writes the certificate of a TLS test server as a CA bundle.
+func writeServerCA(t *testing.T, ts *httptest.Server) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "ca.pem")
+	data := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ts.Certificate().Raw})
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatalf("writing CA file: %v", err)
+	}
+	return path
+}
+
+// writeClientCert writes a self-signed client certificate and its key.
+func writeClientCert(t *testing.T) (*x509.Certificate, string, string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generating key: %v", err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "terrakube-cli"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("creating certificate: %v", err)
+	}
+	cert, _ := x509.ParseCertificate(der)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("marshaling key: %v", err)
+	}
+
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "client.pem"), filepath.Join(dir, "client-key.pem")
+	_ = os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600)
+	_ = os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600)
+	return cert, certFile, keyFile
+}
+
+func get(t *testing.T, opts Options, url string) (*http.Response, error) {
+	t.Helper()
+	base, err := NewBase(opts)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	resp, err := (&http.Client{Transport: base}).Get(url)
+	if err == nil {
+		_ = resp.Body.Close()
+	}
+	return resp, err
+}
+
+func TestNewBase_CAFile(t *testing.T) {
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer ts.Close()
+
+	_, err := get(t, Options{}, ts.URL)
+	if err == nil || !IsCertificateError(err) {
+		t.Fatalf("expected certificate error without CA file, got %v", err)
+	}
+	if _, err := get(t, Options{CAFile: writeServerCA(t, ts)}, ts.URL); err != nil {
+		t.Fatalf("expected the CA file to be trusted, got %v", err)
+	}
+}
+
+func TestNewBase_InsecureSkipVerify(t *testing.T) {
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer ts.Close()
+
+	if _, err := get(t, Options{InsecureSkipVerify: true}, ts.URL); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestNewBase_ClientCertificate(t *testing.T) {
+	cert, certFile, keyFile := writeClientCert(t)
+	clients := x509.NewCertPool()
+	clients.AddCert(cert)
+
+	ts := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(r.TLS.PeerCertificates[0].Subject.CommonName))
+	}))
+	ts.TLS = &tls.Config{ClientAuth: tls.RequireAndVerifyClientCert, ClientCAs: clients}
+	ts.StartTLS()
+	defer ts.Close()
+	ca := writeServerCA(t, ts)
+
+	if _, err := get(t, Options{CAFile: ca}, ts.URL); err == nil {
+		t.Fatal("expected the handshake to fail without a client certificate")
+	}
+	if _, err := get(t, Options{CAFile: ca, ClientCert: certFile, ClientKey: keyFile}, ts.URL); err != nil {
+		t.Fatalf("unexpected error with client certificate: %v", err)
+	}
+}
+
+func TestNewBase_Proxy(t *testing.T) {
+	var proxied string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxied = r.URL.String()
+	}))
+	defer proxy.Close()
+
+	if _, err := get(t, Options{Proxy: proxy.URL}, "http://terrakube.invalid/api/v1/organization"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if proxied != "http://terrakube.invalid/api/v1/organization" {
+		t.Errorf("expected the request to go through the proxy, got %q", proxied)
+	}
+}
+
+func TestNewBase_InvalidOptions(t *testing.T) {
+	_, certFile, _ := writeClientCert(t)
+	empty := filepath.Join(t.TempDir(), "empty.pem")
+	_ = os.WriteFile(empty, []byte("not a certificate"), 0o600)
+
+	tests := []struct {
+		name string
+		opts Options
+		want string
+	}{
+		{"missing CA file", Options{CAFile: filepath.Join(t.TempDir(), "missing.pem")}, "reading CA file"},
+		{"CA file without certificates", Options{CAFile: empty}, "no PEM certificates"},
+		{"client cert without key", Options{ClientCert: certFile}, "must be set together"},
+		{"bad client key", Options{ClientCert: certFile, ClientKey: empty}, "loading client certificate"},
+		{"proxy without scheme", Options{Proxy: "proxy.example.com:3128"}, "invalid proxy URL"},
+		{"proxy with unknown scheme", Options{Proxy: "ftp://proxy.example.com"}, "invalid proxy URL"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewBase(tt.opts)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("expected error containing %q, got %v", tt.want, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,102 @@
+package testutil
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+// OIDCIssuer is a stand-in OpenID Connect issuer supporting discovery, the
+// device authorization grant and refresh tokens.
+type OIDCIssuer struct {
+	Server *httptest.Server
+
+	// Pending is the number of authorization_pending answers sent before the
+	// device code is approved.
+	Pending int
+	// Deny makes the issuer answer access_denied instead of a token.
+	Deny bool
+
+	AccessToken  string
+	RefreshToken string
+	ExpiresIn    int
+
+	// Refreshed is set by a refresh_token grant to the token that was
+	// presented.
+	Refreshed string
+	Polls     int
+
+	mu sync.Mutex
+}
+
+// NewOIDCIssuer starts an issuer that hands out AccessToken and RefreshToken.
+func NewOIDCIssuer(t *testing.T) *OIDCIssuer {
+	t.Helper()
+	iss := &OIDCIssuer{AccessToken: "device-access-token", RefreshToken: "device-refresh-token", ExpiresIn: 3600}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]string{
+			"issuer":                        iss.Server.URL,
+			"device_authorization_endpoint": iss.Server.URL + "/device/code",
+			"token_endpoint":                iss.Server.URL + "/token",
+		})
+	})
+	mux.HandleFunc("/device/code", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]any{
+			"device_code":      "device-code",
+			"user_code":        "ABCD-EFGH",
+			"verification_uri": iss.Server.URL + "/device",
+			"expires_in":       60,
+			"interval":         1,
+		})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			t.Errorf("parsing token request: %v", err)
+		}
+
+		iss.mu.Lock()
+		defer iss.mu.Unlock()
+		switch r.PostForm.Get("grant_type") {
+		case "urn:ietf:params:oauth:grant-type:device_code":
+			iss.Polls++
+			if iss.Deny {
+				writeJSON(w, http.StatusBadRequest, map[string]string{"error": "access_denied"})
+				return
+			}
+			if iss.Polls <= iss.Pending {
+				writeJSON(w, http.StatusBadRequest, map[string]string{"error": "authorization_pending"})
+				return
+			}
+		case "refresh_token":
+			iss.Refreshed = r.PostForm.Get("refresh_token")
+		default:
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "unsupported_grant_type"})
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]any{
+			"access_token":  iss.AccessToken,
+			"refresh_token": iss.RefreshToken,
+			"token_type":    "bearer",
+			"expires_in":    iss.ExpiresIn,
+		})
+	})
+
+	iss.Server = httptest.NewServer(mux)
+	t.Cleanup(iss.Server.Close)
+	return iss
+}
+
+// URL returns the issuer URL.
+func (iss *OIDCIssuer) URL() string {
+	return iss.Server.URL
+}
+
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}